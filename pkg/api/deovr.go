@@ -19,6 +19,7 @@ import (
 	"github.com/xbapps/xbvr/pkg/config"
 	"github.com/xbapps/xbvr/pkg/models"
 	"github.com/xbapps/xbvr/pkg/session"
+	"github.com/xbapps/xbvr/pkg/tasks"
 )
 
 type DeoLibrary struct {
@@ -241,9 +242,11 @@ func (i DeoVRResource) WebService() *restful.WebService {
 	ws.Route(ws.HEAD("/").To(i.getDeoLibrary))
 
 	ws.Route(ws.GET("/").Filter(restfulAuthFilter).To(i.getDeoLibrary).
+		Param(ws.QueryParameter("query", "Scene query listed before the playlists")).
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Writes(DeoLibrary{}))
 	ws.Route(ws.POST("/").Filter(restfulAuthFilter).To(i.getDeoLibrary).
+		Param(ws.QueryParameter("query", "Scene query listed before the playlists")).
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Writes(DeoLibrary{}))
 
@@ -624,6 +627,15 @@ func (i DeoVRResource) getDeoLibrary(req *restful.Request, resp *restful.Respons
 
 	var sceneLists []DeoListScenes
 
+	// a scene query in the url is listed first, eg /deovr?query=favourite added within 7d
+	if query := req.QueryParameter("query"); query != "" && models.ValidateSceneQuery(query) == nil {
+		r := models.RequestSceneList{Query: optional.NewString(query), IsAccessible: optional.NewBool(true), IsAvailable: optional.NewBool(true)}
+		sceneLists = append(sceneLists, DeoListScenes{
+			Name: query,
+			List: scenesToDeoList(req, models.QuerySceneSummaries(r)),
+		})
+	}
+
 	var savedPlaylists []models.Playlist
 	db.Where("is_deo_enabled = ?", true).Order("ordering asc").Find(&savedPlaylists)
 
@@ -642,6 +654,17 @@ func (i DeoVRResource) getDeoLibrary(req *restful.Request, resp *restful.Respons
 		}
 	}
 
+	if recommended := tasks.RecommendedScenes(50, true); len(recommended) > 0 {
+		summaries := make([]models.SceneSummary, len(recommended))
+		for i, scene := range recommended {
+			summaries[i] = models.SceneSummary{ID: scene.ID, Title: scene.Title, Duration: uint(scene.Duration), CoverURL: scene.CoverURL, IsScripted: scene.IsScripted}
+		}
+		sceneLists = append(sceneLists, DeoListScenes{
+			Name: "Recommended",
+			List: scenesToDeoList(req, summaries),
+		})
+	}
+
 	// Add unmatched files at the end
 	var unmatched []models.File
 	db.Model(&unmatched).
@@ -688,7 +711,7 @@ func filesToDeoList(req *restful.Request, files []models.File) []DeoListItem {
 
 	list := make([]DeoListItem, 0)
 	for i := range files {
-		if files[i].Volume.Type == "local" {
+		if files[i].Volume.Type == "local" || files[i].Volume.Type == "s3" || files[i].Volume.IsRemote() {
 			if !files[i].Volume.IsAvailable {
 				continue
 			}
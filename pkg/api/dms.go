@@ -10,6 +10,7 @@ import (
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
 	"github.com/jinzhu/gorm"
+	"github.com/minio/minio-go/v7"
 
 	"github.com/xbapps/xbvr/pkg/common"
 	"github.com/xbapps/xbvr/pkg/models"
@@ -105,5 +106,72 @@ func (i DMSResource) getFile(req *restful.Request, resp *restful.Response) {
 			return
 		}
 		http.Redirect(resp.ResponseWriter, req.Request, url, http.StatusFound)
+	case "sftp", "webdav", "smb":
+		// Track current session
+		setDeoPlayerHost(req)
+		session.TrackSessionFromFile(f, doNotTrack)
+
+		fs, err := f.Volume.GetRemoteFS()
+		if err != nil {
+			log.Errorf("Can't connect to %v: %v", f.Volume.Path, err)
+			resp.WriteHeader(http.StatusBadGateway)
+			return
+		}
+		defer fs.Close()
+
+		rs, err := fs.Open(f.GetRemotePath())
+		if err != nil {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		defer rs.Close()
+
+		ctx := req.Request.Context()
+		http.ServeContent(resp.ResponseWriter, req.Request, f.Filename, f.UpdatedTime, rs)
+		select {
+		case <-ctx.Done():
+			session.FinishTrackingFromFile(doNotTrack)
+			return
+		default:
+		}
+	case "s3":
+		client, meta, err := f.Volume.GetS3Client()
+		if err != nil {
+			log.Errorf("Can't connect to %v: %v", f.Volume.Path, err)
+			resp.WriteHeader(http.StatusBadGateway)
+			return
+		}
+
+		if !meta.ProxyPlayback {
+			url, err := client.PresignedGetObject(context.Background(), meta.Bucket, f.GetRemotePath(), models.S3PresignExpiry, nil)
+			if err != nil {
+				log.Errorf("Can't presign %v: %v", f.GetRemotePath(), err)
+				resp.WriteHeader(http.StatusBadGateway)
+				return
+			}
+			http.Redirect(resp.ResponseWriter, req.Request, url.String(), http.StatusFound)
+			return
+		}
+
+		// Track current session
+		setDeoPlayerHost(req)
+		session.TrackSessionFromFile(f, doNotTrack)
+
+		// the object fetches only the requested ranges from the bucket
+		obj, err := client.GetObject(context.Background(), meta.Bucket, f.GetRemotePath(), minio.GetObjectOptions{})
+		if err != nil {
+			resp.WriteHeader(http.StatusNotFound)
+			return
+		}
+		defer obj.Close()
+
+		ctx := req.Request.Context()
+		http.ServeContent(resp.ResponseWriter, req.Request, f.Filename, f.UpdatedTime, obj)
+		select {
+		case <-ctx.Done():
+			session.FinishTrackingFromFile(doNotTrack)
+			return
+		default:
+		}
 	}
 }
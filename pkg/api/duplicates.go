@@ -0,0 +1,159 @@
+package api
+
+import (
+	"errors"
+	"net/http"
+
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/xbapps/xbvr/pkg/models"
+	"github.com/xbapps/xbvr/pkg/tasks"
+)
+
+type RequestKeepBestFile struct {
+	FileIDs []uint `json:"file_ids"`
+	// KeepFileID overrides the file picked by resolution, bitrate and codec
+	KeepFileID uint `json:"keep_file_id"`
+}
+
+type ResponseKeepBestFile struct {
+	Kept    models.File `json:"kept"`
+	Removed []uint      `json:"removed"`
+}
+
+type RequestMergeScenes struct {
+	TargetSceneID uint   `json:"target_scene_id"`
+	SceneIDs      []uint `json:"scene_ids"`
+}
+
+type DuplicatesResource struct{}
+
+func (i DuplicatesResource) WebService() *restful.WebService {
+	tags := []string{"Duplicates"}
+
+	ws := new(restful.WebService)
+
+	ws.Path("/api/duplicates").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+
+	ws.Route(ws.GET("/files").To(i.getDuplicateFiles).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes([]tasks.DuplicateFileGroup{}))
+
+	ws.Route(ws.GET("/scenes").To(i.getDuplicateScenes).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes([]tasks.DuplicateSceneGroup{}))
+
+	ws.Route(ws.POST("/files/keep-best").To(i.keepBestFile).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(RequestKeepBestFile{}).
+		Writes(ResponseKeepBestFile{}))
+
+	ws.Route(ws.POST("/scenes/merge").To(i.mergeScenes).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(RequestMergeScenes{}).
+		Writes(models.Scene{}))
+
+	return ws
+}
+
+func (i DuplicatesResource) getDuplicateFiles(req *restful.Request, resp *restful.Response) {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	resp.WriteHeaderAndEntity(http.StatusOK, tasks.FindDuplicateFiles(db))
+}
+
+func (i DuplicatesResource) getDuplicateScenes(req *restful.Request, resp *restful.Response) {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	resp.WriteHeaderAndEntity(http.StatusOK, tasks.FindDuplicateScenes(db))
+}
+
+// keepBestFile deletes all files of a duplicate group except the best one. When the kept file
+// isn't matched yet it takes over the scene of the removed files.
+func (i DuplicatesResource) keepBestFile(req *restful.Request, resp *restful.Response) {
+	var r RequestKeepBestFile
+	if err := req.ReadEntity(&r); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+	if len(r.FileIDs) < 2 {
+		APIError(req, resp, http.StatusBadRequest, errors.New("at least two files are required"))
+		return
+	}
+
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	var files []models.File
+	db.Where("id in (?) and type = ?", r.FileIDs, "video").Find(&files)
+	if len(files) != len(r.FileIDs) {
+		APIError(req, resp, http.StatusNotFound, errors.New("file not found"))
+		return
+	}
+	// the other files are deleted from disk, so they have to be duplicates of each other now
+	if !tasks.InSameDuplicateGroup(db, r.FileIDs) {
+		APIError(req, resp, http.StatusBadRequest, errors.New("the files aren't duplicates of each other"))
+		return
+	}
+
+	keep := tasks.BestDuplicateFile(files)
+	if r.KeepFileID != 0 {
+		keep = models.File{}
+		for _, f := range files {
+			if f.ID == r.KeepFileID {
+				keep = f
+			}
+		}
+		if keep.ID == 0 {
+			APIError(req, resp, http.StatusBadRequest, errors.New("keep_file_id is not one of file_ids"))
+			return
+		}
+	}
+
+	if keep.SceneID == 0 {
+		for _, f := range files {
+			if f.SceneID != 0 {
+				if err := tasks.LinkFileToScene(db, &keep, f.SceneID); err != nil {
+					APIError(req, resp, http.StatusInternalServerError, err)
+					return
+				}
+				break
+			}
+		}
+	}
+
+	removed := []uint{}
+	for _, f := range files {
+		if f.ID == keep.ID {
+			continue
+		}
+		removeFileByFileId(f.ID)
+		removed = append(removed, f.ID)
+	}
+
+	keep.GetIfExistByPK(keep.ID)
+	resp.WriteHeaderAndEntity(http.StatusOK, ResponseKeepBestFile{Kept: keep, Removed: removed})
+}
+
+func (i DuplicatesResource) mergeScenes(req *restful.Request, resp *restful.Response) {
+	var r RequestMergeScenes
+	if err := req.ReadEntity(&r); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+	if r.TargetSceneID == 0 || len(r.SceneIDs) == 0 {
+		APIError(req, resp, http.StatusBadRequest, errors.New("target_scene_id and scene_ids are required"))
+		return
+	}
+
+	scene, err := tasks.MergeScenes(r.TargetSceneID, r.SceneIDs)
+	if err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+	resp.WriteHeaderAndEntity(http.StatusOK, scene)
+}
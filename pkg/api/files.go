@@ -15,7 +15,9 @@ import (
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
 	"github.com/markphelps/optional"
+	"github.com/minio/minio-go/v7"
 	"github.com/xbapps/xbvr/pkg/models"
+	"github.com/xbapps/xbvr/pkg/tasks"
 )
 
 type RequestMatchFile struct {
@@ -37,6 +39,13 @@ type RequestFileList struct {
 	Filename    optional.String   `json:"filename"`
 }
 
+type RequestOrganise struct {
+	// Template defaults to the configured layout
+	Template string `json:"template"`
+	// FileIDs limits the run to these video files, all matched files are organised when empty
+	FileIDs []uint `json:"file_ids"`
+}
+
 type FilesResource struct{}
 
 func (i FilesResource) WebService() *restful.WebService {
@@ -65,9 +74,122 @@ func (i FilesResource) WebService() *restful.WebService {
 	ws.Route(ws.DELETE("/file/{file-id}").To(i.removeFile).
 		Metadata(restfulspec.KeyOpenAPITags, tags))
 
+	ws.Route(ws.GET("/file/{file-id}/suggestions").To(i.getFileSuggestions).
+		Param(ws.PathParameter("file-id", "File ID").DataType("int")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes(ResponseFileSuggestions{}))
+
+	ws.Route(ws.GET("/review").To(i.getReviewQueue).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes([]models.FileMatchSuggestion{}))
+
+	ws.Route(ws.POST("/review/{suggestion-id}/accept").To(i.acceptSuggestion).
+		Param(ws.PathParameter("suggestion-id", "Suggestion ID").DataType("int")).
+		Metadata(restfulspec.KeyOpenAPITags, tags))
+
+	ws.Route(ws.POST("/review/{suggestion-id}/reject").To(i.rejectSuggestion).
+		Param(ws.PathParameter("suggestion-id", "Suggestion ID").DataType("int")).
+		Metadata(restfulspec.KeyOpenAPITags, tags))
+
+	ws.Route(ws.POST("/organise/preview").To(i.previewOrganise).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(RequestOrganise{}).
+		Writes(tasks.OrganiseResult{}))
+
+	ws.Route(ws.POST("/organise").To(i.organise).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(RequestOrganise{}).
+		Writes(tasks.OrganiseResult{}))
+
+	ws.Route(ws.GET("/organise/log").To(i.getOrganiseLog).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes([]models.OrganiseAction{}))
+
+	ws.Route(ws.POST("/organise/undo/{batch-id}").To(i.undoOrganise).
+		Param(ws.PathParameter("batch-id", "Organiser run").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags))
+
 	return ws
 }
 
+type ResponseFileSuggestions struct {
+	Parsed      tasks.ParsedFilename    `json:"parsed"`
+	Suggestions []tasks.MatchSuggestion `json:"suggestions"`
+}
+
+func (i FilesResource) getFileSuggestions(req *restful.Request, resp *restful.Response) {
+	id, err := strconv.Atoi(req.PathParameter("file-id"))
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var file models.File
+	if err := file.GetIfExistByPK(uint(id)); err != nil {
+		resp.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	parsed, suggestions, err := tasks.SuggestScenesForFile(file)
+	if err != nil {
+		APIError(req, resp, http.StatusInternalServerError, err)
+		return
+	}
+	if suggestions == nil {
+		suggestions = []tasks.MatchSuggestion{}
+	}
+
+	resp.WriteHeaderAndEntity(http.StatusOK, ResponseFileSuggestions{Parsed: parsed, Suggestions: suggestions})
+}
+
+func (i FilesResource) getReviewQueue(req *restful.Request, resp *restful.Response) {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	resp.WriteHeaderAndEntity(http.StatusOK, models.GetPendingFileMatchSuggestions(db))
+}
+
+func (i FilesResource) acceptSuggestion(req *restful.Request, resp *restful.Response) {
+	id, err := strconv.Atoi(req.PathParameter("suggestion-id"))
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	var suggestion models.FileMatchSuggestion
+	if err := suggestion.GetIfExistByPK(uint(id)); err != nil {
+		resp.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	if err := tasks.LinkFileToScene(db, &suggestion.File, suggestion.SceneID); err != nil {
+		APIError(req, resp, http.StatusInternalServerError, err)
+		return
+	}
+	db.Model(&models.FileMatchSuggestion{}).Where("id = ?", suggestion.ID).Update("status", "accepted")
+
+	resp.WriteHeader(http.StatusOK)
+}
+
+func (i FilesResource) rejectSuggestion(req *restful.Request, resp *restful.Response) {
+	id, err := strconv.Atoi(req.PathParameter("suggestion-id"))
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	// rejected suggestions are kept, so the scene isn't suggested for the file again
+	db.Model(&models.FileMatchSuggestion{}).Where("id = ?", id).Update("status", "rejected")
+
+	resp.WriteHeader(http.StatusOK)
+}
+
 func (i FilesResource) getFile(req *restful.Request, resp *restful.Response) {
 	var file models.File
 
@@ -261,6 +383,7 @@ func (i FilesResource) matchFile(req *restful.Request, resp *restful.Response) {
 
 	// Finally, update scene available/accessible status
 	scene.UpdateStatus()
+	scene.UpdateFilenameIndex(db)
 
 	resp.WriteHeaderAndEntity(http.StatusOK, nil)
 }
@@ -321,6 +444,7 @@ func (i FilesResource) unmatchFile(req *restful.Request, resp *restful.Response)
 
 		// Finally, update scene available/accessible status
 		scene.UpdateStatus()
+		scene.UpdateFilenameIndex(db)
 	}
 
 	resp.WriteHeaderAndEntity(http.StatusOK, scene)
@@ -366,6 +490,31 @@ func removeFileByFileId(fileId uint) models.Scene {
 			} else {
 				log.Errorf("error deleting file %v", err)
 			}
+		case "sftp", "webdav", "smb":
+			rfs, err := file.Volume.GetRemoteFS()
+			if err != nil {
+				log.Errorf("error deleting file %v", err)
+				return scene
+			}
+			err = rfs.Remove(file.GetRemotePath())
+			rfs.Close()
+			if err == nil || errors.Is(err, fs.ErrNotExist) {
+				deleted = true
+			} else {
+				log.Errorf("error deleting file: %v", err)
+			}
+		case "s3":
+			client, meta, err := file.Volume.GetS3Client()
+			if err != nil {
+				log.Errorf("error deleting file %v", err)
+				return scene
+			}
+			err = client.RemoveObject(context.Background(), meta.Bucket, file.GetRemotePath(), minio.RemoveObjectOptions{})
+			if err == nil {
+				deleted = true
+			} else {
+				log.Errorf("error deleting file: %v", err)
+			}
 		}
 
 		if deleted {
@@ -380,3 +529,48 @@ func removeFileByFileId(fileId uint) models.Scene {
 	}
 	return scene
 }
+
+func (i FilesResource) previewOrganise(req *restful.Request, resp *restful.Response) {
+	var r RequestOrganise
+	if err := req.ReadEntity(&r); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := tasks.OrganiseFiles(r.Template, r.FileIDs, true)
+	if err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+	resp.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (i FilesResource) organise(req *restful.Request, resp *restful.Response) {
+	var r RequestOrganise
+	if err := req.ReadEntity(&r); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+
+	result, err := tasks.OrganiseFiles(r.Template, r.FileIDs, false)
+	if err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+	resp.WriteHeaderAndEntity(http.StatusOK, result)
+}
+
+func (i FilesResource) getOrganiseLog(req *restful.Request, resp *restful.Response) {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	resp.WriteHeaderAndEntity(http.StatusOK, tasks.GetOrganiseBatches(db))
+}
+
+func (i FilesResource) undoOrganise(req *restful.Request, resp *restful.Response) {
+	if err := tasks.UndoOrganise(req.PathParameter("batch-id")); err != nil {
+		APIError(req, resp, http.StatusConflict, err)
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+}
@@ -174,9 +174,11 @@ func (i HeresphereResource) WebService() *restful.WebService {
 	ws.Route(ws.HEAD("/").To(i.getHeresphereLibrary))
 
 	ws.Route(ws.GET("/").Filter(HeresphereAuthFilter).To(i.getHeresphereLibrary).
+		Param(ws.QueryParameter("query", "Scene query listed before the playlists")).
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Writes(DeoLibrary{}))
 	ws.Route(ws.POST("/").Filter(HeresphereAuthFilter).To(i.getHeresphereLibrary).
+		Param(ws.QueryParameter("query", "Scene query listed before the playlists")).
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Writes(DeoLibrary{}))
 
@@ -989,6 +991,19 @@ func (i HeresphereResource) getHeresphereLibrary(req *restful.Request, resp *res
 
 	var sceneLists []HeresphereListScenes
 
+	// a scene query in the url is listed first, eg /heresphere?query=favourite added within 7d
+	if query := req.QueryParameter("query"); query != "" && models.ValidateSceneQuery(query) == nil {
+		r := models.RequestSceneList{Query: optional.NewString(query), IsAccessible: optional.NewBool(true), IsAvailable: optional.NewBool(true)}
+		list := models.QuerySceneIDs(r)
+		for i := range list {
+			list[i] = fmt.Sprintf("%v://%v/heresphere/%v", getProto(req), req.Request.Host, list[i])
+		}
+		sceneLists = append(sceneLists, HeresphereListScenes{
+			Name: query,
+			List: list,
+		})
+	}
+
 	var savedPlaylists []models.Playlist
 	db.Where("is_deo_enabled = ?", true).Order("ordering asc").Find(&savedPlaylists)
 
@@ -1012,6 +1027,17 @@ func (i HeresphereResource) getHeresphereLibrary(req *restful.Request, resp *res
 		}
 	}
 
+	if recommended := tasks.RecommendedScenes(50, true); len(recommended) > 0 {
+		list := make([]string, len(recommended))
+		for i := range recommended {
+			list[i] = fmt.Sprintf("%v://%v/heresphere/%v", getProto(req), req.Request.Host, recommended[i].ID)
+		}
+		sceneLists = append(sceneLists, HeresphereListScenes{
+			Name: "Recommended",
+			List: list,
+		})
+	}
+
 	// Add unmatched files at the end
 	var unmatched []models.File
 	db.Model(&unmatched).
@@ -0,0 +1,161 @@
+package api
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	restfulspec "github.com/emicklei/go-restful-openapi/v2"
+	"github.com/emicklei/go-restful/v3"
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+type ResponseNotification struct {
+	ID           uint      `json:"id"`
+	CreatedAt    time.Time `json:"created_at"`
+	PlaylistID   uint      `json:"playlist_id"`
+	PlaylistName string    `json:"playlist_name"`
+	SceneID      uint      `json:"scene_id"`
+	SceneTitle   string    `json:"scene_title"`
+	Site         string    `json:"site"`
+	CoverURL     string    `json:"cover_url"`
+	IsRead       bool      `json:"is_read"`
+}
+
+type ResponseNotifications struct {
+	Unread        int                    `json:"unread"`
+	Notifications []ResponseNotification `json:"notifications"`
+}
+
+type NotificationResource struct{}
+
+func (i NotificationResource) WebService() *restful.WebService {
+	tags := []string{"Notifications"}
+
+	ws := new(restful.WebService)
+
+	ws.Path("/api/notifications").
+		Consumes(restful.MIME_JSON).
+		Produces(restful.MIME_JSON)
+
+	ws.Route(ws.GET("").To(i.listNotifications).
+		Param(ws.QueryParameter("unread", "Only list unread notifications").DataType("boolean")).
+		Param(ws.QueryParameter("playlist-id", "Only list notifications of a saved search").DataType("int")).
+		Param(ws.QueryParameter("limit", "Number of notifications, newest first").DataType("int").DefaultValue("100")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes(ResponseNotifications{}))
+
+	ws.Route(ws.POST("/{notification-id}/read").To(i.markRead).
+		Param(ws.PathParameter("notification-id", "Notification ID").DataType("int")).
+		Metadata(restfulspec.KeyOpenAPITags, tags))
+
+	ws.Route(ws.POST("/{notification-id}/unread").To(i.markUnread).
+		Param(ws.PathParameter("notification-id", "Notification ID").DataType("int")).
+		Metadata(restfulspec.KeyOpenAPITags, tags))
+
+	ws.Route(ws.POST("/read").To(i.markAllRead).
+		Param(ws.QueryParameter("playlist-id", "Only mark the notifications of a saved search").DataType("int")).
+		Metadata(restfulspec.KeyOpenAPITags, tags))
+
+	ws.Route(ws.DELETE("/{notification-id}").To(i.removeNotification).
+		Param(ws.PathParameter("notification-id", "Notification ID").DataType("int")).
+		Metadata(restfulspec.KeyOpenAPITags, tags))
+
+	ws.Route(ws.DELETE("/read").To(i.removeRead).
+		Metadata(restfulspec.KeyOpenAPITags, tags))
+
+	return ws
+}
+
+func (i NotificationResource) listNotifications(req *restful.Request, resp *restful.Response) {
+	limit, err := strconv.Atoi(req.QueryParameter("limit"))
+	if err != nil || limit <= 0 {
+		limit = 100
+	}
+
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	// notifications of deleted scenes aren't listed
+	tx := db.Table("saved_search_notifications").
+		Select(`saved_search_notifications.id, saved_search_notifications.created_at, saved_search_notifications.playlist_id,
+			playlists.name as playlist_name, saved_search_notifications.scene_id, scenes.title as scene_title,
+			scenes.site, scenes.cover_url, saved_search_notifications.is_read`).
+		Joins("join playlists on playlists.id = saved_search_notifications.playlist_id").
+		Joins("join scenes on scenes.id = saved_search_notifications.scene_id and scenes.deleted_at is null")
+	if req.QueryParameter("unread") == "true" {
+		tx = tx.Where("saved_search_notifications.is_read = ?", false)
+	}
+	if playlistID, err := strconv.Atoi(req.QueryParameter("playlist-id")); err == nil {
+		tx = tx.Where("saved_search_notifications.playlist_id = ?", playlistID)
+	}
+
+	out := ResponseNotifications{Notifications: []ResponseNotification{}}
+	tx.Order("saved_search_notifications.created_at desc, saved_search_notifications.id desc").Limit(limit).Scan(&out.Notifications)
+	out.Unread = models.CountUnreadSavedSearchNotifications(db)
+
+	resp.WriteHeaderAndEntity(http.StatusOK, out)
+}
+
+func (i NotificationResource) markRead(req *restful.Request, resp *restful.Response) {
+	i.setRead(req, resp, true)
+}
+
+func (i NotificationResource) markUnread(req *restful.Request, resp *restful.Response) {
+	i.setRead(req, resp, false)
+}
+
+func (i NotificationResource) setRead(req *restful.Request, resp *restful.Response, read bool) {
+	id, err := strconv.Atoi(req.PathParameter("notification-id"))
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	if db.Model(&models.SavedSearchNotification{}).Where("id = ?", id).Update("is_read", read).RowsAffected == 0 {
+		resp.WriteHeader(http.StatusNotFound)
+		return
+	}
+
+	resp.WriteHeader(http.StatusOK)
+}
+
+func (i NotificationResource) markAllRead(req *restful.Request, resp *restful.Response) {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	tx := db.Model(&models.SavedSearchNotification{}).Where("is_read = ?", false)
+	if playlistID, err := strconv.Atoi(req.QueryParameter("playlist-id")); err == nil {
+		tx = tx.Where("playlist_id = ?", playlistID)
+	}
+	tx.Update("is_read", true)
+
+	resp.WriteHeader(http.StatusOK)
+}
+
+func (i NotificationResource) removeNotification(req *restful.Request, resp *restful.Response) {
+	id, err := strconv.Atoi(req.PathParameter("notification-id"))
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	db.Where("id = ?", id).Delete(&models.SavedSearchNotification{})
+
+	resp.WriteHeader(http.StatusOK)
+}
+
+func (i NotificationResource) removeRead(req *restful.Request, resp *restful.Response) {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	db.Where("is_read = ?", true).Delete(&models.SavedSearchNotification{})
+
+	resp.WriteHeader(http.StatusOK)
+}
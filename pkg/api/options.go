@@ -32,9 +32,11 @@ import (
 )
 
 type NewVolumeRequest struct {
-	Type  string `json:"type"`
-	Path  string `json:"path"`
-	Token string `json:"token"`
+	Type   string                      `json:"type"`
+	Path   string                      `json:"path"`
+	Token  string                      `json:"token"`
+	Remote models.RemoteVolumeMetadata `json:"remote"`
+	S3     models.S3VolumeMetadata     `json:"s3"`
 }
 
 type VersionCheckResponse struct {
@@ -83,6 +85,8 @@ type RequestSaveOptionsAdvanced struct {
 	UseAltSrcInFileMatching      bool      `json:"useAltSrcInFileMatching"`
 	UseAltSrcInScriptFilters     bool      `json:"useAltSrcInScriptFilters"`
 	AutoLimitScraping            bool      `json:"autoLimitScraping"`
+	StageScrapedScenes           bool      `json:"stageScrapedScenes"`
+	StagingAutoApprove           string    `json:"stagingAutoApprove"`
 	IgnoreReleasedBefore         time.Time `json:"ignoreReleasedBefore"`
 	FlareSolverrAddress          string    `json:"flareSolverrAddress"`
 	UseFlareSolverr              bool      `json:"useFlareSolverr"`
@@ -91,6 +95,17 @@ type RequestSaveOptionsAdvanced struct {
 	ProxyApiKeyValue             string    `json:"proxyApiKeyValue"`
 }
 
+type RequestStagedScenes struct {
+	IDs    []uint `json:"ids"`
+	SiteID string `json:"site_id"` // all staged scenes of the site when no ids are given
+}
+
+type ResponseStagedScene struct {
+	models.StagedScene
+	Scene models.ScrapedScene     `json:"scene"`
+	Diff  []models.SceneFieldDiff `json:"diff"`
+}
+
 type RequestSaveOptionsFunscripts struct {
 	ScrapeFunscripts bool `json:"scrapeFunscripts"`
 }
@@ -140,8 +155,11 @@ type GetStateResponse struct {
 }
 
 type GetSearchStateResponse struct {
-	DocumentCount uint64 `json:"documentCount"`
-	InProgress    bool   `json:"inProgress"`
+	DocumentCount uint64     `json:"documentCount"`
+	InProgress    bool       `json:"inProgress"`
+	Pending       int        `json:"pending"`
+	LagSeconds    int        `json:"lagSeconds"`
+	LastIndexed   *time.Time `json:"lastIndexed"`
 }
 
 type GetFunscriptCountResponse struct {
@@ -203,7 +221,30 @@ type RequestSaveOptionsTaskSchedule struct {
 	AVIFHourStart    int  `json:"avifHourStart"`
 	AVIFHourEnd      int  `json:"avifHourEnd"`
 	AVIFStartDelay   int  `json:"avifStartDelay"`
+
+	PhashEnabled      bool `json:"phashEnabled"`
+	PhashHourInterval int  `json:"phashHourInterval"`
+	PhashUseRange     bool `json:"phashUseRange"`
+	PhashMinuteStart  int  `json:"phashMinuteStart"`
+	PhashHourStart    int  `json:"phashHourStart"`
+	PhashHourEnd      int  `json:"phashHourEnd"`
+	PhashStartDelay   int  `json:"phashStartDelay"`
+}
+type RequestSaveSiteSchedule struct {
+	ScheduleMode string `json:"schedule_mode"`
+	ScheduleCron string `json:"schedule_cron"`
+	ScheduleDays int    `json:"schedule_days"`
 }
+
+type RequestSaveSiteCacheTTL struct {
+	CacheTTL int `json:"cache_ttl"`
+}
+
+type ResponseHTTPCache struct {
+	Domains []scrape.HTTPCacheDomainStats `json:"domains"`
+	Sites   []scrape.HTTPCacheSiteUsage   `json:"sites"`
+}
+
 type RequestSaveSiteMatchParams struct {
 	SiteId      string                   `json:"site"`
 	MatchParams models.AltSrcMatchParams `json:"match_params"`
@@ -222,15 +263,33 @@ type RequestSCustomSiteCreate struct {
 }
 
 type GetStorageResponse struct {
-	Volumes           []models.Volume `json:"volumes"`
-	MatchOhash        bool            `json:"match_ohash"`
-	VideoExt          []string        `json:"video_ext"`
-	ForbiddenVideoExt []string        `json:"forbidden_video_ext"`
-	DefaultVideoExt   []string        `json:"default_video_ext"`
+	Volumes                 []models.Volume `json:"volumes"`
+	MatchOhash              bool            `json:"match_ohash"`
+	VideoExt                []string        `json:"video_ext"`
+	ForbiddenVideoExt       []string        `json:"forbidden_video_ext"`
+	DefaultVideoExt         []string        `json:"default_video_ext"`
+	WatchVolumes            bool            `json:"watch_volumes"`
+	WatchDebounceSeconds    int             `json:"watch_debounce_seconds"`
+	FuzzyMatch              bool            `json:"fuzzy_match"`
+	FuzzyMatchAutoLink      float64         `json:"fuzzy_match_auto_link"`
+	FuzzyMatchMinConfidence float64         `json:"fuzzy_match_min_confidence"`
+	Organise                bool            `json:"organise"`
+	OrganiseTemplate        string          `json:"organise_template"`
 }
 type RequestSaveOptionsStorage struct {
-	MatchOhash bool     `json:"match_ohash"`
-	VideoExt   []string `json:"video_ext"`
+	MatchOhash              bool     `json:"match_ohash"`
+	VideoExt                []string `json:"video_ext"`
+	WatchVolumes            bool     `json:"watch_volumes"`
+	WatchDebounceSeconds    int      `json:"watch_debounce_seconds"`
+	FuzzyMatch              bool     `json:"fuzzy_match"`
+	FuzzyMatchAutoLink      float64  `json:"fuzzy_match_auto_link"`
+	FuzzyMatchMinConfidence float64  `json:"fuzzy_match_min_confidence"`
+	Organise                bool     `json:"organise"`
+	OrganiseTemplate        string   `json:"organise_template"`
+}
+
+type RequestUpdateStorage struct {
+	NfoExport bool `json:"nfo_export"`
 }
 
 type RequestSaveCollectorConfig struct {
@@ -265,6 +324,11 @@ func (i ConfigResource) WebService() *restful.WebService {
 	ws.Route(ws.GET("/sites").To(i.listSites).
 		Metadata(restfulspec.KeyOpenAPITags, tags))
 
+	ws.Route(ws.GET("/sites/{site}/runs").To(i.listScraperRuns).
+		Param(ws.PathParameter("site", "Site ID").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes([]models.ScraperRun{}))
+
 	ws.Route(ws.PUT("/sites/{site}").To(i.toggleSite).
 		Metadata(restfulspec.KeyOpenAPITags, tags))
 
@@ -283,12 +347,68 @@ func (i ConfigResource) WebService() *restful.WebService {
 	ws.Route(ws.PUT("/sites/use_proxy/{site}").To(i.toggleUseProxy).
 		Metadata(restfulspec.KeyOpenAPITags, tags))
 
+	ws.Route(ws.PUT("/sites/cache_ttl/{site}").To(i.saveSiteCacheTTL).
+		Param(ws.PathParameter("site", "Site ID").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(RequestSaveSiteCacheTTL{}).
+		Writes([]models.Site{}))
+
+	ws.Route(ws.PUT("/sites/schedule/{site}").To(i.saveSiteSchedule).
+		Param(ws.PathParameter("site", "Site ID").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(RequestSaveSiteSchedule{}).
+		Writes([]models.Site{}))
+
 	ws.Route(ws.POST("/scraper/force-site-update").To(i.forceSiteUpdate).
 		Metadata(restfulspec.KeyOpenAPITags, tags))
 
 	ws.Route(ws.POST("/scraper/delete-scenes").To(i.deleteScenes).
 		Metadata(restfulspec.KeyOpenAPITags, tags))
 
+	ws.Route(ws.GET("/scraper/http-cache").To(i.getHTTPCache).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes(ResponseHTTPCache{}))
+
+	ws.Route(ws.DELETE("/scraper/http-cache/{site}").To(i.clearHTTPCache).
+		Param(ws.PathParameter("site", "Site ID").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes(ResponseHTTPCache{}))
+
+	ws.Route(ws.GET("/scraper/rate-limits").To(i.listRateLimits).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes([]scrape.ScraperRateLimit{}))
+
+	ws.Route(ws.PUT("/scraper/rate-limits").To(i.saveRateLimit).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Reads(scrape.ScraperRateLimit{}).
+		Writes([]scrape.ScraperRateLimit{}))
+
+	ws.Route(ws.DELETE("/scraper/rate-limits/{limiter}").To(i.deleteRateLimit).
+		Param(ws.PathParameter("limiter", "Limiter ID, usually a domain").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes([]scrape.ScraperRateLimit{}))
+
+	ws.Route(ws.POST("/scraper/rate-limits/{limiter}/reset").To(i.resetRateLimit).
+		Param(ws.PathParameter("limiter", "Limiter ID, usually a domain").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes([]scrape.ScraperRateLimit{}))
+
+	ws.Route(ws.GET("/scraper/staging").To(i.listStagedScenes).
+		Param(ws.QueryParameter("site", "Only list the staged scenes of this site").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes([]models.StagedScene{}))
+
+	ws.Route(ws.GET("/scraper/staging/{staged-id}").To(i.getStagedScene).
+		Param(ws.PathParameter("staged-id", "Staged scene ID").DataType("int")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes(ResponseStagedScene{}))
+
+	ws.Route(ws.POST("/scraper/staging/approve").To(i.approveStagedScenes).
+		Metadata(restfulspec.KeyOpenAPITags, tags))
+
+	ws.Route(ws.POST("/scraper/staging/reject").To(i.rejectStagedScenes).
+		Metadata(restfulspec.KeyOpenAPITags, tags))
+
 	ws.Route(ws.GET("/site/match_params/{site}").To(i.siteMatchParams).
 		Metadata(restfulspec.KeyOpenAPITags, tags))
 	ws.Route(ws.POST("/site/save_match_params").To(i.saveSiteMatchParams).
@@ -305,6 +425,10 @@ func (i ConfigResource) WebService() *restful.WebService {
 		Param(ws.PathParameter("storage-id", "Storage ID").DataType("int")).
 		Metadata(restfulspec.KeyOpenAPITags, tags))
 
+	ws.Route(ws.PUT("/storage/{storage-id}").To(i.updateStorage).
+		Param(ws.PathParameter("storage-id", "Storage ID").DataType("int")).
+		Metadata(restfulspec.KeyOpenAPITags, tags))
+
 	ws.Route(ws.PUT("/storage").To(i.saveOptionsStorage).
 		Metadata(restfulspec.KeyOpenAPITags, tags))
 
@@ -330,7 +454,7 @@ func (i ConfigResource) WebService() *restful.WebService {
 
 	// "Cache" section endpoints
 	ws.Route(ws.DELETE("/cache/reset/{cache}").To(i.resetCache).
-		Param(ws.PathParameter("cache", "Cache to reset - possible choices are `images`, `previews`, and `searchIndex`").DataType("string")).
+		Param(ws.PathParameter("cache", "Cache to reset - possible choices are `images`, `previews`, `scrape`, and `searchIndex`").DataType("string")).
 		Metadata(restfulspec.KeyOpenAPITags, tags))
 
 	// "Previews" section endpoints
@@ -393,6 +517,15 @@ func (i ConfigResource) listSites(req *restful.Request, resp *restful.Response)
 	i.listSitesWithDB(req, resp, db)
 }
 
+func (i ConfigResource) listScraperRuns(req *restful.Request, resp *restful.Response) {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	var runs []models.ScraperRun
+	db.Where("site_id = ?", req.PathParameter("site")).Order("id desc").Limit(50).Find(&runs)
+	resp.WriteHeaderAndEntity(http.StatusOK, runs)
+}
+
 func (i ConfigResource) toggleSite(req *restful.Request, resp *restful.Response) {
 	i.toggleSiteField(req, resp, "IsEnabled")
 }
@@ -455,6 +588,104 @@ func (i ConfigResource) toggleSiteField(req *restful.Request, resp *restful.Resp
 	i.listSitesWithDB(req, resp, db)
 }
 
+func (i ConfigResource) saveSiteSchedule(req *restful.Request, resp *restful.Response) {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	var r RequestSaveSiteSchedule
+	if err := req.ReadEntity(&r); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+	if err := tasks.ValidateSiteSchedule(r.ScheduleMode, r.ScheduleCron, r.ScheduleDays); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+
+	var site models.Site
+	if err := site.GetIfExist(req.PathParameter("site")); err != nil {
+		APIError(req, resp, http.StatusNotFound, err)
+		return
+	}
+	site.ScheduleMode = r.ScheduleMode
+	site.ScheduleCron = r.ScheduleCron
+	site.ScheduleDays = r.ScheduleDays
+	site.Save()
+
+	i.listSitesWithDB(req, resp, db)
+}
+
+func (i ConfigResource) saveSiteCacheTTL(req *restful.Request, resp *restful.Response) {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	var r RequestSaveSiteCacheTTL
+	if err := req.ReadEntity(&r); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+	if r.CacheTTL < 0 {
+		APIError(req, resp, http.StatusBadRequest, errors.New("the cache TTL can't be negative"))
+		return
+	}
+
+	var site models.Site
+	if err := site.GetIfExist(req.PathParameter("site")); err != nil {
+		APIError(req, resp, http.StatusNotFound, err)
+		return
+	}
+	site.CacheTTL = r.CacheTTL
+	site.Save()
+	scrape.LoadHTTPCachePolicies()
+
+	i.listSitesWithDB(req, resp, db)
+}
+
+func (i ConfigResource) getHTTPCache(req *restful.Request, resp *restful.Response) {
+	resp.WriteHeaderAndEntity(http.StatusOK, ResponseHTTPCache{Domains: scrape.GetHTTPCacheStats(), Sites: scrape.GetHTTPCacheUsage()})
+}
+
+func (i ConfigResource) clearHTTPCache(req *restful.Request, resp *restful.Response) {
+	if err := scrape.ClearHTTPCache(req.PathParameter("site")); err != nil {
+		APIError(req, resp, http.StatusInternalServerError, err)
+		return
+	}
+	i.getHTTPCache(req, resp)
+}
+
+func (i ConfigResource) listRateLimits(req *restful.Request, resp *restful.Response) {
+	resp.WriteHeaderAndEntity(http.StatusOK, scrape.GetScraperRateLimits())
+}
+
+func (i ConfigResource) saveRateLimit(req *restful.Request, resp *restful.Response) {
+	var r scrape.ScraperRateLimit
+	if err := req.ReadEntity(&r); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+	if err := scrape.SaveScraperRateLimit(r); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+	i.listRateLimits(req, resp)
+}
+
+func (i ConfigResource) deleteRateLimit(req *restful.Request, resp *restful.Response) {
+	if err := scrape.DeleteScraperRateLimit(req.PathParameter("limiter")); err != nil {
+		APIError(req, resp, http.StatusInternalServerError, err)
+		return
+	}
+	i.listRateLimits(req, resp)
+}
+
+func (i ConfigResource) resetRateLimit(req *restful.Request, resp *restful.Response) {
+	if err := scrape.ResetScraperRateLimit(req.PathParameter("limiter")); err != nil {
+		APIError(req, resp, http.StatusNotFound, err)
+		return
+	}
+	i.listRateLimits(req, resp)
+}
+
 func (i ConfigResource) listSitesWithDB(req *restful.Request, resp *restful.Response, db *gorm.DB) {
 	var sites []models.Site
 	switch db.Dialect().GetName() {
@@ -481,9 +712,17 @@ func (i ConfigResource) listSitesWithDB(req *restful.Request, resp *restful.Resp
 		countMap[c.ScraperID] = c.Count
 	}
 
+	latestRuns := models.GetLatestScraperRuns(db)
+
 	for idx, site := range sites {
 		sites[idx].HasScraper = scraperSet[site.ID]
 		sites[idx].SceneCount = countMap[site.ID]
+		sites[idx].Anomalies = []string{}
+		if run, ok := latestRuns[site.ID]; ok {
+			sites[idx].LastRun = &run
+			sites[idx].Anomalies = run.GetAnomalies()
+		}
+		sites[idx].NextScrape = tasks.SiteNextScrape(db, site, sites[idx].LastRun)
 	}
 	resp.WriteHeaderAndEntity(http.StatusOK, sites)
 }
@@ -588,6 +827,10 @@ func (i ConfigResource) saveOptionsAdvanced(req *restful.Request, resp *restful.
 	config.Config.Advanced.UseAltSrcInFileMatching = r.UseAltSrcInFileMatching
 	config.Config.Advanced.UseAltSrcInScriptFilters = r.UseAltSrcInScriptFilters
 	config.Config.Advanced.AutoLimitScraping = r.AutoLimitScraping
+	config.Config.Advanced.StageScrapedScenes = r.StageScrapedScenes
+	if r.StagingAutoApprove != "" {
+		config.Config.Advanced.StagingAutoApprove = r.StagingAutoApprove
+	}
 	config.Config.Advanced.IgnoreReleasedBefore = r.IgnoreReleasedBefore
 	config.Config.Advanced.FlareSolverrAddress = r.FlareSolverrAddress
 	config.Config.Advanced.UseFlareSolverr = r.UseFlareSolverr
@@ -653,7 +896,7 @@ func (i ConfigResource) listStorage(req *restful.Request, resp *restful.Response
 	defer db.Close()
 
 	var vol []models.Volume
-	db.Raw(`select id, path, last_scan,is_available, is_enabled, type,
+	db.Raw(`select id, path, last_scan,is_available, is_enabled, type, nfo_export,
        	(select count(*) from files where files.volume_id = volumes.id) as file_count,
 		(select count(*) from files where files.volume_id = volumes.id and files.scene_id = 0) as unmatched_count,
        	(select sum(files.size) from files where files.volume_id = volumes.id) as total_size
@@ -662,6 +905,13 @@ func (i ConfigResource) listStorage(req *restful.Request, resp *restful.Response
 	var out GetStorageResponse
 	out.Volumes = vol
 	out.MatchOhash = config.Config.Storage.MatchOhash
+	out.WatchVolumes = config.Config.Storage.WatchVolumes
+	out.WatchDebounceSeconds = config.Config.Storage.WatchDebounceSeconds
+	out.FuzzyMatch = config.Config.Storage.FuzzyMatch
+	out.FuzzyMatchAutoLink = config.Config.Storage.FuzzyMatchAutoLink
+	out.FuzzyMatchMinConfidence = config.Config.Storage.FuzzyMatchMinConfidence
+	out.Organise = config.Config.Storage.Organise
+	out.OrganiseTemplate = config.Config.Storage.OrganiseTemplate
 
 	// Fallback to default video extensions if none are set
 	if len(config.Config.Storage.VideoExt) == 0 {
@@ -711,6 +961,8 @@ func (i ConfigResource) addStorage(req *restful.Request, resp *restful.Response)
 
 		tlog.Info("Added new storage folder ", path)
 
+		tasks.SyncVolumeWatchers()
+
 	case "putio":
 		tokenSource := oauth2.StaticTokenSource(&oauth2.Token{AccessToken: r.Token})
 		oauthClient := oauth2.NewClient(context.Background(), tokenSource)
@@ -735,6 +987,75 @@ func (i ConfigResource) addStorage(req *restful.Request, resp *restful.Response)
 		nv := models.Volume{Path: "Put.io (" + acct.Username + ")", IsEnabled: true, IsAvailable: true, Metadata: r.Token, Type: r.Type}
 		nv.Save()
 
+		tlog.Info("Added new cloud storage ", nv.Path)
+
+	case "sftp", "webdav", "smb":
+		if r.Remote.Root == "" {
+			r.Remote.Root = "/"
+		}
+
+		fs, err := models.ConnectRemoteFS(r.Type, &r.Remote)
+		if err != nil {
+			tlog.Errorf("Can't connect to server: %v", err)
+			APIError(req, resp, 400, fmt.Errorf("Can't connect to server: %v", err))
+			return
+		}
+		fi, err := fs.Stat(r.Remote.Root)
+		fs.Close()
+		if err != nil || !fi.IsDir() {
+			tlog.Error("Path does not exist or is not a directory")
+			APIError(req, resp, 400, errors.New("Path does not exist or is not a directory"))
+			return
+		}
+
+		path := r.Remote.RemoteDisplayPath(r.Type)
+
+		var vol []models.Volume
+		db.Where(&models.Volume{Path: path}).Find(&vol)
+
+		if len(vol) > 0 {
+			tlog.Error("Network storage already exists")
+			APIError(req, resp, 400, errors.New("Network storage already exists"))
+			return
+		}
+
+		metadata, _ := json.Marshal(r.Remote)
+		nv := models.Volume{Path: path, IsEnabled: true, IsAvailable: true, Metadata: string(metadata), Type: r.Type}
+		nv.Save()
+
+		tlog.Info("Added new network storage ", nv.Path)
+
+	case "s3":
+		r.S3.Prefix = strings.Trim(r.S3.Prefix, "/")
+
+		client, err := r.S3.NewClient()
+		if err != nil {
+			tlog.Errorf("Can't connect to bucket: %v", err)
+			APIError(req, resp, 400, fmt.Errorf("Can't connect to bucket: %v", err))
+			return
+		}
+		exists, err := client.BucketExists(context.Background(), r.S3.Bucket)
+		if err != nil || !exists {
+			tlog.Errorf("Can't access bucket %v: %v", r.S3.Bucket, err)
+			APIError(req, resp, 400, errors.New("Bucket does not exist or credentials are invalid"))
+			return
+		}
+
+		path := r.S3.S3DisplayPath()
+
+		var vol []models.Volume
+		db.Where(&models.Volume{Path: path}).Find(&vol)
+
+		if len(vol) > 0 {
+			tlog.Error("Cloud storage already exists")
+			APIError(req, resp, 400, errors.New("Cloud storage already exists"))
+			return
+		}
+
+		metadata, _ := json.Marshal(r.S3)
+		nv := models.Volume{Path: path, IsEnabled: true, IsAvailable: true, Metadata: string(metadata), Type: r.Type}
+		nv.Save()
+
 		tlog.Info("Added new cloud storage ", nv.Path)
 	}
 
@@ -744,6 +1065,42 @@ func (i ConfigResource) addStorage(req *restful.Request, resp *restful.Response)
 	resp.WriteHeader(http.StatusOK)
 }
 
+func (i ConfigResource) updateStorage(req *restful.Request, resp *restful.Response) {
+	id, err := strconv.Atoi(req.PathParameter("storage-id"))
+	if err != nil {
+		resp.WriteHeader(http.StatusBadRequest)
+		return
+	}
+
+	var r RequestUpdateStorage
+	if err := req.ReadEntity(&r); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	vol := models.Volume{}
+	if err := db.First(&vol, id).Error; err == gorm.ErrRecordNotFound {
+		resp.WriteHeader(http.StatusNotFound)
+		return
+	}
+	if r.NfoExport && vol.Type != "local" {
+		APIError(req, resp, http.StatusBadRequest, errors.New("nfo files can only be exported to local folders"))
+		return
+	}
+	db.Model(&models.Volume{}).Where("id = ?", vol.ID).Update("nfo_export", r.NfoExport)
+
+	// Inform UI about state change
+	common.PublishWS("state.change.optionsStorage", nil)
+
+	if r.NfoExport {
+		go tasks.ExportNfo(false, false)
+	}
+	resp.WriteHeader(http.StatusOK)
+}
+
 func (i ConfigResource) removeStorage(req *restful.Request, resp *restful.Response) {
 	id, err := strconv.Atoi(req.PathParameter("storage-id"))
 	if err != nil {
@@ -801,6 +1158,67 @@ func (i ConfigResource) forceSiteUpdate(req *restful.Request, resp *restful.Resp
 	}
 }
 
+func (i ConfigResource) listStagedScenes(req *restful.Request, resp *restful.Response) {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	var staged []models.StagedScene
+	q := db.Order("scraper_id, id")
+	if site := req.QueryParameter("site"); site != "" {
+		q = q.Where("scraper_id = ?", site)
+	}
+	q.Find(&staged)
+	resp.WriteHeaderAndEntity(http.StatusOK, staged)
+}
+
+func (i ConfigResource) getStagedScene(req *restful.Request, resp *restful.Response) {
+	id, err := strconv.Atoi(req.PathParameter("staged-id"))
+	if err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+
+	staged, scene, diff, err := tasks.GetStagedSceneDiff(uint(id))
+	if err != nil {
+		APIError(req, resp, http.StatusNotFound, err)
+		return
+	}
+	resp.WriteHeaderAndEntity(http.StatusOK, ResponseStagedScene{StagedScene: staged, Scene: scene, Diff: diff})
+}
+
+func (i ConfigResource) approveStagedScenes(req *restful.Request, resp *restful.Response) {
+	var r RequestStagedScenes
+	if err := req.ReadEntity(&r); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+	if len(r.IDs) == 0 && r.SiteID == "" {
+		APIError(req, resp, http.StatusBadRequest, errors.New("no staged scenes selected"))
+		return
+	}
+
+	count, err := tasks.ApproveStagedScenes(r.IDs, r.SiteID)
+	if err != nil {
+		APIError(req, resp, http.StatusConflict, err)
+		return
+	}
+	resp.WriteHeaderAndEntity(http.StatusOK, map[string]int{"count": count})
+}
+
+func (i ConfigResource) rejectStagedScenes(req *restful.Request, resp *restful.Response) {
+	var r RequestStagedScenes
+	if err := req.ReadEntity(&r); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+	if len(r.IDs) == 0 && r.SiteID == "" {
+		APIError(req, resp, http.StatusBadRequest, errors.New("no staged scenes selected"))
+		return
+	}
+
+	resp.WriteHeaderAndEntity(http.StatusOK, map[string]int{"count": tasks.RejectStagedScenes(r.IDs, r.SiteID)})
+}
+
 func (i ConfigResource) deleteScenes(req *restful.Request, resp *restful.Response) {
 	var r struct {
 		ScraperId string `json:"scraper_id"`
@@ -817,15 +1235,18 @@ func (i ConfigResource) deleteScenes(req *restful.Request, resp *restful.Respons
 	var scenes []models.Scene
 	db.Where("scraper_id = ?", r.ScraperId).Find(&scenes)
 
+	var sceneIDs []string
 	for _, obj := range scenes {
 		files, _ := obj.GetFiles()
 		for _, file := range files {
 			file.SceneID = 0
 			file.Save()
 		}
+		sceneIDs = append(sceneIDs, obj.SceneID)
 	}
 
 	db.Where("scraper_id = ?", r.ScraperId).Delete(&models.Scene{})
+	models.QueueSceneIndexDelete(sceneIDs...)
 
 	// Disable limit scraping when deleting scenes to allow full re-scrape
 	var site models.Site
@@ -854,8 +1275,16 @@ func (i ConfigResource) getSearchState(req *restful.Request, resp *restful.Respo
 	var out GetSearchStateResponse
 
 	out.InProgress = models.CheckLock("index")
+	busy := tasks.SearchIndexBusy()
 	out.DocumentCount = 0
-	if !out.InProgress { // don't open if in progress, bleve open will hang
+
+	pending, lag, lastIndexed := tasks.SearchIndexLag()
+	out.Pending = pending
+	out.LagSeconds = int(lag.Seconds())
+	if !lastIndexed.IsZero() {
+		out.LastIndexed = &lastIndexed
+	}
+	if !busy { // don't open if in progress, bleve open will hang
 		idx, err := tasks.NewIndex("scenes")
 		if err == nil {
 			defer idx.Bleve.Close()
@@ -884,6 +1313,11 @@ func (i ConfigResource) resetCache(req *restful.Request, resp *restful.Response)
 		config.State.CacheSize.SearchIndex = 0
 	}
 
+	if cache == "scrape" {
+		scrape.ClearHTTPCache("")
+		config.State.CacheSize.Scrape = 0
+	}
+
 	if cache == "previews" {
 		db, _ := models.GetDB()
 		db.Model(&models.Scene{}).Where("has_video_preview = ?", true).Update("has_video_preview", false)
@@ -1036,6 +1470,9 @@ func (i ConfigResource) saveOptionsTaskSchedule(req *restful.Request, resp *rest
 	if r.AVIFHourEnd > 23 {
 		r.AVIFHourEnd -= 24
 	}
+	if r.PhashHourEnd > 23 {
+		r.PhashHourEnd -= 24
+	}
 
 	config.Config.Cron.RescrapeSchedule.Enabled = r.RescrapeEnabled
 	config.Config.Cron.RescrapeSchedule.HourInterval = r.RescrapeHourInterval
@@ -1093,6 +1530,14 @@ func (i ConfigResource) saveOptionsTaskSchedule(req *restful.Request, resp *rest
 	config.Config.Cron.AVIFConversionSchedule.HourEnd = r.AVIFHourEnd
 	config.Config.Cron.AVIFConversionSchedule.RunAtStartDelay = r.AVIFStartDelay
 
+	config.Config.Cron.PhashSchedule.Enabled = r.PhashEnabled
+	config.Config.Cron.PhashSchedule.HourInterval = r.PhashHourInterval
+	config.Config.Cron.PhashSchedule.UseRange = r.PhashUseRange
+	config.Config.Cron.PhashSchedule.MinuteStart = r.PhashMinuteStart
+	config.Config.Cron.PhashSchedule.HourStart = r.PhashHourStart
+	config.Config.Cron.PhashSchedule.HourEnd = r.PhashHourEnd
+	config.Config.Cron.PhashSchedule.RunAtStartDelay = r.PhashStartDelay
+
 	config.SaveConfig()
 
 	resp.WriteHeaderAndEntity(http.StatusOK, r)
@@ -1239,8 +1684,29 @@ func (i ConfigResource) saveOptionsStorage(req *restful.Request, resp *restful.R
 		allowedExt = config.DefaultVideoExtensions
 	}
 	config.Config.Storage.VideoExt = allowedExt
+	config.Config.Storage.WatchVolumes = r.WatchVolumes
+	if r.WatchDebounceSeconds > 0 {
+		config.Config.Storage.WatchDebounceSeconds = r.WatchDebounceSeconds
+	}
+	config.Config.Storage.FuzzyMatch = r.FuzzyMatch
+	if r.FuzzyMatchAutoLink > 0 && r.FuzzyMatchAutoLink <= 1 {
+		config.Config.Storage.FuzzyMatchAutoLink = r.FuzzyMatchAutoLink
+	}
+	if r.FuzzyMatchMinConfidence > 0 && r.FuzzyMatchMinConfidence <= 1 {
+		config.Config.Storage.FuzzyMatchMinConfidence = r.FuzzyMatchMinConfidence
+	}
+	if r.OrganiseTemplate != "" {
+		if err := tasks.ValidateOrganiseTemplate(r.OrganiseTemplate); err != nil {
+			APIError(req, resp, http.StatusBadRequest, err)
+			return
+		}
+		config.Config.Storage.OrganiseTemplate = r.OrganiseTemplate
+	}
+	config.Config.Storage.Organise = r.Organise
 	config.SaveConfig()
 
+	go tasks.SyncVolumeWatchers()
+
 	resp.WriteHeaderAndEntity(http.StatusOK, r)
 }
 
@@ -1,6 +1,7 @@
 package api
 
 import (
+	"encoding/json"
 	"net/http"
 	"strconv"
 
@@ -8,6 +9,7 @@ import (
 	"github.com/emicklei/go-restful/v3"
 	"github.com/jinzhu/gorm"
 	"github.com/xbapps/xbvr/pkg/models"
+	"github.com/xbapps/xbvr/pkg/tasks"
 )
 
 type CreateUpdatePlaylistRequest struct {
@@ -16,6 +18,7 @@ type CreateUpdatePlaylistRequest struct {
 	IsDeoEnabled bool   `json:"is_deo_enabled"`
 	PlaylistType string `json:"playlist_type"`
 	SearchParams string `json:"search_params"`
+	IsSubscribed bool   `json:"is_subscribed"`
 }
 
 type PlaylistResource struct{}
@@ -81,8 +84,16 @@ func (i PlaylistResource) createPlaylist(req *restful.Request, resp *restful.Res
 	if r.PlaylistType == "" {
 		r.PlaylistType = "scene"
 	}
+	if err := validatePlaylistQuery(r); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
 	nv := models.Playlist{Name: r.Name, IsDeoEnabled: r.IsDeoEnabled, IsSmart: r.IsSmart, PlaylistType: r.PlaylistType, SearchParams: r.SearchParams}
+	nv.IsSubscribed = r.IsSubscribed && r.IsSmart && r.PlaylistType == "scene"
 	nv.Save()
+	if nv.IsSubscribed {
+		go tasks.CheckSavedSearches()
+	}
 
 	resp.WriteHeaderAndEntity(http.StatusOK, nv)
 }
@@ -112,10 +123,31 @@ func (i PlaylistResource) updatePlaylist(req *restful.Request, resp *restful.Res
 		return
 	}
 
+	r.IsSmart = playlist.IsSmart
+	if r.PlaylistType == "" {
+		r.PlaylistType = playlist.PlaylistType
+	}
+	if err := validatePlaylistQuery(r); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+
+	// a changed search starts over, the scenes it matches now aren't new
+	subscribed := r.IsSubscribed && r.IsSmart && r.PlaylistType == "scene"
+	resubscribe := subscribed && (!playlist.IsSubscribed || playlist.SearchParams != r.SearchParams)
+	if resubscribe || (playlist.IsSubscribed && !subscribed) {
+		models.ClearSavedSearch(db, playlist.ID)
+		playlist.CheckedAt = nil
+	}
+
 	playlist.Name = r.Name
 	playlist.SearchParams = r.SearchParams
 	playlist.IsDeoEnabled = r.IsDeoEnabled
+	playlist.IsSubscribed = subscribed
 	playlist.Save()
+	if resubscribe {
+		go tasks.CheckSavedSearches()
+	}
 
 	resp.WriteHeaderAndEntity(http.StatusOK, playlist)
 }
@@ -140,6 +172,20 @@ func (i PlaylistResource) removePlaylist(req *restful.Request, resp *restful.Res
 
 	db.Where("id = ?", id).Delete(models.Playlist{})
 	db.Delete(&playlist)
+	db.Where("playlist_id = ?", id).Delete(&models.SavedSearchScene{})
+	db.Where("playlist_id = ?", id).Delete(&models.SavedSearchNotification{})
 
 	resp.WriteHeader(http.StatusOK)
 }
+
+// validatePlaylistQuery checks the query of smart scene playlists, so they can't be saved listing nothing
+func validatePlaylistQuery(r CreateUpdatePlaylistRequest) error {
+	if !r.IsSmart || r.PlaylistType != "scene" {
+		return nil
+	}
+	var params models.RequestSceneList
+	if err := json.Unmarshal([]byte(r.SearchParams), &params); err != nil || params.Query.OrElse("") == "" {
+		return nil
+	}
+	return models.ValidateSceneQuery(params.Query.OrElse(""))
+}
@@ -10,12 +10,14 @@ import (
 
 	"github.com/blevesearch/bleve/v2"
 	"github.com/blevesearch/bleve/v2/document"
+	"github.com/blevesearch/bleve/v2/search/query"
 	index "github.com/blevesearch/bleve_index_api"
 	restfulspec "github.com/emicklei/go-restful-openapi/v2"
 	"github.com/emicklei/go-restful/v3"
 	"github.com/go-test/deep"
 	"github.com/jinzhu/gorm"
 	"github.com/mozillazg/go-slugify"
+	"github.com/pkg/errors"
 
 	"github.com/xbapps/xbvr/pkg/models"
 	"github.com/xbapps/xbvr/pkg/tasks"
@@ -52,6 +54,11 @@ type RequestDeleteScene struct {
 	SceneID uint `json:"scene_id"`
 }
 
+type RequestSceneFieldLock struct {
+	Field  string `json:"field"`
+	Locked bool   `json:"locked"`
+}
+
 type RequestEditSceneDetails struct {
 	Title        string   `json:"title"`
 	Synopsis     string   `json:"synopsis"`
@@ -69,8 +76,14 @@ type RequestEditSceneDetails struct {
 }
 
 type ResponseGetScenes struct {
-	Results int            `json:"results"`
-	Scenes  []models.Scene `json:"scenes"`
+	Results int                              `json:"results"`
+	Scenes  []models.Scene                   `json:"scenes"`
+	Facets  map[string][]ResponseSearchFacet `json:"facets,omitempty"`
+}
+
+type ResponseSearchFacet struct {
+	Term  string `json:"term"`
+	Count int    `json:"count"`
 }
 
 type ResponseGetFilters struct {
@@ -116,6 +129,13 @@ func (i SceneResource) WebService() *restful.WebService {
 		Writes(ResponseGetScenes{}))
 
 	ws.Route(ws.GET("/search").To(i.searchSceneIndex).
+		Param(ws.QueryParameter("q", "Search query")).
+		Param(ws.QueryParameter("site", "Only scenes of the site, repeatable")).
+		Param(ws.QueryParameter("tag", "Only scenes with the tag, repeatable")).
+		Param(ws.QueryParameter("cast", "Only scenes with the actor, repeatable")).
+		Param(ws.QueryParameter("year", "Only scenes released in the year, repeatable")).
+		Param(ws.QueryParameter("resolution", "Only scenes with a file of the resolution, eg 8K, repeatable")).
+		Param(ws.QueryParameter("facet_size", "Number of terms per facet").DataType("int").DefaultValue("10")).
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Writes(ResponseGetScenes{}))
 
@@ -137,6 +157,10 @@ func (i SceneResource) WebService() *restful.WebService {
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Writes(models.Scene{}))
 
+	ws.Route(ws.PUT("/{scene-id}/field-lock").To(i.lockSceneField).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes([]models.SceneFieldSource{}))
+
 	ws.Route(ws.POST("/rate/{scene-id}").To(i.rateScene).
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Writes(models.Scene{}))
@@ -153,10 +177,23 @@ func (i SceneResource) WebService() *restful.WebService {
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Writes(ResponseGetScenes{}))
 
+	ws.Route(ws.GET("/recommended").To(i.getRecommendedScenes).
+		Param(ws.QueryParameter("limit", "Number of scenes").DataType("int").DefaultValue("25")).
+		Param(ws.QueryParameter("available", "Only available scenes").DataType("boolean").DefaultValue("false")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes(ResponseGetScenes{}))
+
 	ws.Route(ws.GET("/{scene-id}").To(i.getScene).
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Writes(models.Scene{}))
 
+	ws.Route(ws.GET("/{scene-id}/similar").To(i.getSimilarScenes).
+		Param(ws.PathParameter("scene-id", "Scene ID or database ID")).
+		Param(ws.QueryParameter("limit", "Number of scenes").DataType("int").DefaultValue("25")).
+		Param(ws.QueryParameter("available", "Only available scenes").DataType("boolean").DefaultValue("false")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes(ResponseGetScenes{}))
+
 	ws.Route(ws.GET("/alternate_source/{scene-id}").To(i.getSceneAlternateSources).
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Writes(ResponseGetAlternateSources{}))
@@ -242,6 +279,7 @@ func (i SceneResource) deleteScene(req *restful.Request, resp *restful.Response)
 		file.Save()
 	}
 	db.Delete(&scene)
+	models.QueueSceneIndexDelete(scene.SceneID)
 	resp.WriteHeaderAndEntity(http.StatusOK, scene)
 }
 
@@ -457,11 +495,46 @@ func (i SceneResource) getScene(req *restful.Request, resp *restful.Response) {
 		}
 		_ = scene.GetIfExistByPK(uint(id))
 	}
+	scene.FieldSources = models.GetSceneFieldSources(db, scene.ID)
 	db.Close()
 
 	resp.WriteHeaderAndEntity(http.StatusOK, scene)
 }
 
+func (i SceneResource) getSimilarScenes(req *restful.Request, resp *restful.Response) {
+	var scene models.Scene
+	if strings.Contains(req.PathParameter("scene-id"), "-") {
+		scene.GetIfExist(req.PathParameter("scene-id"))
+	} else if id, err := strconv.Atoi(req.PathParameter("scene-id")); err == nil {
+		scene.GetIfExistByPK(uint(id))
+	}
+	if scene.ID == 0 {
+		APIError(req, resp, http.StatusNotFound, errors.New("scene not found"))
+		return
+	}
+
+	limit, available := recommendParams(req)
+	scenes := tasks.SimilarScenes(scene.ID, limit, available)
+	resp.WriteHeaderAndEntity(http.StatusOK, ResponseGetScenes{Results: len(scenes), Scenes: scenes})
+}
+
+func (i SceneResource) getRecommendedScenes(req *restful.Request, resp *restful.Response) {
+	limit, available := recommendParams(req)
+	scenes := tasks.RecommendedScenes(limit, available)
+	resp.WriteHeaderAndEntity(http.StatusOK, ResponseGetScenes{Results: len(scenes), Scenes: scenes})
+}
+
+func recommendParams(req *restful.Request) (int, bool) {
+	limit, err := strconv.Atoi(req.QueryParameter("limit"))
+	if err != nil || limit <= 0 {
+		limit = 25
+	}
+	if limit > 200 {
+		limit = 200
+	}
+	return limit, req.QueryParameter("available") == "true"
+}
+
 func (i SceneResource) getScenes(req *restful.Request, resp *restful.Response) {
 	var r models.RequestSceneList
 	err := req.ReadEntity(&r)
@@ -469,6 +542,12 @@ func (i SceneResource) getScenes(req *restful.Request, resp *restful.Response) {
 		log.Error(err)
 		return
 	}
+	if query := strings.TrimSpace(r.Query.OrElse("")); query != "" {
+		if err := models.ValidateSceneQuery(query); err != nil {
+			APIError(req, resp, http.StatusBadRequest, err)
+			return
+		}
+	}
 
 	out := models.QueryScenes(r, true)
 	resp.WriteHeaderAndEntity(http.StatusOK, out)
@@ -534,7 +613,7 @@ func (i SceneResource) getSearchFields(req *restful.Request, resp *restful.Respo
 	db, _ := models.GetDB()
 	defer db.Close()
 
-	if models.CheckLock("index") {
+	if tasks.SearchIndexBusy() {
 		results = append(results, ResponseSceneSearchValue{"Error", "Search indexes locked - reindex in progress"})
 		resp.WriteHeaderAndEntity(http.StatusOK, results)
 		return
@@ -585,11 +664,28 @@ func (i SceneResource) getSearchFields(req *restful.Request, resp *restful.Respo
 func (i SceneResource) searchSceneIndex(req *restful.Request, resp *restful.Response) {
 	q := req.QueryParameter("q")
 
+	// facet values narrow the search down to scenes having them
+	var drillDown []query.Query
+	for name, field := range tasks.SearchFacets {
+		for _, value := range req.Request.URL.Query()[name] {
+			termQuery := bleve.NewTermQuery(value)
+			termQuery.SetField(field)
+			drillDown = append(drillDown, termQuery)
+		}
+	}
+	facetSize, err := strconv.Atoi(req.QueryParameter("facet_size"))
+	if err != nil || facetSize <= 0 {
+		facetSize = 10
+	}
+
 	db, _ := models.GetDB()
 	defer db.Close()
 	var scenes []models.Scene
 
-	if strings.HasPrefix(q, "http") {
+	// urls and filenames can't be narrowed down by facets
+	matchOther := q != "" && len(drillDown) == 0
+
+	if matchOther && strings.HasPrefix(q, "http") {
 		// if searching for a link, see if it is in the external ref table for scene alternate source
 		var scene models.Scene
 		splits := strings.Split(q, "?")
@@ -612,9 +708,9 @@ func (i SceneResource) searchSceneIndex(req *restful.Request, resp *restful.Resp
 	}
 
 	var fileScenes []models.File
-	if path != "" {
+	if matchOther && path != "" {
 		db.Where("path like ? and filename like ? and scene_id > 0", "%"+path+"%", "%"+filename+"%").Find(&fileScenes)
-	} else {
+	} else if matchOther {
 		db.Where("filename like ? and scene_id > 0", "%"+filename+"%").Find(&fileScenes)
 	}
 
@@ -632,14 +728,18 @@ func (i SceneResource) searchSceneIndex(req *restful.Request, resp *restful.Resp
 		}
 	}
 
-	// search bleve search indexes
+	// search bleve search indexes, unless opening them would wait for the indexer
+	if tasks.SearchIndexBusy() {
+		resp.WriteHeaderAndEntity(http.StatusOK, ResponseGetScenes{Results: len(scenes), Scenes: scenes})
+		return
+	}
 	idx, err := tasks.NewIndex("scenes")
 	if err != nil {
 		log.Error(err)
 		resp.WriteHeaderAndEntity(http.StatusOK, ResponseGetScenes{Results: len(scenes), Scenes: scenes})
 		return
 	}
-	if strings.HasPrefix(q, "http") {
+	if matchOther && strings.HasPrefix(q, "http") {
 		// if searching for a link, see if it is in the external ref table for scene alternate source
 		var extref models.ExternalReference
 		var scene models.Scene
@@ -666,14 +766,25 @@ func (i SceneResource) searchSceneIndex(req *restful.Request, resp *restful.Resp
 	}
 
 	defer idx.Bleve.Close()
-	query := bleve.NewQueryStringQuery(q)
+	var searchQuery query.Query
+	if q != "" {
+		searchQuery = bleve.NewQueryStringQuery(q)
+	} else {
+		searchQuery = bleve.NewMatchAllQuery()
+	}
+	if len(drillDown) > 0 {
+		searchQuery = bleve.NewConjunctionQuery(append([]query.Query{searchQuery}, drillDown...)...)
+	}
 
-	searchRequest := bleve.NewSearchRequest(query)
+	searchRequest := bleve.NewSearchRequest(searchQuery)
 	searchRequest.Fields = []string{"Id", "title", "cast", "site", "description"}
 	searchRequest.IncludeLocations = true
 	searchRequest.From = 0
 	searchRequest.Size = 25
 	searchRequest.SortBy([]string{"-_score"})
+	for name, field := range tasks.SearchFacets {
+		searchRequest.AddFacet(name, bleve.NewFacetRequest(field, facetSize))
+	}
 
 	searchResults, err := idx.Bleve.Search(searchRequest)
 	if err != nil {
@@ -697,7 +808,18 @@ func (i SceneResource) searchSceneIndex(req *restful.Request, resp *restful.Resp
 		}
 	}
 
-	resp.WriteHeaderAndEntity(http.StatusOK, ResponseGetScenes{Results: len(scenes), Scenes: scenes})
+	facets := make(map[string][]ResponseSearchFacet, len(searchResults.Facets))
+	for name, facet := range searchResults.Facets {
+		facets[name] = []ResponseSearchFacet{}
+		if facet.Terms == nil {
+			continue
+		}
+		for _, term := range facet.Terms.Terms() {
+			facets[name] = append(facets[name], ResponseSearchFacet{Term: term.Term, Count: term.Count})
+		}
+	}
+
+	resp.WriteHeaderAndEntity(http.StatusOK, ResponseGetScenes{Results: len(scenes), Scenes: scenes, Facets: facets})
 }
 
 func (i SceneResource) addSceneCuepoint(req *restful.Request, resp *restful.Response) {
@@ -735,6 +857,34 @@ func (i SceneResource) addSceneCuepoint(req *restful.Request, resp *restful.Resp
 	resp.WriteHeaderAndEntity(http.StatusOK, scene)
 }
 
+func (i SceneResource) lockSceneField(req *restful.Request, resp *restful.Response) {
+	sceneId, err := strconv.Atoi(req.PathParameter("scene-id"))
+	if err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+
+	var r RequestSceneFieldLock
+	if err := req.ReadEntity(&r); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	var scene models.Scene
+	if err := scene.GetIfExistByPK(uint(sceneId)); err != nil {
+		APIError(req, resp, http.StatusNotFound, err)
+		return
+	}
+	if err := models.LockSceneField(db, scene.ID, r.Field, r.Locked); err != nil {
+		APIError(req, resp, http.StatusBadRequest, err)
+		return
+	}
+	resp.WriteHeaderAndEntity(http.StatusOK, models.GetSceneFieldSources(db, scene.ID))
+}
+
 func (i SceneResource) deleteSceneCuepoint(req *restful.Request, resp *restful.Response) {
 	sceneId, err := strconv.Atoi(req.PathParameter("scene-id"))
 	if err != nil {
@@ -851,13 +1001,16 @@ func (i SceneResource) editScene(req *restful.Request, resp *restful.Response) {
 	defer db.Close()
 	err = scene.GetIfExistByPK(uint(sceneId))
 	if err == nil {
+		var editedFields []string
 		if scene.Title != r.Title {
 			scene.Title = r.Title
 			models.AddAction(scene.SceneID, "edit", "title", r.Title)
+			editedFields = append(editedFields, "title")
 		}
 		if scene.Synopsis != r.Synopsis {
 			scene.Synopsis = r.Synopsis
 			models.AddAction(scene.SceneID, "edit", "synopsis", r.Synopsis)
+			editedFields = append(editedFields, "synopsis")
 		}
 		if scene.Studio != r.Studio {
 			scene.Studio = r.Studio
@@ -875,6 +1028,7 @@ func (i SceneResource) editScene(req *restful.Request, resp *restful.Response) {
 			scene.ReleaseDateText = r.ReleaseDate
 			scene.ReleaseDate, _ = time.Parse("2006-01-02", r.ReleaseDate)
 			models.AddAction(scene.SceneID, "edit", "release_date_text", r.ReleaseDate)
+			editedFields = append(editedFields, "release_date")
 		}
 		if scene.FilenamesArr != r.FilenamesArr {
 			scene.FilenamesArr = r.FilenamesArr
@@ -887,6 +1041,7 @@ func (i SceneResource) editScene(req *restful.Request, resp *restful.Response) {
 		if scene.CoverURL != r.CoverURL {
 			scene.CoverURL = r.CoverURL
 			models.AddAction(scene.SceneID, "edit", "cover_url", r.CoverURL)
+			editedFields = append(editedFields, "cover")
 		}
 		if scene.IsMultipart != r.IsMultipart {
 			scene.IsMultipart = r.IsMultipart
@@ -919,9 +1074,12 @@ func (i SceneResource) editScene(req *restful.Request, resp *restful.Response) {
 			for _, v := range newCast {
 				db.Model(&scene).Association("Cast").Append(&v)
 			}
+			editedFields = append(editedFields, "cast")
 		}
 
 		scene.Save()
+		models.SetSceneFieldSources(db, scene.ID, models.FieldSourceManual, "", editedFields...)
+		scene.UpdateFilenameIndex(db)
 
 		// Update search index with new data
 		scenes := []models.Scene{scene}
@@ -1008,6 +1166,7 @@ func ProcessTagChanges(scene *models.Scene, tags *[]string, db *gorm.DB) {
 		for _, v := range newTags {
 			db.Model(&scene).Association("Tags").Append(&v)
 		}
+		models.SetSceneFieldSources(db, scene.ID, models.FieldSourceManual, "", "tags")
 	}
 }
 func (i SceneResource) getSceneAlternateSources(req *restful.Request, resp *restful.Response) {
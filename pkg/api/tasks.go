@@ -2,6 +2,7 @@ package api
 
 import (
 	"encoding/json"
+	"errors"
 	"net/http"
 	"strconv"
 	"strings"
@@ -71,6 +72,10 @@ func (i TaskResource) WebService() *restful.WebService {
 	ws.Route(ws.GET("/scrape").To(i.scrape).
 		Metadata(restfulspec.KeyOpenAPITags, tags))
 
+	ws.Route(ws.GET("/scrape/cancel").To(i.cancelScrape).
+		Param(ws.QueryParameter("site", "Only cancel the scraper of this site").DataType("string")).
+		Metadata(restfulspec.KeyOpenAPITags, tags))
+
 	ws.Route(ws.POST("/singlescrape").To(i.singleScrape).
 		Metadata(restfulspec.KeyOpenAPITags, tags).
 		Writes(ResponseSceneScrape{}))
@@ -81,6 +86,15 @@ func (i TaskResource) WebService() *restful.WebService {
 	ws.Route(ws.GET("/preview/generate").To(i.previewGenerate).
 		Metadata(restfulspec.KeyOpenAPITags, tags))
 
+	ws.Route(ws.GET("/phash/generate").To(i.phashGenerate).
+		Metadata(restfulspec.KeyOpenAPITags, tags))
+
+	ws.Route(ws.GET("/nfo/export").To(i.nfoExport).
+		Param(ws.QueryParameter("dry_run", "Only list the files that would be written").DataType("boolean")).
+		Param(ws.QueryParameter("force", "Write all files, not only the ones of changed scenes").DataType("boolean")).
+		Metadata(restfulspec.KeyOpenAPITags, tags).
+		Writes([]tasks.NfoExport{}))
+
 	ws.Route(ws.GET("/funscript/export-all").To(i.exportAllFunscripts).
 		Metadata(restfulspec.KeyOpenAPITags, tags))
 
@@ -137,6 +151,14 @@ func (i TaskResource) scrape(req *restful.Request, resp *restful.Response) {
 	qQuick := req.QueryParameter("quick")
 	go tasks.Scrape(qSiteID, "", "", qQuick == "true")
 }
+func (i TaskResource) cancelScrape(req *restful.Request, resp *restful.Response) {
+	if !tasks.CancelScrape(req.QueryParameter("site")) {
+		APIError(req, resp, http.StatusNotFound, errors.New("nothing is being scraped"))
+		return
+	}
+	resp.WriteHeader(http.StatusOK)
+}
+
 func (i TaskResource) singleScrape(req *restful.Request, resp *restful.Response) {
 	var scrapeParams RequestSingleScrape
 	req.ReadEntity(&scrapeParams)
@@ -206,6 +228,20 @@ func (i TaskResource) previewGenerate(req *restful.Request, resp *restful.Respon
 	go tasks.GeneratePreviews(nil)
 }
 
+func (i TaskResource) phashGenerate(req *restful.Request, resp *restful.Response) {
+	go tasks.GeneratePhashes(nil)
+}
+
+func (i TaskResource) nfoExport(req *restful.Request, resp *restful.Response) {
+	dryRun := req.QueryParameter("dry_run") == "true"
+	force := req.QueryParameter("force") == "true"
+	if dryRun {
+		resp.WriteHeaderAndEntity(http.StatusOK, tasks.ExportNfo(true, force))
+		return
+	}
+	go tasks.ExportNfo(false, force)
+}
+
 func (i TaskResource) scrapeJAVR(req *restful.Request, resp *restful.Response) {
 	var r RequestScrapeJAVR
 	err := req.ReadEntity(&r)
@@ -6,6 +6,7 @@ import (
 	"io"
 	"os"
 	"path/filepath"
+	"strings"
 
 	"github.com/ProtonMail/go-appdir"
 )
@@ -42,6 +43,36 @@ func DirSize(path string) (int64, error) {
 	return size, err
 }
 
+// parseKnownFlags parses the command line flags registered so far. InitPaths runs from a package init, flags
+// registered later, such as the flags of go test, are left to their own parsing.
+func parseKnownFlags() {
+	var args []string
+	for i := 1; i < len(os.Args); i++ {
+		arg := os.Args[i]
+		if arg == "--" {
+			break
+		}
+		if !strings.HasPrefix(arg, "-") {
+			continue
+		}
+		name, _, hasValue := strings.Cut(strings.TrimLeft(arg, "-"), "=")
+		f := flag.Lookup(name)
+		if f == nil {
+			// -h and -help print the usage
+			if name == "h" || name == "help" {
+				args = append(args, arg)
+			}
+			continue
+		}
+		args = append(args, arg)
+		if b, ok := f.Value.(interface{ IsBoolFlag() bool }); !hasValue && !(ok && b.IsBoolFlag()) && i+1 < len(os.Args) {
+			args = append(args, os.Args[i+1])
+			i++
+		}
+	}
+	flag.CommandLine.Parse(args)
+}
+
 func InitPaths() {
 
 	enableLocalStorage := flag.Bool("localstorage", false, "Optional: Use local folder to store application data")
@@ -58,7 +89,7 @@ func InitPaths() {
 	db_connection_pool_size := flag.Int("db_connection_pool_size", 0, "Optional: sets a limit to the number of db connections while scraping")
 	concurrentSscrapers := flag.Int("concurrent_scrapers", 0, "Optional: sets a limit to the number of concurrent scrapers")
 
-	flag.Parse()
+	parseKnownFlags()
 
 	if *app_dir == "" {
 		tmp := os.Getenv("XBVR_APPDIR")
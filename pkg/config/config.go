@@ -69,6 +69,8 @@ type ObjectConfig struct {
 		UseAltSrcInFileMatching      bool      `default:"true" json:"useAltSrcInFileMatching"`
 		UseAltSrcInScriptFilters     bool      `default:"true" json:"useAltSrcInScriptFilters"`
 		AutoLimitScraping            bool      `default:"true" json:"autoLimitScraping"`
+		StageScrapedScenes           bool      `default:"false" json:"stageScrapedScenes"`
+		StagingAutoApprove           string    `default:"new" json:"stagingAutoApprove"` // none, new or all, for scheduled scrapes
 		IgnoreReleasedBefore         time.Time `json:"ignoreReleasedBefore"`
 		FlareSolverrAddress          string    `json:"flareSolverrAddress"`
 		UseFlareSolverr              bool      `json:"useFlareSolverr"`
@@ -192,10 +194,26 @@ type ObjectConfig struct {
 			HourEnd         int  `default:"6" json:"hourEnd"`
 			RunAtStartDelay int  `default:"0" json:"runAtStartDelay"`
 		} `json:"avifConversionSchedule"`
+		PhashSchedule struct {
+			Enabled         bool `default:"false" json:"enabled"`
+			HourInterval    int  `default:"2" json:"hourInterval"`
+			UseRange        bool `default:"false" json:"useRange"`
+			MinuteStart     int  `default:"0" json:"minuteStart"`
+			HourStart       int  `default:"0" json:"hourStart"`
+			HourEnd         int  `default:"23" json:"hourEnd"`
+			RunAtStartDelay int  `default:"0" json:"runAtStartDelay"`
+		} `json:"phashSchedule"`
 	} `json:"cron"`
 	Storage struct {
-		MatchOhash bool     `default:"false" json:"match_ohash"`
-		VideoExt   []string `json:"video_ext"`
+		MatchOhash              bool     `default:"false" json:"match_ohash"`
+		VideoExt                []string `json:"video_ext"`
+		WatchVolumes            bool     `default:"true" json:"watch_volumes"`
+		WatchDebounceSeconds    int      `default:"10" json:"watch_debounce_seconds"`
+		FuzzyMatch              bool     `default:"true" json:"fuzzy_match"`
+		FuzzyMatchAutoLink      float64  `default:"0.9" json:"fuzzy_match_auto_link"`
+		FuzzyMatchMinConfidence float64  `default:"0.4" json:"fuzzy_match_min_confidence"`
+		Organise                bool     `default:"false" json:"organise"`
+		OrganiseTemplate        string   `default:"{site}/{release_date:2006}/{title} - {cast} [{projection}].{ext}" json:"organise_template"`
 	} `json:"storage"`
 	ScraperSettings struct {
 		TMWVRNet struct {
@@ -50,6 +50,7 @@ type ObjectState struct {
 		Images      int64 `json:"images"`
 		Previews    int64 `json:"previews"`
 		SearchIndex int64 `json:"searchIndex"`
+		Scrape      int64 `json:"scrape"`
 	} `json:"cacheSize"`
 }
 
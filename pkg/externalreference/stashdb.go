@@ -49,6 +49,7 @@ func ApplySceneRules() {
 	tlog.Infof("Starting Scene Rule Matching")
 
 	matchOnSceneUrl()
+	matchOnPhash()
 	config := models.BuildActorScraperRules()
 
 	for sitename, configSite := range config.StashSceneMatching {
@@ -135,6 +136,72 @@ func matchOnSceneUrl() {
 		}
 	}
 }
+
+// links unmatched stashdb scenes to the scene of a file with a similar perceptual hash, only when a single scene matches
+func matchOnPhash() {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	type hashedFile struct {
+		SceneID       uint
+		SceneRef      string
+		Phash         string
+		VideoDuration float64
+	}
+	var files []hashedFile
+	db.Table("files").
+		Select("files.scene_id, scenes.scene_id as scene_ref, files.phash, files.video_duration").
+		Joins("join scenes on scenes.id = files.scene_id").
+		Joins("left join external_reference_links erl on erl.internal_db_id = scenes.id and erl.external_source = 'stashdb scene'").
+		Where("files.phash <> '' and files.type = 'video' and erl.id is null").
+		Scan(&files)
+	if len(files) == 0 {
+		return
+	}
+
+	var stashScenes []models.ExternalReference
+	db.Joins("Left JOIN external_reference_links erl on erl.external_reference_id = external_references.id").
+		Where("external_references.external_source = ? and erl.internal_db_id is null and external_data like ?", "stashdb scene", "%PHASH%").
+		Find(&stashScenes)
+
+	linked := map[uint]bool{}
+	for _, stashScene := range stashScenes {
+		var data models.StashScene
+		json.Unmarshal([]byte(stashScene.ExternalData), &data)
+
+		var match hashedFile
+		matchCnt := 0
+		for _, fp := range data.Fingerprints {
+			if fp.Algorithm != "PHASH" {
+				continue
+			}
+			for _, file := range files {
+				if linked[file.SceneID] || file.SceneID == match.SceneID {
+					continue
+				}
+				distance := models.PhashDistance(fp.Hash, file.Phash)
+				if distance < 0 || distance > models.PhashMatchDistance {
+					continue
+				}
+				// cuts of a scene share frames, also require a similar duration
+				if fp.Duration > 0 && math.Abs(float64(fp.Duration)-file.VideoDuration) > 5 {
+					continue
+				}
+				match = file
+				matchCnt += 1
+			}
+		}
+		if matchCnt == 1 {
+			xbvrLink := models.ExternalReferenceLink{InternalTable: "scenes", InternalDbId: match.SceneID, InternalNameId: match.SceneRef,
+				ExternalReferenceID: stashScene.ID, ExternalSource: stashScene.ExternalSource, ExternalId: stashScene.ExternalId, MatchType: 15}
+			stashScene.XbvrLinks = append(stashScene.XbvrLinks, xbvrLink)
+			stashScene.Save()
+			linked[match.SceneID] = true
+			log.Infof("Scene %s matched to Stashdb scene %s using phash", match.SceneRef, stashScene.ExternalId)
+		}
+	}
+}
+
 func removeQueryFromURL(rawURL string) string {
 	parsedURL, err := url.Parse(rawURL)
 	if err != nil {
@@ -0,0 +1,39 @@
+package ffprobe
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"net"
+	"net/http"
+	"net/url"
+	"path"
+	"time"
+)
+
+// GetProbeDataReader probes media that is not available as a local file, such as a file on a network volume.
+// The media is served to ffprobe over a temporary loopback http server, so ffprobe can seek with range requests
+// and only reads the parts of the file it needs. open is called once per request made by ffprobe.
+func GetProbeDataReader(name string, modtime time.Time, open func() (io.ReadSeekCloser, error), timeout time.Duration) (data *ProbeData, err error) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		return nil, err
+	}
+
+	srv := &http.Server{Handler: http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rs, err := open()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		defer rs.Close()
+		http.ServeContent(w, r, name, modtime, rs)
+	})}
+	go srv.Serve(ln)
+	defer srv.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+
+	return GetProbeDataContext(ctx, fmt.Sprintf("http://%v/%v", ln.Addr().String(), url.PathEscape(path.Base(name))))
+}
@@ -184,6 +184,17 @@ func Migrate(migrateTo string) {
 				return tx.AutoMigrate(Playlist{}).Error
 			},
 		},
+		{
+			// listed ahead of 0009-create-default-lists, which saves playlists with the current model on new databases
+			ID: "0096-playlist-subscription-columns",
+			Migrate: func(tx *gorm.DB) error {
+				type Playlist struct {
+					IsSubscribed bool
+					CheckedAt    *time.Time
+				}
+				return tx.AutoMigrate(Playlist{}).Error
+			},
+		},
 		{
 			ID: "0009-create-default-lists",
 			Migrate: func(tx *gorm.DB) error {
@@ -847,6 +858,221 @@ func Migrate(migrateTo string) {
 				return tx.AutoMigrate(File{}).Error
 			},
 		},
+		{
+			ID: "0084-file-etag",
+			Migrate: func(tx *gorm.DB) error {
+				type File struct {
+					ETag string `json:"etag"`
+				}
+				return tx.AutoMigrate(File{}).Error
+			},
+		},
+		{
+			ID: "0085-scene-filenames",
+			Migrate: func(tx *gorm.DB) error {
+				type SceneFilename struct {
+					ID                  uint   `gorm:"primary_key"`
+					SceneID             uint   `gorm:"index"`
+					ExternalReferenceID uint   `gorm:"index"`
+					Filename            string `gorm:"index;size:500"`
+				}
+				return tx.AutoMigrate(SceneFilename{}).Error
+			},
+		},
+		{
+			ID: "0086-file-match-suggestions",
+			Migrate: func(tx *gorm.DB) error {
+				type FileMatchSuggestion struct {
+					ID         uint `gorm:"primary_key"`
+					CreatedAt  time.Time
+					UpdatedAt  time.Time
+					FileID     uint `gorm:"index"`
+					SceneID    uint `gorm:"index"`
+					Confidence float64
+					Reasons    string `sql:"type:text;"`
+					Status     string
+				}
+				return tx.AutoMigrate(FileMatchSuggestion{}).Error
+			},
+		},
+		{
+			ID: "0087-file-phash",
+			Migrate: func(tx *gorm.DB) error {
+				type File struct {
+					Phash string `gorm:"index" json:"phash"`
+				}
+				if err := tx.AutoMigrate(File{}).Error; err != nil {
+					return err
+				}
+				return tx.Exec("update files set phash = '' where phash is null").Error
+			},
+		},
+		{
+			ID: "0088-organise-actions",
+			Migrate: func(tx *gorm.DB) error {
+				type OrganiseAction struct {
+					ID        uint `gorm:"primary_key"`
+					CreatedAt time.Time
+					BatchID   string `gorm:"index"`
+					FileID    uint   `gorm:"index"`
+					OldPath   string `sql:"type:text;"`
+					NewPath   string `sql:"type:text;"`
+					UndoneAt  *time.Time
+				}
+				return tx.AutoMigrate(OrganiseAction{}).Error
+			},
+		},
+		{
+			ID: "0089-nfo-export",
+			Migrate: func(tx *gorm.DB) error {
+				type Volume struct {
+					NfoExport bool `json:"nfo_export"`
+				}
+				type File struct {
+					NfoExportedAt time.Time
+				}
+				if err := tx.AutoMigrate(Volume{}).Error; err != nil {
+					return err
+				}
+				if err := tx.AutoMigrate(File{}).Error; err != nil {
+					return err
+				}
+				if err := tx.Exec("update volumes set nfo_export = ? where nfo_export is null", false).Error; err != nil {
+					return err
+				}
+				return tx.Exec("update files set nfo_exported_at = '0000-00-00' where nfo_exported_at is null").Error
+			},
+		},
+		{
+			ID: "0090-scraper-runs",
+			Migrate: func(tx *gorm.DB) error {
+				type ScraperRun struct {
+					ID              uint `gorm:"primary_key"`
+					CreatedAt       time.Time
+					SiteID          string `gorm:"index"`
+					StartedAt       time.Time
+					DurationSeconds float64
+					LimitScraping   bool
+					Pages           int
+					HttpErrors      int
+					HttpStatuses    string `sql:"type:text;"`
+					Error           string `sql:"type:text;"`
+					Scenes          int
+					NewScenes       int
+					UpdatedScenes   int
+					MissingTitle    int
+					MissingCover    int
+					MissingCast     int
+					MissingTags     int
+					MissingDuration int
+					MissingReleased int
+					MissingSynopsis int
+					Anomalies       string `sql:"type:text;"`
+				}
+				return tx.AutoMigrate(ScraperRun{}).Error
+			},
+		},
+		{
+			ID: "0091-staged-scenes",
+			Migrate: func(tx *gorm.DB) error {
+				type StagedScene struct {
+					ID        uint `gorm:"primary_key"`
+					CreatedAt time.Time
+					UpdatedAt time.Time
+					SceneID   string `gorm:"unique_index"`
+					ScraperID string `gorm:"index"`
+					Title     string
+					IsNew     bool
+					Data      string `sql:"type:longtext;"`
+				}
+				return tx.AutoMigrate(StagedScene{}).Error
+			},
+		},
+		{
+			ID: "0092-scene-field-sources",
+			Migrate: func(tx *gorm.DB) error {
+				type SceneFieldSource struct {
+					ID        uint `gorm:"primary_key"`
+					UpdatedAt time.Time
+					SceneID   uint   `gorm:"unique_index:idx_scene_field_source"`
+					Field     string `gorm:"unique_index:idx_scene_field_source"`
+					Source    string
+					SourceID  string
+					Locked    bool
+				}
+				return tx.AutoMigrate(SceneFieldSource{}).Error
+			},
+		},
+		{
+			ID: "0093-site-schedules",
+			Migrate: func(tx *gorm.DB) error {
+				type Site struct {
+					ScheduleMode string `json:"schedule_mode" xbvrbackup:"schedule_mode"`
+					ScheduleCron string `json:"schedule_cron" xbvrbackup:"schedule_cron"`
+					ScheduleDays int    `json:"schedule_days" xbvrbackup:"schedule_days"`
+				}
+				if err := tx.AutoMigrate(Site{}).Error; err != nil {
+					return err
+				}
+				return tx.Exec("update sites set schedule_mode = '', schedule_cron = '', schedule_days = 0 where schedule_mode is null").Error
+			},
+		},
+		{
+			ID: "0094-site-cache-ttl",
+			Migrate: func(tx *gorm.DB) error {
+				type Site struct {
+					CacheTTL int `json:"cache_ttl" xbvrbackup:"cache_ttl"`
+				}
+				if err := tx.AutoMigrate(Site{}).Error; err != nil {
+					return err
+				}
+				// responses are now cached per site, drop what is left of the old colly cache
+				os.RemoveAll(common.ScrapeCacheDir)
+				os.MkdirAll(common.ScrapeCacheDir, os.ModePerm)
+				return tx.Exec("update sites set cache_ttl = 0 where cache_ttl is null").Error
+			},
+		},
+		{
+			ID: "0096-saved-search-subscriptions",
+			Migrate: func(tx *gorm.DB) error {
+				type Playlist struct {
+					IsSubscribed bool
+					CheckedAt    *time.Time
+				}
+				type SavedSearchScene struct {
+					ID         uint `gorm:"primary_key"`
+					PlaylistID uint `gorm:"unique_index:idx_saved_search_scene"`
+					SceneID    uint `gorm:"unique_index:idx_saved_search_scene"`
+				}
+				type SavedSearchNotification struct {
+					ID         uint `gorm:"primary_key"`
+					CreatedAt  time.Time
+					PlaylistID uint `gorm:"index"`
+					SceneID    uint
+					IsRead     bool `gorm:"index"`
+				}
+				if err := tx.AutoMigrate(Playlist{}, SavedSearchScene{}, SavedSearchNotification{}).Error; err != nil {
+					return err
+				}
+				return tx.Exec("update playlists set is_subscribed = ? where is_subscribed is null", false).Error
+			},
+		},
+		{
+			ID: "0097-file-content-hash",
+			Migrate: func(tx *gorm.DB) error {
+				type File struct {
+					ContentHash string `gorm:"index"`
+				}
+				if err := tx.AutoMigrate(File{}).Error; err != nil {
+					return err
+				}
+				// script hashes were stored as oshash, which is only meant for videos
+				if err := tx.Exec("update files set content_hash = os_hash, os_hash = '' where type = 'script'").Error; err != nil {
+					return err
+				}
+				return tx.Exec("update files set content_hash = '' where content_hash is null").Error
+			},
+		},
 
 		// ===============================================================================================
 		// Put DB Schema migrations above this line and migrations that rely on the updated schema below
@@ -2428,6 +2654,26 @@ func Migrate(migrateTo string) {
 				return tx.Table("scenes").AddIndex("idx_scenes_scraper_id", "scraper_id").Error
 			},
 		},
+		{
+			ID: "0092-build-scene-filename-index",
+			Migrate: func(tx *gorm.DB) error {
+				return models.RebuildSceneFilenameIndex(tx)
+			},
+		},
+		{
+			// rebuild search indexes with tags, cuepoints, aliases, files and facets
+			ID: "0095-rebuild-richer-indexes",
+			Migrate: func(tx *gorm.DB) error {
+				os.RemoveAll(common.IndexDirV2)
+				os.MkdirAll(common.IndexDirV2, os.ModePerm)
+				// rebuild asynchronously, no need to hold up startup, blocking the UI
+				go func() {
+					tasks.SearchIndex()
+					tasks.CalculateCacheSizes()
+				}()
+				return nil
+			},
+		},
 	}
 
 	// Wrap migrations to automatically track progress
@@ -113,6 +113,8 @@ func (i *Actor) Save() error {
 		log.Fatal("Failed to save ", err)
 	}
 
+	QueueSceneIndexForActors(i.ID)
+
 	return nil
 }
 
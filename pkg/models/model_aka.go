@@ -54,6 +54,11 @@ func (o *Aka) UpdateAkaSceneCastRecords() {
 	//  Significating faster than iterating through the results of multiple simpler queries.
 	// 	The Raw Sql used is compatible between mysql & sqlite
 
+	// reindex the scenes of aka actors, before the changes for scenes losing an aka actor and after for the others
+	var akaActorIDs []uint
+	commonDb.Model(&Aka{}).Pluck("aka_actor_id", &akaActorIDs)
+	QueueSceneIndexForActors(akaActorIDs...)
+
 	// add missing scene_cast records for aka actors
 	commonDb.Exec(`
 	insert into scene_cast 
@@ -89,6 +94,7 @@ func (o *Aka) UpdateAkaSceneCastRecords() {
 	var actor Actor
 	actor.CountActorTags()
 	o.RefreshAkaActorNames()
+	QueueSceneIndexForActors(akaActorIDs...)
 }
 
 func (o *Aka) RefreshAkaActorNames() {
@@ -2,7 +2,9 @@ package models
 
 import (
 	"math"
+	"math/bits"
 	"os"
+	"path"
 	"path/filepath"
 	"strconv"
 	"strings"
@@ -22,6 +24,9 @@ type File struct {
 	Filename    string    `json:"filename" xbvrbackup:"filename"`
 	Size        int64     `json:"size" xbvrbackup:"size"`
 	OsHash      string    `json:"oshash" xbvrbackup:"oshash"`
+	ContentHash string    `gorm:"index" json:"-" xbvrbackup:"-"` // scripts only, to detect moves
+	Phash       string    `gorm:"index" json:"phash" xbvrbackup:"phash"`
+	ETag        string    `json:"etag" xbvrbackup:"-"`
 	CreatedTime time.Time `json:"created_time" xbvrbackup:"created_time"`
 	UpdatedTime time.Time `json:"updated_time" xbvrbackup:"updated_time"`
 
@@ -43,12 +48,19 @@ type File struct {
 	IsSelectedScript    bool `json:"is_selected_script" xbvrbackup:"is_selected_script"`
 	IsExported          bool `json:"is_exported" xbvrbackup:"-"`
 	RefreshHeatmapCache bool `json:"refresh_heatmap_cache" xbvrbackup:"-"`
+
+	NfoExportedAt time.Time `json:"-" xbvrbackup:"-"`
 }
 
 func (f *File) GetPath() string {
 	return filepath.Join(f.Path, f.Filename)
 }
 
+// GetRemotePath returns the slash separated path of a file on a network volume, or the object key on a s3 volume
+func (f *File) GetRemotePath() string {
+	return path.Join(f.Path, f.Filename)
+}
+
 func (f *File) Save() error {
 	db, _ := GetDB()
 	defer db.Close()
@@ -87,6 +99,9 @@ func (f *File) Exists() bool {
 	case "putio":
 		// NOTE: we're assuming files weren't removed via Put.io web UI, so there's no need to check
 		return true
+	case "sftp", "webdav", "smb", "s3":
+		// checking each file would need a connection per file, rescans remove files deleted on the server
+		return f.Volume.IsAvailable
 	default:
 		return false
 	}
@@ -106,3 +121,23 @@ func (f *File) CalculateFramerate() error {
 	f.VideoAvgFrameRateVal = math.Ceil(v1 / v2)
 	return nil
 }
+
+// PhashMatchDistance is the largest hamming distance between perceptual hashes still considered the same video
+const PhashMatchDistance = 4
+
+// PhashDistance returns the hamming distance between two hex encoded perceptual hashes,
+// or -1 when either hash is missing or invalid
+func PhashDistance(a string, b string) int {
+	if a == "" || b == "" {
+		return -1
+	}
+	x, err := strconv.ParseUint(a, 16, 64)
+	if err != nil {
+		return -1
+	}
+	y, err := strconv.ParseUint(b, 16, 64)
+	if err != nil {
+		return -1
+	}
+	return bits.OnesCount64(x ^ y)
+}
@@ -0,0 +1,41 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// FileMatchSuggestion is a scene proposed by the fuzzy matcher for a file that didn't match any
+// expected filename. Suggestions below the auto link threshold wait in the review queue.
+type FileMatchSuggestion struct {
+	ID        uint      `gorm:"primary_key" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+
+	FileID     uint    `gorm:"index" json:"file_id"`
+	File       File    `json:"file"`
+	SceneID    uint    `gorm:"index" json:"scene_id"`
+	Scene      Scene   `json:"scene"`
+	Confidence float64 `json:"confidence"`
+	Reasons    string  `sql:"type:text;" json:"reasons"`
+	Status     string  `json:"status"` // pending, accepted, rejected or auto
+}
+
+func (o *FileMatchSuggestion) GetIfExistByPK(id uint) error {
+	db, _ := GetDB()
+	defer db.Close()
+
+	return db.Preload("File").Preload("File.Volume").Preload("Scene").Where(&FileMatchSuggestion{ID: id}).First(o).Error
+}
+
+// GetPendingFileMatchSuggestions returns the review queue, only files that are still unmatched are included
+func GetPendingFileMatchSuggestions(db *gorm.DB) []FileMatchSuggestion {
+	var suggestions []FileMatchSuggestion
+	db.Preload("File").Preload("Scene").Preload("Scene.Cast").
+		Joins("join files on files.id = file_match_suggestions.file_id and files.scene_id = 0").
+		Where("file_match_suggestions.status = ?", "pending").
+		Order("file_match_suggestions.confidence desc").
+		Find(&suggestions)
+	return suggestions
+}
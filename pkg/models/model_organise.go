@@ -0,0 +1,17 @@
+package models
+
+import (
+	"time"
+)
+
+// OrganiseAction records a file moved by the organiser, actions of one run share a BatchID so the
+// run can be undone
+type OrganiseAction struct {
+	ID        uint       `gorm:"primary_key" json:"id"`
+	CreatedAt time.Time  `json:"created_at"`
+	BatchID   string     `gorm:"index" json:"batch_id"`
+	FileID    uint       `gorm:"index" json:"file_id"` // 0 for companion files that are not in the library
+	OldPath   string     `sql:"type:text;" json:"old_path"`
+	NewPath   string     `sql:"type:text;" json:"new_path"`
+	UndoneAt  *time.Time `json:"undone_at"`
+}
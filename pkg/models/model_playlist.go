@@ -19,6 +19,10 @@ type Playlist struct {
 	IsSmart      bool   `json:"is_smart" xbvrbackup:"is_smart"`
 	PlaylistType string `json:"playlist_type" xbvrbackup:"playlist_type"`
 	SearchParams string `json:"search_params" sql:"type:text;" xbvrbackup:"search_params"`
+
+	// subscribed saved searches notify about scenes that start matching them, checked after scrapes and rescans
+	IsSubscribed bool       `json:"is_subscribed" xbvrbackup:"is_subscribed"`
+	CheckedAt    *time.Time `json:"checked_at" xbvrbackup:"-"`
 }
 
 func (o *Playlist) Save() error {
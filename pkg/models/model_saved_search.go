@@ -0,0 +1,69 @@
+package models
+
+import (
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// SavedSearchScene is a scene that matched a subscribed saved search when it was last checked
+type SavedSearchScene struct {
+	ID         uint `gorm:"primary_key"`
+	PlaylistID uint `gorm:"unique_index:idx_saved_search_scene"`
+	SceneID    uint `gorm:"unique_index:idx_saved_search_scene"`
+}
+
+// SavedSearchNotification tells about a scene that started matching a subscribed saved search
+type SavedSearchNotification struct {
+	ID         uint      `gorm:"primary_key" json:"id"`
+	CreatedAt  time.Time `json:"created_at"`
+	PlaylistID uint      `gorm:"index" json:"playlist_id"`
+	SceneID    uint      `json:"scene_id"`
+	IsRead     bool      `gorm:"index" json:"is_read"`
+}
+
+// GetSavedSearchSceneIDs returns the scenes that matched a saved search when it was last checked
+func GetSavedSearchSceneIDs(db *gorm.DB, playlistID uint) []uint {
+	var ids []uint
+	db.Model(&SavedSearchScene{}).Where("playlist_id = ?", playlistID).Pluck("scene_id", &ids)
+	return ids
+}
+
+// UpdateSavedSearchScenes replaces the scenes that matched a saved search
+func UpdateSavedSearchScenes(db *gorm.DB, playlistID uint, added []uint, removed []uint) error {
+	tx := db.Begin()
+	for i := 0; i < len(removed); i += 500 {
+		batch := removed[i:min(i+500, len(removed))]
+		if err := tx.Where("playlist_id = ? and scene_id in (?)", playlistID, batch).Delete(&SavedSearchScene{}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	for _, id := range added {
+		if err := tx.Create(&SavedSearchScene{PlaylistID: playlistID, SceneID: id}).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Model(&Playlist{}).Where("id = ?", playlistID).UpdateColumn("checked_at", time.Now()).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	return tx.Commit().Error
+}
+
+// ClearSavedSearch forgets the scenes that matched a saved search, the next check only records them again
+func ClearSavedSearch(db *gorm.DB, playlistID uint) {
+	db.Where("playlist_id = ?", playlistID).Delete(&SavedSearchScene{})
+	db.Model(&Playlist{}).Where("id = ?", playlistID).UpdateColumn("checked_at", gorm.Expr("NULL"))
+}
+
+// CountUnreadSavedSearchNotifications counts the unread notifications of scenes that still exist
+func CountUnreadSavedSearchNotifications(db *gorm.DB) int {
+	var count int
+	db.Model(&SavedSearchNotification{}).
+		Joins("join scenes on scenes.id = saved_search_notifications.scene_id and scenes.deleted_at is null").
+		Where("saved_search_notifications.is_read = ?", false).
+		Count(&count)
+	return count
+}
@@ -116,6 +116,7 @@ type Scene struct {
 	Score       float64 `gorm:"-" json:"_score" xbvrbackup:"-"`
 
 	AlternateSource []ExternalReferenceLink `json:"alternate_source" xbvrbackup:"-"`
+	FieldSources    []SceneFieldSource      `gorm:"-" json:"field_sources" xbvrbackup:"-"`
 }
 
 type Image struct {
@@ -156,6 +157,8 @@ func (i *Scene) Save() error {
 		log.Fatal("Failed to save ", err)
 	}
 
+	QueueSceneIndex(i.ID)
+
 	return nil
 }
 
@@ -235,6 +238,18 @@ func GetScenesBySceneIDs(sceneIDs []string) ([]Scene, error) {
 	return scenes, err
 }
 
+// GetScenePKs returns the primary keys of the scenes with the scene ids
+func GetScenePKs(sceneIDs []string) []uint {
+	commonDb, _ := GetCommonDB()
+	var ids []uint
+	for i := 0; i < len(sceneIDs); i += 500 {
+		var batch []uint
+		commonDb.Model(&Scene{}).Where("scene_id in (?)", sceneIDs[i:min(i+500, len(sceneIDs))]).Pluck("id", &batch)
+		ids = append(ids, batch...)
+	}
+	return ids
+}
+
 func (o *Scene) GetFunscriptTitle() string {
 	// first make the title filename safe
 	re := regexp.MustCompile(`[?/\<>|]`)
@@ -447,8 +462,9 @@ func SceneCreateUpdateFromExternal(db *gorm.DB, ext ScrapedScene) error {
 
 	var o Scene
 	db.Where(&Scene{SceneID: ext.SceneID}).FirstOrCreate(&o)
+	locked := GetLockedSceneFields(db, o.ID)
 
-	if o.Title != ext.Title {
+	if o.Title != ext.Title && !locked["title"] {
 		// reset scriptfile.IsExported state on title change
 		scriptfiles, err := o.GetScriptFiles()
 		if err == nil {
@@ -467,19 +483,35 @@ func SceneCreateUpdateFromExternal(db *gorm.DB, ext ScrapedScene) error {
 	o.IsSubscribed = site.Subscribed
 
 	// Clean & Associate Tags
-	var tags = o.Tags
-	db.Model(&o).Association("Tags").Clear()
-	for idx, tag := range tags {
-		tmpTag := Tag{}
-		db.Where(&Tag{Name: tag.Name}).FirstOrCreate(&tmpTag)
-		tags[idx] = tmpTag
-	}
-	o.Tags = tags
+	if !locked["tags"] {
+		var tags = o.Tags
+		db.Model(&o).Association("Tags").Clear()
+		for idx, tag := range tags {
+			tmpTag := Tag{}
+			db.Where(&Tag{Name: tag.Name}).FirstOrCreate(&tmpTag)
+			tags[idx] = tmpTag
+		}
+		o.Tags = tags
+	}
 	SaveWithRetry(db, &o)
+	o.UpdateFilenameIndex(db)
+
+	var fields []string
+	for _, field := range SceneProvenanceFields {
+		if !locked[field] {
+			fields = append(fields, field)
+		}
+	}
+	source, sourceID := ScrapedSceneSource(ext)
+	SetSceneFieldSources(db, o.ID, source, sourceID, fields...)
 
 	// Clean & Associate Actors
-	db.Model(&o).Association("Cast").Clear()
 	var tmpActor Actor
+	if locked["cast"] {
+		ext.Cast = nil
+	} else {
+		db.Model(&o).Association("Cast").Clear()
+	}
 	for _, name := range ext.Cast {
 		tmpActor = Actor{}
 		db.Where(&Actor{Name: strings.Replace(name, ".", "", -1)}).FirstOrCreate(&tmpActor)
@@ -512,6 +544,7 @@ func SceneCreateUpdateFromExternal(db *gorm.DB, ext ScrapedScene) error {
 	db.Where("external_source like 'alternate scene %' and external_url = ?", o.SceneURL).Find(&extrefs)
 	for _, extref := range extrefs {
 		db.Where("external_reference_id = ?", extref.ID).Delete(&ExternalReferenceLink{})
+		db.Where("external_reference_id = ?", extref.ID).Delete(&SceneFilename{})
 		db.Delete(&extref)
 	}
 
@@ -561,18 +594,27 @@ func (o *Scene) PopulateSceneFieldsFromExternal(db *gorm.DB, ext ScrapedScene) {
 		return
 	}
 
+	// fields locked by the user keep their value, alternate sources have no locks
+	locked := GetLockedSceneFields(db, o.ID)
+
 	o.NeedsUpdate = false
 	o.EditsApplied = false
 	o.SceneID = ext.SceneID
 	o.ScraperId = ext.ScraperID
-	o.Title = ext.Title
+	if !locked["title"] {
+		o.Title = ext.Title
+	}
 	o.SceneType = ext.SceneType
 	o.Studio = ext.Studio
 	o.Site = ext.Site
 	o.Duration = ext.Duration
-	o.Synopsis = ext.Synopsis
-	o.ReleaseDateText = ext.Released
-	if ext.Covers != nil {
+	if !locked["synopsis"] {
+		o.Synopsis = ext.Synopsis
+	}
+	if !locked["release_date"] {
+		o.ReleaseDateText = ext.Released
+	}
+	if ext.Covers != nil && !locked["cover"] {
 		o.CoverURL = ext.Covers[0]
 	}
 	o.SceneURL = ext.HomepageURL
@@ -589,7 +631,7 @@ func (o *Scene) PopulateSceneFieldsFromExternal(db *gorm.DB, ext ScrapedScene) {
 	o.TrailerType = ext.TrailerType
 	o.TrailerSource = ext.TrailerSrc
 
-	if ext.Released != "" {
+	if ext.Released != "" && !locked["release_date"] {
 		dateParsed, err := dateparse.ParseLocal(strings.Replace(ext.Released, ",", "", -1))
 		if err == nil {
 			o.ReleaseDate = dateParsed
@@ -632,24 +674,28 @@ func (o *Scene) PopulateSceneFieldsFromExternal(db *gorm.DB, ext ScrapedScene) {
 	db.Where("id = ?", o.ScraperId).FirstOrInit(&site)
 	o.IsSubscribed = site.Subscribed
 
-	var tags []Tag
-	for _, name := range ext.Tags {
-		tagClean := ConvertTag(name)
-		if tagClean != "" {
-			tags = append(tags, Tag{Name: tagClean})
+	if !locked["tags"] {
+		var tags []Tag
+		for _, name := range ext.Tags {
+			tagClean := ConvertTag(name)
+			if tagClean != "" {
+				tags = append(tags, Tag{Name: tagClean})
+			}
 		}
+		o.Tags = tags
 	}
-	o.Tags = tags
 
 	// Clean & Associate Actors
-	var cast []Actor
-	var tmpActor Actor
-	for _, name := range ext.Cast {
-		tmpActor = Actor{}
-		db.Where(&Actor{Name: strings.Replace(name, ".", "", -1)}).FirstOrCreate(&tmpActor)
-		cast = append(cast, tmpActor)
+	if !locked["cast"] {
+		var cast []Actor
+		var tmpActor Actor
+		for _, name := range ext.Cast {
+			tmpActor = Actor{}
+			db.Where(&Actor{Name: strings.Replace(name, ".", "", -1)}).FirstOrCreate(&tmpActor)
+			cast = append(cast, tmpActor)
+		}
+		o.Cast = cast
 	}
-	o.Cast = cast
 }
 
 func SceneUpdateScriptData(db *gorm.DB, ext ScrapedScene) {
@@ -702,6 +748,7 @@ type RequestSceneList struct {
 	Attributes   []optional.String `json:"attributes"`
 	Volume       optional.Int      `json:"volume"`
 	Released     optional.String   `json:"releaseMonth"`
+	Query        optional.String   `json:"query"`
 	Sort         optional.String   `json:"sort"`
 }
 
@@ -1171,6 +1218,16 @@ func queryScenes(db *gorm.DB, r RequestSceneList) (*gorm.DB, *gorm.DB) {
 		tx = tx.Where("release_date_text LIKE ?", r.Released.OrElse("")+"%")
 	}
 
+	if query := strings.TrimSpace(r.Query.OrElse("")); query != "" {
+		where, args, err := CompileSceneQuery(query, tx.Dialect().GetName())
+		if err != nil {
+			// an invalid query must not list every scene
+			log.Warnf("Invalid scene query %q: %v", query, err)
+			where, args = "1 = 0", nil
+		}
+		tx = tx.Where(where, args...)
+	}
+
 	switch r.Sort.OrElse("") {
 	case "added_desc":
 		tx = tx.Order("added_date desc")
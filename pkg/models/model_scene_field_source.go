@@ -0,0 +1,113 @@
+package models
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/thoas/go-funk"
+)
+
+// Sources of scene field values
+const (
+	FieldSourceScraper = "scraper"
+	FieldSourceStashDB = "stashdb"
+	FieldSourceTPDB    = "tpdb"
+	FieldSourceManual  = "manual"
+)
+
+// SceneProvenanceFields are the scene fields whose source is recorded and which can be locked
+var SceneProvenanceFields = []string{"title", "synopsis", "cover", "cast", "tags", "release_date"}
+
+// SceneFieldSource records where the value of a scene field came from and since when. Scrapes don't change
+// locked fields.
+type SceneFieldSource struct {
+	ID        uint      `gorm:"primary_key" json:"-"`
+	UpdatedAt time.Time `json:"updated_at"`
+	SceneID   uint      `gorm:"unique_index:idx_scene_field_source" json:"-"`
+	Field     string    `gorm:"unique_index:idx_scene_field_source" json:"field"`
+	Source    string    `json:"source"`
+	SourceID  string    `json:"source_id"` // scraper id of scraped values
+	Locked    bool      `json:"locked"`
+}
+
+// ScrapedSceneSource returns the field source of a scraped scene
+func ScrapedSceneSource(ext ScrapedScene) (string, string) {
+	switch {
+	case ext.ScraperID == "tpdb":
+		return FieldSourceTPDB, ""
+	case strings.HasSuffix(ext.ScraperID, "-stashdb"):
+		return FieldSourceStashDB, ext.ScraperID
+	}
+	return FieldSourceScraper, ext.ScraperID
+}
+
+// ActionColumnField maps a column of an edit action to its provenance field, it returns "" for other columns
+func ActionColumnField(column string) string {
+	switch column {
+	case "cover_url":
+		return "cover"
+	case "release_date_text":
+		return "release_date"
+	}
+	if funk.ContainsString(SceneProvenanceFields, column) {
+		return column
+	}
+	return ""
+}
+
+func GetSceneFieldSources(db *gorm.DB, sceneID uint) []SceneFieldSource {
+	sources := []SceneFieldSource{}
+	db.Where("scene_id = ?", sceneID).Order("field").Find(&sources)
+	return sources
+}
+
+// GetLockedSceneFields returns the locked fields of a scene
+func GetLockedSceneFields(db *gorm.DB, sceneID uint) map[string]bool {
+	locked := map[string]bool{}
+	if sceneID == 0 {
+		return locked
+	}
+	var sources []SceneFieldSource
+	db.Where("scene_id = ? and locked = ?", sceneID, true).Find(&sources)
+	for _, s := range sources {
+		locked[s.Field] = true
+	}
+	return locked
+}
+
+// SetSceneFieldSources records the source of fields of a scene. A field keeps its time while the same source
+// keeps providing it, manual edits always update it.
+func SetSceneFieldSources(db *gorm.DB, sceneID uint, source string, sourceID string, fields ...string) {
+	if sceneID == 0 || len(fields) == 0 {
+		return
+	}
+	var existing []SceneFieldSource
+	db.Where("scene_id = ? and field in (?)", sceneID, fields).Find(&existing)
+	for _, field := range fields {
+		row := SceneFieldSource{SceneID: sceneID, Field: field}
+		for _, e := range existing {
+			if e.Field == field {
+				row = e
+			}
+		}
+		if row.ID != 0 && row.Source == source && row.SourceID == sourceID && source != FieldSourceManual {
+			continue
+		}
+		row.Source = source
+		row.SourceID = sourceID
+		db.Save(&row)
+	}
+}
+
+// LockSceneField sets the locked flag of a scene field
+func LockSceneField(db *gorm.DB, sceneID uint, field string, locked bool) error {
+	if !funk.ContainsString(SceneProvenanceFields, field) {
+		return fmt.Errorf("field %v can't be locked", field)
+	}
+	var row SceneFieldSource
+	db.Where(&SceneFieldSource{SceneID: sceneID, Field: field}).FirstOrInit(&row)
+	row.Locked = locked
+	return db.Save(&row).Error
+}
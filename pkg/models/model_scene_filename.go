@@ -0,0 +1,155 @@
+package models
+
+import (
+	"encoding/json"
+	"path"
+	"regexp"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+)
+
+// SceneFilename is an entry in the index of expected filenames used to match files to scenes.
+// It mirrors Scene.FilenamesArr and the filenames of alternate scene sources, which have an
+// ExternalReferenceID and no SceneID until they are linked.
+type SceneFilename struct {
+	ID                  uint   `gorm:"primary_key" json:"id"`
+	SceneID             uint   `gorm:"index" json:"scene_id"`
+	ExternalReferenceID uint   `gorm:"index" json:"external_reference_id"`
+	Filename            string `gorm:"index;size:500" json:"filename"`
+}
+
+// companion files are matched using the name of the video they belong to
+var companionFileExt = []string{".funscript", ".cmscript", ".hsp", ".srt"}
+
+// filenameSuffixRules strip suffixes that appear on companion files but not on the matching video,
+// e.g. "video_ai.funscript" belongs to "video.mp4". Only well known suffixes to avoid false positives.
+var filenameSuffixRules = []*regexp.Regexp{
+	regexp.MustCompile(`(?i)_(ai|2d|ow)\.funscript$`),
+}
+
+// NormalizeFilename returns the key a filename is stored under in the filename index
+func NormalizeFilename(name string) string {
+	return strings.ToLower(path.Base(name))
+}
+
+// FilenameMatchKeys returns the index keys a file should be looked up with
+func FilenameMatchKeys(filename string) []string {
+	name := NormalizeFilename(filename)
+	keys := []string{name}
+	add := func(key string) {
+		for _, k := range keys {
+			if k == key {
+				return
+			}
+		}
+		keys = append(keys, key)
+	}
+
+	ext := path.Ext(name)
+	for _, companionExt := range companionFileExt {
+		if ext == companionExt {
+			add(strings.TrimSuffix(name, ext) + ".mp4")
+		}
+	}
+	for _, rule := range filenameSuffixRules {
+		if rule.MatchString(name) {
+			add(rule.ReplaceAllString(name, ".mp4"))
+		}
+	}
+	return keys
+}
+
+// UpdateFilenameIndex replaces the index entries of the scene with the names in FilenamesArr
+func (o *Scene) UpdateFilenameIndex(db *gorm.DB) {
+	if o.ID == 0 {
+		return
+	}
+	var filenames []string
+	json.Unmarshal([]byte(o.FilenamesArr), &filenames)
+
+	db.Where("scene_id = ? and external_reference_id = 0", o.ID).Delete(&SceneFilename{})
+	insertSceneFilenames(db, o.ID, 0, filenames)
+}
+
+// UpdateAltSourceFilenameIndex replaces the index entries of an alternate scene source
+func UpdateAltSourceFilenameIndex(db *gorm.DB, externalReferenceID uint, filenames []string) {
+	if externalReferenceID == 0 {
+		return
+	}
+	db.Where("external_reference_id = ?", externalReferenceID).Delete(&SceneFilename{})
+	insertSceneFilenames(db, 0, externalReferenceID, filenames)
+}
+
+// RebuildSceneFilenameIndex recreates the filename index from all scenes and alternate scene sources
+func RebuildSceneFilenameIndex(db *gorm.DB) error {
+	tx := db.Begin()
+	tx.Delete(&SceneFilename{})
+
+	type sceneFilenames struct {
+		ID           uint
+		FilenamesArr string
+	}
+	var scenes []sceneFilenames
+	if err := tx.Model(&Scene{}).Select("id, filenames_arr").Where("filenames_arr <> '' and filenames_arr <> 'null'").Scan(&scenes).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, scene := range scenes {
+		var filenames []string
+		json.Unmarshal([]byte(scene.FilenamesArr), &filenames)
+		insertSceneFilenames(tx, scene.ID, 0, filenames)
+	}
+
+	var extrefs []ExternalReference
+	if err := tx.Select("id, external_data").Where("external_source like 'alternate scene %'").Find(&extrefs).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+	for _, extref := range extrefs {
+		var data SceneAlternateSource
+		if json.Unmarshal([]byte(extref.ExternalData), &data) != nil {
+			continue
+		}
+		var filenames []string
+		json.Unmarshal([]byte(data.Scene.FilenamesArr), &filenames)
+		insertSceneFilenames(tx, 0, extref.ID, filenames)
+	}
+
+	return tx.Commit().Error
+}
+
+func insertSceneFilenames(db *gorm.DB, sceneID uint, externalReferenceID uint, filenames []string) {
+	seen := map[string]bool{}
+	var values []string
+	var args []interface{}
+	for _, filename := range filenames {
+		key := NormalizeFilename(filename)
+		if key == "" || key == "." || seen[key] {
+			continue
+		}
+		seen[key] = true
+		values = append(values, "(?, ?, ?)")
+		args = append(args, sceneID, externalReferenceID, key)
+	}
+	if len(values) == 0 {
+		return
+	}
+	db.Exec("insert into scene_filenames (scene_id, external_reference_id, filename) values "+strings.Join(values, ", "), args...)
+}
+
+// FindScenesByFilenames returns the scenes expecting a file with one of the given index keys
+func FindScenesByFilenames(db *gorm.DB, keys []string) []Scene {
+	var scenes []Scene
+	db.Where("id in (select scene_id from scene_filenames where filename in (?) and scene_id <> 0)", keys).Find(&scenes)
+	return scenes
+}
+
+// FindAltSourcesByFilenames returns the alternate scene sources expecting a file with one of the given index keys
+func FindAltSourcesByFilenames(db *gorm.DB, keys []string) []ExternalReference {
+	var extrefs []ExternalReference
+	db.Preload("XbvrLinks").
+		Where("external_source like 'alternate scene %' and id in (select external_reference_id from scene_filenames where filename in (?) and external_reference_id <> 0)", keys).
+		Find(&extrefs)
+	return extrefs
+}
@@ -0,0 +1,543 @@
+package models
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// Scene queries combine conditions on typed fields with AND, OR, NOT and parentheses, eg
+//
+//	(tag:blowjob OR tag:pov) AND NOT site:slr AND duration>30m AND rating>=4 AND watched=false
+//	cast:"Jane Doe" -tag:outdoor added within 7d
+//	released>=2023 released<now-6mo favourite
+//
+// Conditions next to each other are combined with AND, a - in front of a condition negates it. Text fields
+// compare with : or = and != and accept * as wildcard, title, synopsis and cuepoint match on a part of the text
+// with :. Numbers, durations and dates compare with = != > >= < <= too. Durations are minutes or have units like
+// 1h30m, dates are 2023, 2023-05 or 2023-05-17, now or today, optionally with arithmetic like now-7d, where
+// h, d, w, mo and y are the units. A duration alone is a time ago, "added within 7d" is the same as
+// "added>=now-7d". A yes/no field alone means it is true, other words alone match the title.
+
+type sceneQueryFieldType int
+
+const (
+	sceneQueryText sceneQueryFieldType = iota
+	sceneQueryNumber
+	sceneQueryDuration
+	sceneQueryDate
+	sceneQueryBool
+)
+
+type sceneQueryField struct {
+	kind     sceneQueryFieldType
+	column   string
+	exists   string // subquery the column belongs to, matching the scene when a row meets the condition
+	contains bool   // : matches a part of the text
+}
+
+var sceneQueryFields = map[string]sceneQueryField{
+	"tag":         {kind: sceneQueryText, column: "t.name", exists: "select 1 from scene_tags st join tags t on t.id = st.tag_id where st.scene_id = scenes.id and %v"},
+	"cast":        {kind: sceneQueryText, column: "a.name", exists: "select 1 from scene_cast sc join actors a on a.id = sc.actor_id where sc.scene_id = scenes.id and %v"},
+	"cuepoint":    {kind: sceneQueryText, column: "cp.name", exists: "select 1 from scene_cuepoints cp where cp.scene_id = scenes.id and %v", contains: true},
+	"site":        {kind: sceneQueryText, column: "scenes.site"},
+	"studio":      {kind: sceneQueryText, column: "scenes.studio"},
+	"title":       {kind: sceneQueryText, column: "scenes.title", contains: true},
+	"synopsis":    {kind: sceneQueryText, column: "scenes.synopsis", contains: true},
+	"id":          {kind: sceneQueryText, column: "scenes.scene_id"},
+	"codec":       {kind: sceneQueryText, column: "f.video_codec_name", exists: "select 1 from files f where f.scene_id = scenes.id and f.`type` = 'video' and %v"},
+	"projection":  {kind: sceneQueryText, column: "f.video_projection", exists: "select 1 from files f where f.scene_id = scenes.id and f.`type` = 'video' and %v"},
+	"duration":    {kind: sceneQueryDuration, column: "scenes.duration"},
+	"rating":      {kind: sceneQueryNumber, column: "scenes.star_rating"},
+	"resolution":  {kind: sceneQueryNumber, column: "((f.video_width * (case when f.video_projection like '%%_tb' then 2 else 1 end) + 500) %v 1000)", exists: "select 1 from files f where f.scene_id = scenes.id and f.`type` = 'video' and %v"},
+	"watchtime":   {kind: sceneQueryDuration, column: "(scenes.total_watch_time / 60)"},
+	"released":    {kind: sceneQueryDate, column: "scenes.release_date"},
+	"added":       {kind: sceneQueryDate, column: "scenes.added_date"},
+	"opened":      {kind: sceneQueryDate, column: "scenes.last_opened"},
+	"watched":     {kind: sceneQueryBool, column: "scenes.is_watched"},
+	"favourite":   {kind: sceneQueryBool, column: "scenes.favourite"},
+	"watchlist":   {kind: sceneQueryBool, column: "scenes.watchlist"},
+	"wishlist":    {kind: sceneQueryBool, column: "scenes.wishlist"},
+	"available":   {kind: sceneQueryBool, column: "scenes.is_available"},
+	"accessible":  {kind: sceneQueryBool, column: "scenes.is_accessible"},
+	"scripted":    {kind: sceneQueryBool, column: "scenes.is_scripted"},
+	"subscribed":  {kind: sceneQueryBool, column: "scenes.is_subscribed"},
+	"trailerlist": {kind: sceneQueryBool, column: "scenes.trailerlist"},
+}
+
+var sceneQueryAliases = map[string]string{
+	"tags":        "tag",
+	"actor":       "cast",
+	"cuepoints":   "cuepoint",
+	"description": "synopsis",
+	"favorite":    "favourite",
+	"release":     "released",
+}
+
+// SceneQueryError is a query that can't be parsed, Pos is the offset of the problem in the query
+type SceneQueryError struct {
+	Pos int
+	Msg string
+}
+
+func (e *SceneQueryError) Error() string {
+	return fmt.Sprintf("%v at position %v", e.Msg, e.Pos+1)
+}
+
+type sceneQueryTokenKind int
+
+const (
+	tokenEOF sceneQueryTokenKind = iota
+	tokenWord
+	tokenString
+	tokenOperator
+	tokenOpen
+	tokenClose
+)
+
+type sceneQueryToken struct {
+	kind sceneQueryTokenKind
+	text string
+	pos  int
+}
+
+func (t sceneQueryToken) is(keyword string) bool {
+	return t.kind == tokenWord && strings.EqualFold(t.text, keyword)
+}
+
+func tokenizeSceneQuery(query string) ([]sceneQueryToken, error) {
+	var tokens []sceneQueryToken
+	runes := []rune(query)
+	for i := 0; i < len(runes); {
+		r := runes[i]
+		switch {
+		case unicode.IsSpace(r):
+			i++
+		case r == '(':
+			tokens = append(tokens, sceneQueryToken{kind: tokenOpen, text: "(", pos: i})
+			i++
+		case r == ')':
+			tokens = append(tokens, sceneQueryToken{kind: tokenClose, text: ")", pos: i})
+			i++
+		case r == '"':
+			start := i
+			var sb strings.Builder
+			for i++; i < len(runes) && runes[i] != '"'; i++ {
+				if runes[i] == '\\' && i+1 < len(runes) {
+					i++
+				}
+				sb.WriteRune(runes[i])
+			}
+			if i == len(runes) {
+				return nil, &SceneQueryError{Pos: start, Msg: "unterminated quote"}
+			}
+			i++
+			tokens = append(tokens, sceneQueryToken{kind: tokenString, text: sb.String(), pos: start})
+		case strings.ContainsRune(":=!<>", r):
+			start := i
+			op := string(r)
+			if i+1 < len(runes) && runes[i+1] == '=' && r != ':' && r != '=' {
+				op += "="
+				i++
+			}
+			if op == "!" {
+				return nil, &SceneQueryError{Pos: start, Msg: "expected !="}
+			}
+			i++
+			tokens = append(tokens, sceneQueryToken{kind: tokenOperator, text: op, pos: start})
+		default:
+			start := i
+			for i < len(runes) && !unicode.IsSpace(runes[i]) && !strings.ContainsRune(`()":=!<>`, runes[i]) {
+				i++
+			}
+			tokens = append(tokens, sceneQueryToken{kind: tokenWord, text: string(runes[start:i]), pos: start})
+		}
+	}
+	return append(tokens, sceneQueryToken{kind: tokenEOF, pos: len(runes)}), nil
+}
+
+// sceneQueryNow is the time relative dates are resolved against
+var sceneQueryNow = time.Now
+
+type sceneQueryCompiler struct {
+	tokens  []sceneQueryToken
+	pos     int
+	dialect string
+	now     time.Time
+}
+
+// CompileSceneQuery compiles a scene query to a where condition on the scenes table and its arguments
+func CompileSceneQuery(query string, dialect string) (string, []interface{}, error) {
+	tokens, err := tokenizeSceneQuery(query)
+	if err != nil {
+		return "", nil, err
+	}
+	c := sceneQueryCompiler{tokens: tokens, dialect: dialect, now: sceneQueryNow().UTC()}
+	if c.peek().kind == tokenEOF {
+		return "", nil, &SceneQueryError{Pos: 0, Msg: "empty query"}
+	}
+	where, args, err := c.parseOr()
+	if err != nil {
+		return "", nil, err
+	}
+	if t := c.peek(); t.kind != tokenEOF {
+		return "", nil, &SceneQueryError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+	return where, args, nil
+}
+
+// ValidateSceneQuery returns why a scene query can't be used, or nil
+func ValidateSceneQuery(query string) error {
+	_, _, err := CompileSceneQuery(query, "sqlite3")
+	return err
+}
+
+func (c *sceneQueryCompiler) peek() sceneQueryToken {
+	return c.tokens[c.pos]
+}
+
+func (c *sceneQueryCompiler) next() sceneQueryToken {
+	t := c.tokens[c.pos]
+	if t.kind != tokenEOF {
+		c.pos++
+	}
+	return t
+}
+
+func (c *sceneQueryCompiler) parseOr() (string, []interface{}, error) {
+	where, args, err := c.parseAnd()
+	if err != nil {
+		return "", nil, err
+	}
+	for c.peek().is("or") {
+		c.next()
+		right, rightArgs, err := c.parseAnd()
+		if err != nil {
+			return "", nil, err
+		}
+		where = where + " or " + right
+		args = append(args, rightArgs...)
+	}
+	return "(" + where + ")", args, nil
+}
+
+func (c *sceneQueryCompiler) parseAnd() (string, []interface{}, error) {
+	where, args, err := c.parseNot()
+	if err != nil {
+		return "", nil, err
+	}
+	for {
+		t := c.peek()
+		if t.kind == tokenEOF || t.kind == tokenClose || t.is("or") {
+			break
+		}
+		if t.is("and") {
+			c.next()
+		}
+		right, rightArgs, err := c.parseNot()
+		if err != nil {
+			return "", nil, err
+		}
+		where = where + " and " + right
+		args = append(args, rightArgs...)
+	}
+	return where, args, nil
+}
+
+func (c *sceneQueryCompiler) parseNot() (string, []interface{}, error) {
+	if c.peek().is("not") {
+		c.next()
+		where, args, err := c.parseNot()
+		if err != nil {
+			return "", nil, err
+		}
+		return "not " + where, args, nil
+	}
+	return c.parsePrimary()
+}
+
+func (c *sceneQueryCompiler) parsePrimary() (string, []interface{}, error) {
+	t := c.next()
+	switch t.kind {
+	case tokenOpen:
+		where, args, err := c.parseOr()
+		if err != nil {
+			return "", nil, err
+		}
+		if close := c.next(); close.kind != tokenClose {
+			return "", nil, &SceneQueryError{Pos: close.pos, Msg: "expected )"}
+		}
+		return where, args, nil
+	case tokenString:
+		return c.condition(sceneQueryFields["title"], ":", t)
+	case tokenWord:
+	default:
+		return "", nil, &SceneQueryError{Pos: t.pos, Msg: fmt.Sprintf("unexpected %q", t.text)}
+	}
+
+	negate := false
+	name := t.text
+	if strings.HasPrefix(name, "-") && len(name) > 1 {
+		negate = true
+		name = name[1:]
+	}
+	if alias, ok := sceneQueryAliases[strings.ToLower(name)]; ok {
+		name = alias
+	}
+	field, isField := sceneQueryFields[strings.ToLower(name)]
+
+	var where string
+	var args []interface{}
+	var err error
+	switch op := c.peek(); {
+	case op.kind == tokenOperator:
+		if !isField {
+			return "", nil, &SceneQueryError{Pos: t.pos, Msg: fmt.Sprintf("unknown field %q", name)}
+		}
+		c.next()
+		value := c.next()
+		if value.kind != tokenWord && value.kind != tokenString {
+			return "", nil, &SceneQueryError{Pos: value.pos, Msg: "expected a value"}
+		}
+		where, args, err = c.condition(field, op.text, value)
+	case op.is("within") && isField:
+		c.next()
+		value := c.next()
+		if field.kind != sceneQueryDate {
+			return "", nil, &SceneQueryError{Pos: op.pos, Msg: fmt.Sprintf("%v is not a date", name)}
+		}
+		span, ok := parseSceneQuerySpan(value.text)
+		if !ok || value.kind != tokenWord {
+			return "", nil, &SceneQueryError{Pos: value.pos, Msg: fmt.Sprintf("invalid time span %q", value.text)}
+		}
+		where, args = field.column+" >= ?", []interface{}{span(c.now, -1)}
+	case isField && field.kind == sceneQueryBool:
+		where, args = field.column+" = ?", []interface{}{true}
+	default:
+		where, args, err = c.condition(sceneQueryFields["title"], ":", sceneQueryToken{kind: tokenWord, text: name, pos: t.pos})
+	}
+	if err != nil {
+		return "", nil, err
+	}
+	if negate {
+		where = "not (" + where + ")"
+	}
+	return where, args, nil
+}
+
+// condition compiles a comparison of a field with a value
+func (c *sceneQueryCompiler) condition(field sceneQueryField, op string, value sceneQueryToken) (string, []interface{}, error) {
+	negate := op == "!="
+	if negate {
+		op = "="
+	}
+
+	column := field.column
+	if strings.Contains(column, "%v") {
+		div := "/"
+		if c.dialect == "mysql" {
+			div = "div"
+		}
+		column = fmt.Sprintf(column, div)
+	}
+
+	var where string
+	var args []interface{}
+	switch field.kind {
+	case sceneQueryText:
+		if op != ":" && op != "=" {
+			return "", nil, &SceneQueryError{Pos: value.pos, Msg: fmt.Sprintf("%v can't compare text", op)}
+		}
+		pattern := value.text
+		if field.contains && op == ":" {
+			pattern = "*" + pattern + "*"
+		}
+		if strings.Contains(pattern, "*") {
+			where = "lower(" + column + ") like lower(?)"
+			args = []interface{}{strings.ReplaceAll(pattern, "*", "%")}
+		} else {
+			where = "lower(" + column + ") = lower(?)"
+			args = []interface{}{pattern}
+		}
+	case sceneQueryBool:
+		if op != ":" && op != "=" {
+			return "", nil, &SceneQueryError{Pos: value.pos, Msg: fmt.Sprintf("%v can't compare yes/no", op)}
+		}
+		b, ok := parseSceneQueryBool(value.text)
+		if !ok {
+			return "", nil, &SceneQueryError{Pos: value.pos, Msg: fmt.Sprintf("expected true or false, not %q", value.text)}
+		}
+		where, args = column+" = ?", []interface{}{b}
+	case sceneQueryNumber, sceneQueryDuration:
+		var n float64
+		var err error
+		if field.kind == sceneQueryDuration {
+			n, err = parseSceneQueryMinutes(value.text)
+		} else {
+			n, err = strconv.ParseFloat(strings.TrimSuffix(strings.ToUpper(value.text), "K"), 64)
+		}
+		if err != nil {
+			return "", nil, &SceneQueryError{Pos: value.pos, Msg: fmt.Sprintf("invalid number %q", value.text)}
+		}
+		where, args = column+" "+sqlComparison(op)+" ?", []interface{}{n}
+	case sceneQueryDate:
+		start, end, ok := parseSceneQueryDate(value.text, c.now)
+		if !ok {
+			return "", nil, &SceneQueryError{Pos: value.pos, Msg: fmt.Sprintf("invalid date %q", value.text)}
+		}
+		// a date covers a period, eg released>2023 starts with 2024
+		switch op {
+		case ":", "=":
+			where, args = "("+column+" >= ? and "+column+" < ?)", []interface{}{start, end}
+		case ">":
+			where, args = column+" >= ?", []interface{}{end}
+		case ">=":
+			where, args = column+" >= ?", []interface{}{start}
+		case "<":
+			where, args = column+" < ?", []interface{}{start}
+		case "<=":
+			where, args = column+" < ?", []interface{}{end}
+		}
+	}
+
+	if field.exists != "" {
+		where = "exists (" + fmt.Sprintf(field.exists, where) + ")"
+	}
+	if negate {
+		// scenes without a match, eg tag!=pov are the scenes not tagged pov
+		where = "not " + where
+	}
+	return where, args, nil
+}
+
+func sqlComparison(op string) string {
+	if op == ":" {
+		return "="
+	}
+	return op
+}
+
+func parseSceneQueryBool(s string) (bool, bool) {
+	switch strings.ToLower(s) {
+	case "true", "yes", "1":
+		return true, true
+	case "false", "no", "0":
+		return false, true
+	}
+	return false, false
+}
+
+// parseSceneQueryMinutes reads a number of minutes or a duration like 1h30m
+func parseSceneQueryMinutes(s string) (float64, error) {
+	if n, err := strconv.ParseFloat(s, 64); err == nil {
+		return n, nil
+	}
+	d, err := time.ParseDuration(strings.ToLower(s))
+	if err != nil {
+		return 0, err
+	}
+	return d.Minutes(), nil
+}
+
+// parseSceneQuerySpan reads a time span like 7d or 1y2mo, returning a function that moves a time by it
+func parseSceneQuerySpan(s string) (func(time.Time, int) time.Time, bool) {
+	var years, months, days int
+	var hours time.Duration
+	s = strings.ToLower(s)
+	if s == "" {
+		return nil, false
+	}
+	for s != "" {
+		i := 0
+		for i < len(s) && s[i] >= '0' && s[i] <= '9' {
+			i++
+		}
+		n, err := strconv.Atoi(s[:i])
+		if err != nil {
+			return nil, false
+		}
+		s = s[i:]
+		switch {
+		case strings.HasPrefix(s, "mo"):
+			months += n
+			s = s[2:]
+		case strings.HasPrefix(s, "y"):
+			years += n
+			s = s[1:]
+		case strings.HasPrefix(s, "w"):
+			days += 7 * n
+			s = s[1:]
+		case strings.HasPrefix(s, "d"):
+			days += n
+			s = s[1:]
+		case strings.HasPrefix(s, "h"):
+			hours += time.Duration(n) * time.Hour
+			s = s[1:]
+		default:
+			return nil, false
+		}
+	}
+	return func(t time.Time, sign int) time.Time {
+		return t.AddDate(sign*years, sign*months, sign*days).Add(time.Duration(sign) * hours)
+	}, true
+}
+
+// parseSceneQueryDate reads a date and returns the period it covers
+func parseSceneQueryDate(s string, now time.Time) (time.Time, time.Time, bool) {
+	lower := strings.ToLower(s)
+
+	// a time span alone is a time ago
+	if span, ok := parseSceneQuerySpan(lower); ok {
+		t := span(now, -1)
+		return t, t.Add(time.Second), true
+	}
+
+	base, rest := lower, ""
+	if i := strings.IndexAny(lower, "+-"); i > 0 && (strings.HasPrefix(lower, "now") || strings.HasPrefix(lower, "today")) {
+		base, rest = lower[:i], lower[i:]
+	} else if len(lower) > 10 && (lower[10] == '+' || lower[10] == '-') {
+		base, rest = lower[:10], lower[10:]
+	}
+
+	var start, end time.Time
+	switch base {
+	case "now":
+		start, end = now, now.Add(time.Second)
+	case "today":
+		start = time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, time.UTC)
+		end = start.AddDate(0, 0, 1)
+	default:
+		var err error
+		switch len(base) {
+		case 4:
+			start, err = time.Parse("2006", base)
+			end = start.AddDate(1, 0, 0)
+		case 7:
+			start, err = time.Parse("2006-01", base)
+			end = start.AddDate(0, 1, 0)
+		case 10:
+			start, err = time.Parse("2006-01-02", base)
+			end = start.AddDate(0, 0, 1)
+		default:
+			return start, end, false
+		}
+		if err != nil {
+			return start, end, false
+		}
+	}
+
+	if rest != "" {
+		sign := 1
+		if rest[0] == '-' {
+			sign = -1
+		}
+		span, ok := parseSceneQuerySpan(rest[1:])
+		if !ok {
+			return start, end, false
+		}
+		start, end = span(start, sign), span(end, sign)
+	}
+	return start, end, true
+}
@@ -0,0 +1,105 @@
+package models
+
+import (
+	"errors"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+)
+
+func TestCompileSceneQuery(t *testing.T) {
+	now := time.Date(2024, 3, 15, 12, 0, 0, 0, time.UTC)
+	sceneQueryNow = func() time.Time { return now }
+	defer func() { sceneQueryNow = time.Now }()
+
+	const tag = "exists (select 1 from scene_tags st join tags t on t.id = st.tag_id where st.scene_id = scenes.id and lower(t.name) = lower(?))"
+	const resolution = "(exists (select 1 from files f where f.scene_id = scenes.id and f.`type` = 'video' and ((f.video_width * (case when f.video_projection like '%_tb' then 2 else 1 end) + 500) %v 1000) >= ?))"
+
+	tests := []struct {
+		query   string
+		dialect string
+		where   string
+		args    []interface{}
+		errPos  int
+		errMsg  string
+	}{
+		{
+			query: "(tag:blowjob OR tag:pov) AND NOT site:slr AND duration>30m AND rating>=4 AND watched=false",
+			where: "((" + tag + " or " + tag + ") and not lower(scenes.site) = lower(?) and scenes.duration > ? and scenes.star_rating >= ? and scenes.is_watched = ?)",
+			args:  []interface{}{"blowjob", "pov", "slr", 30.0, 4.0, false},
+		},
+		{
+			query: "tag:a tag:b or tag:c",
+			where: "(" + tag + " and " + tag + " or " + tag + ")",
+			args:  []interface{}{"a", "b", "c"},
+		},
+		{
+			query: "-tag:x",
+			where: "(not (" + tag + "))",
+			args:  []interface{}{"x"},
+		},
+		{
+			query: "added within 7d",
+			where: "(scenes.added_date >= ?)",
+			args:  []interface{}{now.AddDate(0, 0, -7)},
+		},
+		{
+			query: "released>2023",
+			where: "(scenes.release_date >= ?)",
+			args:  []interface{}{time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			query: "released=2023-05",
+			where: "((scenes.release_date >= ? and scenes.release_date < ?))",
+			args:  []interface{}{time.Date(2023, 5, 1, 0, 0, 0, 0, time.UTC), time.Date(2023, 6, 1, 0, 0, 0, 0, time.UTC)},
+		},
+		{
+			query: "opened<now-1mo",
+			where: "(scenes.last_opened < ?)",
+			args:  []interface{}{now.AddDate(0, -1, 0)},
+		},
+		{
+			query: "resolution>=8K",
+			where: strings.Replace(resolution, "%v", "/", 1),
+			args:  []interface{}{8.0},
+		},
+		{
+			query:   "resolution>=8K",
+			dialect: "mysql",
+			where:   strings.Replace(resolution, "%v", "div", 1),
+			args:    []interface{}{8.0},
+		},
+		{query: "(tag:x OR site:y", errPos: 16, errMsg: "expected )"},
+		{query: "tag:x)", errPos: 5, errMsg: `unexpected ")"`},
+		{query: `cast:"Jane Doe`, errPos: 5, errMsg: "unterminated quote"},
+		{query: "rating>high", errPos: 7, errMsg: `invalid number "high"`},
+		{query: "bogus>1", errPos: 0, errMsg: `unknown field "bogus"`},
+		{query: "", errPos: 0, errMsg: "empty query"},
+	}
+
+	for _, tt := range tests {
+		dialect := tt.dialect
+		if dialect == "" {
+			dialect = "sqlite3"
+		}
+		where, args, err := CompileSceneQuery(tt.query, dialect)
+		if tt.errMsg != "" {
+			var queryErr *SceneQueryError
+			if !errors.As(err, &queryErr) || queryErr.Pos != tt.errPos || queryErr.Msg != tt.errMsg {
+				t.Errorf("%q: got error %v, want %v at %v", tt.query, err, tt.errMsg, tt.errPos)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("%q: %v", tt.query, err)
+			continue
+		}
+		if where != tt.where {
+			t.Errorf("%q (%v):\ngot  %v\nwant %v", tt.query, dialect, where, tt.where)
+		}
+		if !reflect.DeepEqual(args, tt.args) {
+			t.Errorf("%q: got args %#v, want %#v", tt.query, args, tt.args)
+		}
+	}
+}
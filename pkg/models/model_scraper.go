@@ -1,6 +1,7 @@
 package models
 
 import (
+	"context"
 	"encoding/json"
 	"sync/atomic"
 	"time"
@@ -8,7 +9,8 @@ import (
 
 var scrapers []Scraper
 
-type ScraperFunc func(*ScrapeWG, bool, []string, chan<- ScrapedScene, string, string, bool) error
+// ScraperFunc scrapes a site. Scrapers stop queueing requests once the context is cancelled.
+type ScraperFunc func(context.Context, *ScrapeWG, bool, []string, chan<- ScrapedScene, string, string, bool) error
 
 type Scraper struct {
 	ID           string      `json:"id"`
@@ -52,6 +54,32 @@ type ScrapedScene struct {
 	Timestamps   string                  `json:"timestamps"`
 }
 
+// SceneScraperDefinition describes a scraper for a studio site in a json or yaml file in the
+// scene_scrapers folder, so sites can be added or fixed without a new release. Field rules use the
+// same selectors and post processing as the actor scrapers.
+type SceneScraperDefinition struct {
+	ID        string                    `json:"id"`
+	Name      string                    `json:"name"`
+	AvatarURL string                    `json:"avatar_url"`
+	Domain    string                    `json:"domain"`
+	Studio    string                    `json:"studio"`     // defaults to the name
+	SceneType string                    `json:"scene_type"` // VR or 2D, defaults to VR
+	Listing   SceneScraperListing       `json:"listing"`
+	Rules     []GenericActorScraperRule `json:"rules"` // xbvr_field is one of the SceneScraperFields
+}
+
+type SceneScraperListing struct {
+	URL        string `json:"url"`         // first page listing the scenes, newest first
+	SceneLinks string `json:"scene_links"` // css selector of the links to the scene pages
+	NextPage   string `json:"next_page"`   // css selector of the link to the next listing page
+	PageURL    string `json:"page_url"`    // instead of next_page, a listing url with a {page} placeholder
+	FirstPage  int    `json:"first_page"`  // number of the first page for page_url, defaults to 1
+	MaxPages   int    `json:"max_pages"`   // stop after this many listing pages, 0 for no limit (500 for page_url)
+}
+
+// SceneScraperFields are the ScrapedScene fields a scene scraper definition can fill. site_id and title are required.
+var SceneScraperFields = []string{"site_id", "title", "synopsis", "released", "duration", "cover", "gallery", "tags", "cast", "filenames", "trailer_url", "members_url", "studio"}
+
 type ActorDetails struct {
 	ImageUrl   string
 	ProfileUrl string
@@ -0,0 +1,51 @@
+package models
+
+import (
+	"encoding/json"
+	"time"
+
+	"github.com/jinzhu/gorm"
+)
+
+// ScraperRun holds the stats of one run of a site scraper. Missing counts are scenes the scraper returned
+// without that field, Anomalies lists what looked wrong compared to earlier runs of the site.
+type ScraperRun struct {
+	ID              uint      `gorm:"primary_key" json:"id"`
+	CreatedAt       time.Time `json:"created_at"`
+	SiteID          string    `gorm:"index" json:"site_id"`
+	StartedAt       time.Time `json:"started_at"`
+	DurationSeconds float64   `json:"duration_seconds"`
+	LimitScraping   bool      `json:"limit_scraping"`
+	Pages           int       `json:"pages"`
+	HttpErrors      int       `json:"http_errors"`
+	HttpStatuses    string    `sql:"type:text;" json:"http_statuses"` // json object of status code to count
+	Error           string    `sql:"type:text;" json:"error"`
+	Scenes          int       `json:"scenes"`
+	NewScenes       int       `json:"new_scenes"`
+	UpdatedScenes   int       `json:"updated_scenes"`
+	MissingTitle    int       `json:"missing_title"`
+	MissingCover    int       `json:"missing_cover"`
+	MissingCast     int       `json:"missing_cast"`
+	MissingTags     int       `json:"missing_tags"`
+	MissingDuration int       `json:"missing_duration"`
+	MissingReleased int       `json:"missing_released"`
+	MissingSynopsis int       `json:"missing_synopsis"`
+	Anomalies       string    `sql:"type:text;" json:"anomalies"` // json array of messages
+}
+
+func (r *ScraperRun) GetAnomalies() []string {
+	var anomalies []string
+	json.Unmarshal([]byte(r.Anomalies), &anomalies)
+	return anomalies
+}
+
+// GetLatestScraperRuns returns the last run of each site
+func GetLatestScraperRuns(db *gorm.DB) map[string]ScraperRun {
+	var runs []ScraperRun
+	db.Where("id in (select max(id) from scraper_runs group by site_id)").Find(&runs)
+	latest := map[string]ScraperRun{}
+	for _, r := range runs {
+		latest[r.SiteID] = r
+	}
+	return latest
+}
@@ -0,0 +1,130 @@
+package models
+
+import (
+	"sync"
+	"time"
+)
+
+// Changes to indexed scene data queue the affected scenes here, the search indexer of the tasks package drains the
+// queue in batches. Scenes are queued by primary key, deleted scenes by scene id as their rows are gone.
+var (
+	searchQueueMutex   sync.Mutex
+	searchQueueScenes  = map[uint]time.Time{}
+	searchQueueDeletes = map[string]time.Time{}
+	searchQueueSignal  = make(chan struct{}, 1)
+)
+
+// QueueSceneIndex queues scenes to be reindexed
+func QueueSceneIndex(ids ...uint) {
+	if len(ids) == 0 {
+		return
+	}
+	now := time.Now()
+	searchQueueMutex.Lock()
+	for _, id := range ids {
+		if _, ok := searchQueueScenes[id]; !ok && id != 0 {
+			searchQueueScenes[id] = now
+		}
+	}
+	searchQueueMutex.Unlock()
+	signalSearchQueue()
+}
+
+// QueueSceneIndexDelete queues deleted scenes to be removed from the index
+func QueueSceneIndexDelete(sceneIDs ...string) {
+	if len(sceneIDs) == 0 {
+		return
+	}
+	now := time.Now()
+	searchQueueMutex.Lock()
+	for _, id := range sceneIDs {
+		if _, ok := searchQueueDeletes[id]; !ok && id != "" {
+			searchQueueDeletes[id] = now
+		}
+	}
+	searchQueueMutex.Unlock()
+	signalSearchQueue()
+}
+
+// UnqueueSceneIndex takes scenes off the queue, when they are about to be read and indexed in bulk
+func UnqueueSceneIndex(ids ...uint) {
+	searchQueueMutex.Lock()
+	defer searchQueueMutex.Unlock()
+	for _, id := range ids {
+		delete(searchQueueScenes, id)
+	}
+}
+
+// QueueSceneIndexForActors queues the scenes of actors, after names or aliases changed
+func QueueSceneIndexForActors(actorIDs ...uint) {
+	if len(actorIDs) == 0 {
+		return
+	}
+	commonDb, _ := GetCommonDB()
+	var sceneIDs []uint
+	commonDb.Table("scene_cast").Where("actor_id in (?)", actorIDs).Pluck("distinct scene_id", &sceneIDs)
+	QueueSceneIndex(sceneIDs...)
+}
+
+// QueueSceneIndexForTags queues the scenes of tags, after names or tag groups changed
+func QueueSceneIndexForTags(tagIDs ...uint) {
+	if len(tagIDs) == 0 {
+		return
+	}
+	commonDb, _ := GetCommonDB()
+	var sceneIDs []uint
+	commonDb.Table("scene_tags").Where("tag_id in (?)", tagIDs).Pluck("distinct scene_id", &sceneIDs)
+	QueueSceneIndex(sceneIDs...)
+}
+
+// TakeSceneIndexQueue removes up to max queued scenes from the queue, deleted scenes are always all taken
+func TakeSceneIndexQueue(max int) ([]uint, []string) {
+	searchQueueMutex.Lock()
+	defer searchQueueMutex.Unlock()
+
+	ids := make([]uint, 0, min(max, len(searchQueueScenes)))
+	for id := range searchQueueScenes {
+		if len(ids) == max {
+			break
+		}
+		ids = append(ids, id)
+		delete(searchQueueScenes, id)
+	}
+	deleted := make([]string, 0, len(searchQueueDeletes))
+	for id := range searchQueueDeletes {
+		deleted = append(deleted, id)
+		delete(searchQueueDeletes, id)
+	}
+	return ids, deleted
+}
+
+// SceneIndexQueueSignal receives when scenes were queued
+func SceneIndexQueueSignal() <-chan struct{} {
+	return searchQueueSignal
+}
+
+// SceneIndexQueueState returns the number of queued scenes and when the oldest was queued
+func SceneIndexQueueState() (int, time.Time) {
+	searchQueueMutex.Lock()
+	defer searchQueueMutex.Unlock()
+
+	var oldest time.Time
+	for _, queued := range searchQueueScenes {
+		if oldest.IsZero() || queued.Before(oldest) {
+			oldest = queued
+		}
+	}
+	for _, queued := range searchQueueDeletes {
+		if oldest.IsZero() || queued.Before(oldest) {
+			oldest = queued
+		}
+	}
+	return len(searchQueueScenes) + len(searchQueueDeletes), oldest
+}
+
+func signalSearchQueue() {
+	select {
+	case searchQueueSignal <- struct{}{}:
+	default:
+	}
+}
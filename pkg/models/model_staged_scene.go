@@ -0,0 +1,127 @@
+package models
+
+import (
+	"encoding/json"
+	"sort"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/thoas/go-funk"
+)
+
+// StagedScene is a scraped scene waiting to be approved before it is written to the scenes table. A scene is
+// staged once, later scrapes replace the staged data.
+type StagedScene struct {
+	ID        uint      `gorm:"primary_key" json:"id"`
+	CreatedAt time.Time `json:"created_at"`
+	UpdatedAt time.Time `json:"updated_at"`
+	SceneID   string    `gorm:"unique_index" json:"scene_id"`
+	ScraperID string    `gorm:"index" json:"scraper_id"`
+	Title     string    `json:"title"`
+	IsNew     bool      `json:"is_new"`
+	Data      string    `sql:"type:longtext;" json:"-"` // json of the ScrapedScene
+}
+
+// SceneFieldDiff is a field of a scene that a staged scene changes
+type SceneFieldDiff struct {
+	Field string      `json:"field"`
+	Old   interface{} `json:"old"`
+	New   interface{} `json:"new"`
+}
+
+func (s *StagedScene) GetScrapedScene() ScrapedScene {
+	var ext ScrapedScene
+	json.Unmarshal([]byte(s.Data), &ext)
+	return ext
+}
+
+// StageScrapedScene stores a scraped scene for review, replacing an earlier staged version of the scene
+func StageScrapedScene(db *gorm.DB, ext ScrapedScene, isNew bool) error {
+	data, err := json.Marshal(ext)
+	if err != nil {
+		return err
+	}
+	var staged StagedScene
+	db.Where(&StagedScene{SceneID: ext.SceneID}).FirstOrInit(&staged)
+	staged.ScraperID = ext.ScraperID
+	staged.Title = ext.Title
+	staged.IsNew = isNew
+	staged.Data = string(data)
+	return db.Save(&staged).Error
+}
+
+// DiffScrapedScene lists the fields a scraped scene would change when it is written to the scene. The scene
+// needs its tags and cast preloaded.
+func (o *Scene) DiffScrapedScene(ext ScrapedScene) []SceneFieldDiff {
+	diffs := []SceneFieldDiff{}
+	addString := func(field string, old string, new string) {
+		if old != new {
+			diffs = append(diffs, SceneFieldDiff{Field: field, Old: old, New: new})
+		}
+	}
+	addList := func(field string, old []string, new []string) {
+		sort.Strings(old)
+		sort.Strings(new)
+		if strings.Join(old, "\n") != strings.Join(new, "\n") {
+			diffs = append(diffs, SceneFieldDiff{Field: field, Old: old, New: new})
+		}
+	}
+
+	addString("title", o.Title, ext.Title)
+	addString("synopsis", o.Synopsis, ext.Synopsis)
+	addString("studio", o.Studio, ext.Studio)
+	addString("site", o.Site, ext.Site)
+	addString("release_date_text", o.ReleaseDateText, ext.Released)
+	addString("scene_url", o.SceneURL, ext.HomepageURL)
+	addString("trailer_source", o.TrailerSource, ext.TrailerSrc)
+	if o.Duration != ext.Duration {
+		diffs = append(diffs, SceneFieldDiff{Field: "duration", Old: o.Duration, New: ext.Duration})
+	}
+	cover := ""
+	if len(ext.Covers) > 0 {
+		cover = ext.Covers[0]
+	}
+	addString("cover_url", o.CoverURL, cover)
+
+	var gallery []string
+	var images []Image
+	json.Unmarshal([]byte(o.Images), &images)
+	for _, img := range images {
+		if img.Type == "gallery" {
+			gallery = append(gallery, img.URL)
+		}
+	}
+	var newGallery []string
+	for _, url := range ext.Gallery {
+		if url != "" {
+			newGallery = append(newGallery, url)
+		}
+	}
+	addList("gallery", gallery, newGallery)
+
+	var tags, newTags []string
+	for _, tag := range o.Tags {
+		tags = append(tags, tag.Name)
+	}
+	for _, name := range ext.Tags {
+		if tag := ConvertTag(name); tag != "" && !funk.ContainsString(newTags, tag) {
+			newTags = append(newTags, tag)
+		}
+	}
+	addList("tags", tags, newTags)
+
+	var cast, newCast []string
+	for _, actor := range o.Cast {
+		cast = append(cast, actor.Name)
+	}
+	for _, name := range ext.Cast {
+		newCast = append(newCast, strings.Replace(name, ".", "", -1))
+	}
+	addList("cast", cast, newCast)
+
+	var filenames []string
+	json.Unmarshal([]byte(o.FilenamesArr), &filenames)
+	addList("filenames", filenames, append([]string{}, ext.Filenames...))
+	return diffs
+}
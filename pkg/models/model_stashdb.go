@@ -63,18 +63,26 @@ type StashURL struct {
 }
 
 type StashScene struct {
-	ID         string             `json:"id"`
-	Title      string             `json:"title"`
-	Details    string             `json:"details"`
-	Date       string             `json:"date"`
-	Updated    time.Time          `json:"updated"`
-	URLs       []StashURL         `json:"urls"`
-	Performers []StashPerformerAs `json:"performers"`
-	Studio     StashStudio        `json:"studio"`
-	Duration   int                `json:"duration"`
-	Code       string             `json:"code"`
-	Images     []StashImages      `json:"images"`
-	Tags       []StashTags        `json:"tags"`
+	ID           string             `json:"id"`
+	Title        string             `json:"title"`
+	Details      string             `json:"details"`
+	Date         string             `json:"date"`
+	Updated      time.Time          `json:"updated"`
+	URLs         []StashURL         `json:"urls"`
+	Performers   []StashPerformerAs `json:"performers"`
+	Studio       StashStudio        `json:"studio"`
+	Duration     int                `json:"duration"`
+	Code         string             `json:"code"`
+	Images       []StashImages      `json:"images"`
+	Tags         []StashTags        `json:"tags"`
+	Fingerprints []StashFingerprint `json:"fingerprints"`
+}
+
+type StashFingerprint struct {
+	Hash        string `json:"hash"`
+	Algorithm   string `json:"algorithm"` // MD5, OSHASH or PHASH
+	Duration    int    `json:"duration"`
+	Submissions int    `json:"submissions"`
 }
 
 type StashPerformerAs struct {
@@ -35,6 +35,12 @@ func (i *TagGroup) Save() error {
 		log.Fatal("Failed to save ", err)
 	}
 
+	var tagIDs []uint
+	for _, t := range i.Tags {
+		tagIDs = append(tagIDs, t.ID)
+	}
+	QueueSceneIndexForTags(tagIDs...)
+
 	return nil
 }
 
@@ -22,6 +22,7 @@ type Volume struct {
 	LastScan       time.Time `json:"last_scan" xbvrbackup:""`
 	IsEnabled      bool      `json:"-" xbvrbackup:""`
 	IsAvailable    bool      `json:"is_available" xbvrbackup:"-"`
+	NfoExport      bool      `json:"nfo_export" xbvrbackup:""`
 	FileCount      int       `gorm:"-" json:"file_count" xbvrbackup:"-"`
 	UnmatchedCount int       `gorm:"-" json:"unmatched_count" xbvrbackup:"-"`
 	TotalSize      int64     `gorm:"-" json:"total_size" xbvrbackup:"-"`
@@ -53,6 +54,28 @@ func (o *Volume) IsMounted() bool {
 		return true
 	case "putio":
 		return true
+	case "sftp", "webdav", "smb":
+		fs, err := o.GetRemoteFS()
+		if err != nil {
+			log.Warnf("Can't connect to %v: %v", o.Path, err)
+			return false
+		}
+		defer fs.Close()
+		meta, _ := o.GetRemoteMetadata()
+		if _, err := fs.Stat(meta.Root); err != nil {
+			return false
+		}
+		return true
+	case "s3":
+		client, meta, err := o.GetS3Client()
+		if err != nil {
+			return false
+		}
+		ok, err := client.BucketExists(context.Background(), meta.Bucket)
+		if err != nil {
+			log.Warnf("Can't connect to %v: %v", o.Path, err)
+		}
+		return ok
 	default:
 		return false
 	}
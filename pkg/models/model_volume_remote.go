@@ -0,0 +1,383 @@
+package models
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/hirochachacha/go-smb2"
+	"github.com/pkg/sftp"
+	"github.com/studio-b12/gowebdav"
+	"golang.org/x/crypto/ssh"
+)
+
+// RemoteVolumeTypes are the volume types accessed directly over the network, without an OS mount
+var RemoteVolumeTypes = []string{"sftp", "webdav", "smb"}
+
+const remoteDialTimeout = 15 * time.Second
+
+// RemoteVolumeMetadata holds the connection details of a network volume, stored as JSON in Volume.Metadata
+type RemoteVolumeMetadata struct {
+	Host       string `json:"host"`
+	Port       int    `json:"port"`
+	Username   string `json:"username"`
+	Password   string `json:"password"`
+	PrivateKey string `json:"private_key,omitempty"` // sftp only, PEM encoded
+	HostKey    string `json:"host_key,omitempty"`    // sftp only, SHA256 fingerprint as shown by ssh-keygen -lf, pinned on the first connect
+	Share      string `json:"share,omitempty"`       // smb only
+	Domain     string `json:"domain,omitempty"`      // smb only
+	URL        string `json:"url,omitempty"`         // webdav only, base url of the server
+	Root       string `json:"root"`
+}
+
+// RemoteFS is the subset of filesystem operations XBVR needs from a network volume
+type RemoteFS interface {
+	ReadDir(dir string) ([]os.FileInfo, error)
+	Stat(name string) (os.FileInfo, error)
+	Open(name string) (io.ReadSeekCloser, error)
+	Remove(name string) error
+	Close() error
+}
+
+func (o *Volume) IsRemote() bool {
+	for _, t := range RemoteVolumeTypes {
+		if o.Type == t {
+			return true
+		}
+	}
+	return false
+}
+
+func (o *Volume) GetRemoteMetadata() (RemoteVolumeMetadata, error) {
+	var meta RemoteVolumeMetadata
+	err := json.Unmarshal([]byte(o.Metadata), &meta)
+	if meta.Root == "" {
+		meta.Root = "/"
+	}
+	return meta, err
+}
+
+// RemoteDisplayPath builds the url style path shown for a network volume, it never includes credentials
+func (m RemoteVolumeMetadata) RemoteDisplayPath(volType string) string {
+	switch volType {
+	case "webdav":
+		return strings.TrimRight(m.URL, "/") + path.Join("/", m.Root)
+	case "smb":
+		return fmt.Sprintf("smb://%v@%v/%v%v", m.Username, m.hostPort(445), m.Share, path.Join("/", m.Root))
+	default:
+		return fmt.Sprintf("%v://%v@%v%v", volType, m.Username, m.hostPort(22), path.Join("/", m.Root))
+	}
+}
+
+func (m RemoteVolumeMetadata) hostPort(defaultPort int) string {
+	port := m.Port
+	if port == 0 {
+		port = defaultPort
+	}
+	return net.JoinHostPort(m.Host, strconv.Itoa(port))
+}
+
+// GetRemoteFS connects to a network volume, the caller must Close the returned filesystem
+func (o *Volume) GetRemoteFS() (RemoteFS, error) {
+	meta, err := o.GetRemoteMetadata()
+	if err != nil {
+		return nil, err
+	}
+	pinned := meta.HostKey
+	fs, err := ConnectRemoteFS(o.Type, &meta)
+	if err != nil {
+		return nil, err
+	}
+	if meta.HostKey != pinned {
+		// volumes added before host keys were pinned keep the key of their first connect
+		metadata, _ := json.Marshal(meta)
+		o.Metadata = string(metadata)
+		db, _ := GetDB()
+		db.Model(&Volume{}).Where("id = ?", o.ID).Update("metadata", o.Metadata)
+		db.Close()
+		log.Infof("Pinned host key %v of %v", meta.HostKey, o.Path)
+	}
+	return fs, nil
+}
+
+// ConnectRemoteFS connects to a network server. An sftp server without a host key in meta is trusted on this first
+// connect, its key is stored in meta.HostKey and every later connect must present the same key.
+func ConnectRemoteFS(volType string, meta *RemoteVolumeMetadata) (RemoteFS, error) {
+	switch volType {
+	case "sftp":
+		return connectSFTP(meta)
+	case "webdav":
+		return connectWebDAV(*meta)
+	case "smb":
+		return connectSMB(*meta)
+	}
+	return nil, errors.New("unsupported volume type " + volType)
+}
+
+// WalkRemote calls fn for every file below root, directories starting with a dot are skipped. It stops at the
+// first directory that can't be listed.
+func WalkRemote(fs RemoteFS, root string, fn func(name string, info os.FileInfo)) error {
+	entries, err := fs.ReadDir(root)
+	if err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		name := path.Join(root, entry.Name())
+		if entry.IsDir() {
+			if !strings.HasPrefix(entry.Name(), ".") {
+				if err := WalkRemote(fs, name, fn); err != nil {
+					return err
+				}
+			}
+			continue
+		}
+		fn(name, entry)
+	}
+	return nil
+}
+
+// sftp
+
+type sftpFS struct {
+	conn   *ssh.Client
+	client *sftp.Client
+}
+
+func connectSFTP(meta *RemoteVolumeMetadata) (RemoteFS, error) {
+	var auth []ssh.AuthMethod
+	if meta.PrivateKey != "" {
+		var signer ssh.Signer
+		var err error
+		if meta.Password != "" {
+			signer, err = ssh.ParsePrivateKeyWithPassphrase([]byte(meta.PrivateKey), []byte(meta.Password))
+		} else {
+			signer, err = ssh.ParsePrivateKey([]byte(meta.PrivateKey))
+		}
+		if err != nil {
+			return nil, err
+		}
+		auth = append(auth, ssh.PublicKeys(signer))
+	}
+	if meta.Password != "" {
+		auth = append(auth, ssh.Password(meta.Password))
+	}
+
+	pinned := meta.HostKey
+	var seen string
+	hostKeyCallback := func(hostname string, remote net.Addr, key ssh.PublicKey) error {
+		seen = ssh.FingerprintSHA256(key)
+		if pinned != "" && seen != pinned {
+			return errors.New("host key mismatch for " + hostname + ", the server presented " + seen)
+		}
+		return nil
+	}
+
+	conn, err := ssh.Dial("tcp", meta.hostPort(22), &ssh.ClientConfig{
+		User:            meta.Username,
+		Auth:            auth,
+		HostKeyCallback: hostKeyCallback,
+		Timeout:         remoteDialTimeout,
+	})
+	if err != nil {
+		return nil, err
+	}
+	client, err := sftp.NewClient(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	meta.HostKey = seen
+	return &sftpFS{conn: conn, client: client}, nil
+}
+
+func (s *sftpFS) ReadDir(dir string) ([]os.FileInfo, error) {
+	return s.client.ReadDir(dir)
+}
+
+func (s *sftpFS) Stat(name string) (os.FileInfo, error) {
+	return s.client.Stat(name)
+}
+
+func (s *sftpFS) Open(name string) (io.ReadSeekCloser, error) {
+	return s.client.Open(name)
+}
+
+func (s *sftpFS) Remove(name string) error {
+	return s.client.Remove(name)
+}
+
+func (s *sftpFS) Close() error {
+	s.client.Close()
+	return s.conn.Close()
+}
+
+// webdav
+
+type webdavFS struct {
+	client *gowebdav.Client
+}
+
+func connectWebDAV(meta RemoteVolumeMetadata) (RemoteFS, error) {
+	client := gowebdav.NewClient(meta.URL, meta.Username, meta.Password)
+	client.SetTimeout(remoteDialTimeout)
+	if err := client.Connect(); err != nil {
+		return nil, err
+	}
+	// the timeout also applies to reading response bodies, which would break streaming
+	client.SetTimeout(0)
+	return &webdavFS{client: client}, nil
+}
+
+func (w *webdavFS) ReadDir(dir string) ([]os.FileInfo, error) {
+	return w.client.ReadDir(dir)
+}
+
+func (w *webdavFS) Stat(name string) (os.FileInfo, error) {
+	return w.client.Stat(name)
+}
+
+func (w *webdavFS) Open(name string) (io.ReadSeekCloser, error) {
+	fi, err := w.client.Stat(name)
+	if err != nil {
+		return nil, err
+	}
+	return &rangeReader{size: fi.Size(), open: func(offset int64, length int64) (io.ReadCloser, error) {
+		return w.client.ReadStreamRange(name, offset, length)
+	}}, nil
+}
+
+func (w *webdavFS) Remove(name string) error {
+	return w.client.Remove(name)
+}
+
+func (w *webdavFS) Close() error {
+	return nil
+}
+
+// rangeReader turns HTTP range requests into an io.ReadSeeker, a new request is only made
+// when reading after a seek
+type rangeReader struct {
+	size   int64
+	offset int64
+	open   func(offset int64, length int64) (io.ReadCloser, error)
+	body   io.ReadCloser
+}
+
+func (r *rangeReader) Read(p []byte) (int, error) {
+	if r.offset >= r.size {
+		return 0, io.EOF
+	}
+	if r.body == nil {
+		body, err := r.open(r.offset, r.size-r.offset)
+		if err != nil {
+			return 0, err
+		}
+		r.body = body
+	}
+	n, err := r.body.Read(p)
+	r.offset += int64(n)
+	if err == io.EOF && r.offset < r.size {
+		r.body.Close()
+		r.body = nil
+		err = nil
+	}
+	return n, err
+}
+
+func (r *rangeReader) Seek(offset int64, whence int) (int64, error) {
+	var abs int64
+	switch whence {
+	case io.SeekStart:
+		abs = offset
+	case io.SeekCurrent:
+		abs = r.offset + offset
+	case io.SeekEnd:
+		abs = r.size + offset
+	default:
+		return 0, errors.New("invalid whence")
+	}
+	if abs < 0 {
+		return 0, errors.New("negative position")
+	}
+	if abs != r.offset && r.body != nil {
+		r.body.Close()
+		r.body = nil
+	}
+	r.offset = abs
+	return abs, nil
+}
+
+func (r *rangeReader) Close() error {
+	if r.body != nil {
+		return r.body.Close()
+	}
+	return nil
+}
+
+// smb
+
+type smbFS struct {
+	conn    net.Conn
+	session *smb2.Session
+	share   *smb2.Share
+}
+
+func connectSMB(meta RemoteVolumeMetadata) (RemoteFS, error) {
+	conn, err := net.DialTimeout("tcp", meta.hostPort(445), remoteDialTimeout)
+	if err != nil {
+		return nil, err
+	}
+	dialer := &smb2.Dialer{
+		Initiator: &smb2.NTLMInitiator{
+			User:     meta.Username,
+			Password: meta.Password,
+			Domain:   meta.Domain,
+		},
+	}
+	session, err := dialer.Dial(conn)
+	if err != nil {
+		conn.Close()
+		return nil, err
+	}
+	share, err := session.Mount(meta.Share)
+	if err != nil {
+		session.Logoff()
+		conn.Close()
+		return nil, err
+	}
+	return &smbFS{conn: conn, session: session, share: share}, nil
+}
+
+// smbPath converts the slash separated paths used in the files table to a path relative to the share
+func smbPath(name string) string {
+	return strings.TrimLeft(path.Clean("/"+name), "/")
+}
+
+func (s *smbFS) ReadDir(dir string) ([]os.FileInfo, error) {
+	return s.share.ReadDir(smbPath(dir))
+}
+
+func (s *smbFS) Stat(name string) (os.FileInfo, error) {
+	return s.share.Stat(smbPath(name))
+}
+
+func (s *smbFS) Open(name string) (io.ReadSeekCloser, error) {
+	return s.share.Open(smbPath(name))
+}
+
+func (s *smbFS) Remove(name string) error {
+	return s.share.Remove(smbPath(name))
+}
+
+func (s *smbFS) Close() error {
+	s.share.Umount()
+	s.session.Logoff()
+	return s.conn.Close()
+}
@@ -0,0 +1,68 @@
+package models
+
+import (
+	"context"
+	"encoding/json"
+	"net/url"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/minio/minio-go/v7"
+	"github.com/minio/minio-go/v7/pkg/credentials"
+)
+
+// S3PresignExpiry is how long presigned urls handed to ffprobe and players stay valid
+const S3PresignExpiry = 6 * time.Hour
+
+// S3VolumeMetadata holds the bucket details of a s3 volume, stored as JSON in Volume.Metadata
+type S3VolumeMetadata struct {
+	Endpoint  string `json:"endpoint"` // host[:port] without scheme, e.g. s3.amazonaws.com or minio.lan:9000
+	UseSSL    bool   `json:"use_ssl"`
+	Region    string `json:"region"`
+	Bucket    string `json:"bucket"`
+	Prefix    string `json:"prefix"`
+	AccessKey string `json:"access_key"`
+	SecretKey string `json:"secret_key"`
+	// ProxyPlayback streams through XBVR instead of redirecting players to a presigned url,
+	// needed when players can't reach the endpoint themselves
+	ProxyPlayback bool `json:"proxy_playback"`
+}
+
+func (o *Volume) GetS3Metadata() (S3VolumeMetadata, error) {
+	var meta S3VolumeMetadata
+	err := json.Unmarshal([]byte(o.Metadata), &meta)
+	meta.Prefix = strings.Trim(meta.Prefix, "/")
+	return meta, err
+}
+
+// S3DisplayPath builds the path shown for a s3 volume
+func (m S3VolumeMetadata) S3DisplayPath() string {
+	return "s3://" + path.Join(m.Endpoint, m.Bucket, strings.Trim(m.Prefix, "/"))
+}
+
+func (m S3VolumeMetadata) NewClient() (*minio.Client, error) {
+	return minio.New(m.Endpoint, &minio.Options{
+		Creds:  credentials.NewStaticV4(m.AccessKey, m.SecretKey, ""),
+		Secure: m.UseSSL,
+		Region: m.Region,
+	})
+}
+
+func (o *Volume) GetS3Client() (*minio.Client, S3VolumeMetadata, error) {
+	meta, err := o.GetS3Metadata()
+	if err != nil {
+		return nil, meta, err
+	}
+	client, err := meta.NewClient()
+	return client, meta, err
+}
+
+// GetS3PresignedURL returns a temporary url to download a file from a s3 volume
+func (f *File) GetS3PresignedURL() (*url.URL, error) {
+	client, meta, err := f.Volume.GetS3Client()
+	if err != nil {
+		return nil, err
+	}
+	return client.PresignedGetObject(context.Background(), meta.Bucket, f.GetRemotePath(), S3PresignExpiry, nil)
+}
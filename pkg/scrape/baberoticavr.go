@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"encoding/csv"
 	"encoding/json"
 	"fmt"
@@ -8,19 +9,18 @@ import (
 	"strconv"
 	"strings"
 
-	"github.com/go-resty/resty/v2"
 	"github.com/gocolly/colly/v2"
 	"github.com/gosimple/slug"
 	"github.com/thoas/go-funk"
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func BaberoticaVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func BaberoticaVR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "baberoticavr"
 	siteID := "BaberoticaVR"
 	logScrapeStart(scraperID, siteID)
-	additionalDetailCollector := createCollector("baberoticavr.com")
+	additionalDetailCollector := createCollector(ctx, "baberoticavr.com")
 
 	additionalDetailCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
 		sc := e.Request.Ctx.GetAny("scene").(models.ScrapedScene)
@@ -30,7 +30,7 @@ func BaberoticaVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, ou
 		out <- sc
 	})
 
-	resp, err := resty.New().R().
+	resp, err := newRestyClient().R().
 		SetHeader("User-Agent", UserAgent).
 		SetDoNotParseResponse(true).
 		Get("https://baberoticavr.com/feed/csv/")
@@ -156,7 +156,7 @@ func BaberoticaVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, ou
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"os"
@@ -21,12 +22,12 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func BadoinkSite(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, scraperID string, siteID string, company string, URL string, singeScrapeAdditionalInfo string, limitScraping bool, masterSiteId string, ogSite bool) error {
+func BadoinkSite(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, scraperID string, siteID string, company string, URL string, singeScrapeAdditionalInfo string, limitScraping bool, masterSiteId string, ogSite bool) error {
 	defer wg.Done()
 	logScrapeStart(scraperID, siteID)
 
-	sceneCollector := createCollector("badoinkvr.com", "babevr.com", "vrcosplayx.com", "18vr.com", "realvr.com")
-	siteCollector := createCollector("badoinkvr.com", "babevr.com", "vrcosplayx.com", "18vr.com", "realvr.com")
+	sceneCollector := createCollector(ctx, "badoinkvr.com", "babevr.com", "vrcosplayx.com", "18vr.com", "realvr.com")
+	siteCollector := createCollector(ctx, "badoinkvr.com", "babevr.com", "vrcosplayx.com", "18vr.com", "realvr.com")
 
 	trailerCollector := cloneCollector(sceneCollector)
 
@@ -261,7 +262,7 @@ func BadoinkSite(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 
 	logScrapeFinished(scraperID, siteID)
@@ -269,20 +270,20 @@ func BadoinkSite(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 	return nil
 }
 
-func BadoinkVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
-	return BadoinkSite(wg, updateSite, knownScenes, out, singleSceneURL, "badoinkvr", "BadoinkVR", "Badoink", "https://badoinkvr.com/vrpornvideos?order=newest", singeScrapeAdditionalInfo, limitScraping, "", true)
+func BadoinkVR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+	return BadoinkSite(ctx, wg, updateSite, knownScenes, out, singleSceneURL, "badoinkvr", "BadoinkVR", "Badoink", "https://badoinkvr.com/vrpornvideos?order=newest", singeScrapeAdditionalInfo, limitScraping, "", true)
 }
 
-func B18VR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
-	return BadoinkSite(wg, updateSite, knownScenes, out, singleSceneURL, "18vr", "18VR", "Badoink", "https://18vr.com/vrpornvideos?order=newest", singeScrapeAdditionalInfo, limitScraping, "", true)
+func B18VR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+	return BadoinkSite(ctx, wg, updateSite, knownScenes, out, singleSceneURL, "18vr", "18VR", "Badoink", "https://18vr.com/vrpornvideos?order=newest", singeScrapeAdditionalInfo, limitScraping, "", true)
 }
 
-func VRCosplayX(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
-	return BadoinkSite(wg, updateSite, knownScenes, out, singleSceneURL, "vrcosplayx", "VRCosplayX", "Badoink", "https://vrcosplayx.com/cosplaypornvideos?order=newest", singeScrapeAdditionalInfo, limitScraping, "", true)
+func VRCosplayX(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+	return BadoinkSite(ctx, wg, updateSite, knownScenes, out, singleSceneURL, "vrcosplayx", "VRCosplayX", "Badoink", "https://vrcosplayx.com/cosplaypornvideos?order=newest", singeScrapeAdditionalInfo, limitScraping, "", true)
 }
 
-func BabeVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
-	return BadoinkSite(wg, updateSite, knownScenes, out, singleSceneURL, "babevr", "BabeVR", "Badoink", "https://babevr.com/vrpornvideos?order=newest", singeScrapeAdditionalInfo, limitScraping, "", true)
+func BabeVR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+	return BadoinkSite(ctx, wg, updateSite, knownScenes, out, singleSceneURL, "babevr", "BabeVR", "Badoink", "https://babevr.com/vrpornvideos?order=newest", singeScrapeAdditionalInfo, limitScraping, "", true)
 }
 
 func init() {
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"strconv"
 	"strings"
@@ -14,14 +15,14 @@ import (
 	"golang.org/x/text/language"
 )
 
-func CariVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func CariVR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "caribbeancomvr"
 	siteID := "CaribbeanCom VR"
 	logScrapeStart(scraperID, siteID)
 
-	sceneCollector := createCollector("en.caribbeancom.com", "www.caribbeancom.com")
-	siteCollector := createCollector("en.caribbeancom.com", "www.caribbeancom.com")
+	sceneCollector := createCollector(ctx, "en.caribbeancom.com", "www.caribbeancom.com")
+	siteCollector := createCollector(ctx, "en.caribbeancom.com", "www.caribbeancom.com")
 	sceneCollectorJap := cloneCollector(sceneCollector)
 
 	sceneCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
@@ -138,7 +139,7 @@ func CariVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"strconv"
@@ -12,14 +13,14 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func DarkRoomVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func DarkRoomVR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "darkroomvr"
 	siteID := "DarkRoomVR"
 	logScrapeStart(scraperID, siteID)
 
-	sceneCollector := createCollector("darkroomvr.com")
-	siteCollector := createCollector("darkroomvr.com")
+	sceneCollector := createCollector(ctx, "darkroomvr.com")
+	siteCollector := createCollector(ctx, "darkroomvr.com")
 
 	sceneCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
 		sc := models.ScrapedScene{}
@@ -136,7 +137,7 @@ func DarkRoomVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -0,0 +1,244 @@
+package scrape
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/base64"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"sort"
+	"sync"
+	"unicode/utf8"
+
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+// Fixtures let scrapers run offline against recorded responses, so site changes can be caught by comparing the
+// scraped scenes with a golden file. A fixture folder holds fixture.json, a responses folder and golden.json.
+const (
+	FixtureRecord = "record"
+	FixtureReplay = "replay"
+)
+
+// ScraperFixture is the fixture.json of a fixture folder
+type ScraperFixture struct {
+	ScraperID      string `json:"scraper_id"`
+	SingleSceneURL string `json:"single_scene_url,omitempty"` // scrape one scene instead of the first listing page
+}
+
+type fixtureResponse struct {
+	Method     string              `json:"method"`
+	URL        string              `json:"url"`
+	StatusCode int                 `json:"status_code"`
+	Header     map[string][]string `json:"header"`
+	Body       string              `json:"body,omitempty"`
+	BodyBase64 string              `json:"body_base64,omitempty"`
+}
+
+type fixtureTransport struct {
+	mode string
+	dir  string
+	next http.RoundTripper
+}
+
+var (
+	fixtureMutex     sync.Mutex
+	activeFixture    *fixtureTransport
+	defaultTransport http.RoundTripper
+)
+
+func (t *fixtureTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	fName := filepath.Join(t.dir, "responses", fixtureKey(req)+".json")
+
+	if t.mode == FixtureReplay {
+		b, err := os.ReadFile(fName)
+		if err != nil {
+			log.Warnf("No recorded response for %v %v", req.Method, req.URL)
+			return &http.Response{StatusCode: http.StatusNotFound, Status: "404 Not Found", Header: http.Header{}, Body: io.NopCloser(bytes.NewReader(nil)), Request: req}, nil
+		}
+		var rec fixtureResponse
+		if err := json.Unmarshal(b, &rec); err != nil {
+			return nil, fmt.Errorf("%v: %v", fName, err)
+		}
+		body := []byte(rec.Body)
+		if rec.BodyBase64 != "" {
+			body, _ = base64.StdEncoding.DecodeString(rec.BodyBase64)
+		}
+		return &http.Response{
+			StatusCode:    rec.StatusCode,
+			Status:        fmt.Sprintf("%d %s", rec.StatusCode, http.StatusText(rec.StatusCode)),
+			Header:        http.Header(rec.Header),
+			Body:          io.NopCloser(bytes.NewReader(body)),
+			ContentLength: int64(len(body)),
+			Request:       req,
+		}, nil
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+	body, err := io.ReadAll(resp.Body)
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(body))
+
+	// the body is stored decoded, so the encoding headers no longer apply
+	header := resp.Header.Clone()
+	header.Del("Content-Encoding")
+	header.Del("Content-Length")
+	header.Del("Set-Cookie")
+	rec := fixtureResponse{Method: req.Method, URL: req.URL.String(), StatusCode: resp.StatusCode, Header: header}
+	if utf8.Valid(body) {
+		rec.Body = string(body)
+	} else {
+		rec.BodyBase64 = base64.StdEncoding.EncodeToString(body)
+	}
+	os.MkdirAll(filepath.Dir(fName), os.ModePerm)
+	out, _ := json.MarshalIndent(rec, "", "  ")
+	if err := os.WriteFile(fName, out, 0644); err != nil {
+		log.Warnf("Can't record response for %v: %v", req.URL, err)
+	}
+	return resp, nil
+}
+
+// fixtureKey identifies a request by method, url and body
+func fixtureKey(req *http.Request) string {
+	h := sha1.New()
+	h.Write([]byte(req.Method + " " + req.URL.String()))
+	if req.GetBody != nil {
+		if body, err := req.GetBody(); err == nil {
+			io.Copy(h, body)
+			body.Close()
+		}
+	}
+	return hex.EncodeToString(h.Sum(nil))[:16]
+}
+
+// getFixtureTransport returns the transport collectors use while a fixture is recorded or replayed
+func getFixtureTransport() http.RoundTripper {
+	if activeFixture == nil {
+		return nil
+	}
+	return activeFixture
+}
+
+// RunScraperFixture runs the scraper of a fixture folder. In record mode the responses of the live site are
+// saved, in replay mode only saved responses are used. Scenes are returned sorted by scene id.
+func RunScraperFixture(dir string, mode string) ([]models.ScrapedScene, error) {
+	if mode != FixtureRecord && mode != FixtureReplay {
+		return nil, fmt.Errorf("unknown fixture mode %v", mode)
+	}
+	var fixture ScraperFixture
+	b, err := os.ReadFile(filepath.Join(dir, "fixture.json"))
+	if err != nil {
+		return nil, err
+	}
+	if err := json.Unmarshal(b, &fixture); err != nil {
+		return nil, err
+	}
+
+	var scraper models.Scraper
+	for _, s := range models.GetScrapers() {
+		if s.ID == fixture.ScraperID {
+			scraper = s
+		}
+	}
+	if scraper.Scrape == nil {
+		return nil, fmt.Errorf("scraper %v not found", fixture.ScraperID)
+	}
+
+	if mode == FixtureRecord {
+		os.RemoveAll(filepath.Join(dir, "responses"))
+	}
+
+	// scrapers using net/http directly go through the default transport
+	fixtureMutex.Lock()
+	defer fixtureMutex.Unlock()
+	defaultTransport = http.DefaultTransport
+	activeFixture = &fixtureTransport{mode: mode, dir: dir, next: defaultTransport}
+	http.DefaultTransport = activeFixture
+	defer func() {
+		http.DefaultTransport = defaultTransport
+		activeFixture = nil
+	}()
+
+	out := make(chan models.ScrapedScene)
+	scenes := []models.ScrapedScene{}
+	done := make(chan struct{})
+	go func() {
+		for sc := range out {
+			scenes = append(scenes, sc)
+		}
+		close(done)
+	}()
+
+	var wg models.ScrapeWG
+	wg.Add(1)
+	err = scraper.Scrape(context.Background(), &wg, false, []string{}, out, fixture.SingleSceneURL, "", true)
+	close(out)
+	<-done
+
+	sort.SliceStable(scenes, func(i, j int) bool { return scenes[i].SceneID < scenes[j].SceneID })
+	return scenes, err
+}
+
+// WriteScraperGolden saves the scenes as the expected result of a fixture
+func WriteScraperGolden(dir string, scenes []models.ScrapedScene) error {
+	out, err := json.MarshalIndent(scenes, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(dir, "golden.json"), append(out, '\n'), 0644)
+}
+
+// CompareScraperGolden checks scenes against the golden file of a fixture, listing the fields that differ
+func CompareScraperGolden(dir string, scenes []models.ScrapedScene) error {
+	b, err := os.ReadFile(filepath.Join(dir, "golden.json"))
+	if err != nil {
+		return err
+	}
+	var golden []map[string]interface{}
+	if err := json.Unmarshal(b, &golden); err != nil {
+		return err
+	}
+	b, _ = json.Marshal(scenes)
+	var actual []map[string]interface{}
+	json.Unmarshal(b, &actual)
+
+	var diffs []string
+	if len(golden) != len(actual) {
+		diffs = append(diffs, fmt.Sprintf("expected %v scenes, got %v", len(golden), len(actual)))
+	}
+	for i := 0; i < len(golden) && i < len(actual); i++ {
+		var keys []string
+		for k := range golden[i] {
+			keys = append(keys, k)
+		}
+		sort.Strings(keys)
+		for _, k := range keys {
+			expected, _ := json.Marshal(golden[i][k])
+			got, _ := json.Marshal(actual[i][k])
+			if !bytes.Equal(expected, got) {
+				diffs = append(diffs, fmt.Sprintf("scene %v %v: expected %s, got %s", golden[i]["_id"], k, expected, got))
+			}
+		}
+	}
+	if len(diffs) > 0 {
+		msg := ""
+		for _, d := range diffs {
+			msg += d + "\n"
+		}
+		return errors.New(msg)
+	}
+	return nil
+}
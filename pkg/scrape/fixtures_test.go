@@ -0,0 +1,62 @@
+package scrape
+
+import (
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+// To add a fixture, create testdata/fixtures/<name>/fixture.json with the scraper_id and record it from the live site:
+//
+//	go test ./pkg/scrape -run TestScraperFixtures -record <name>
+//
+// Use -update to rewrite the golden files from the recorded responses after an intended change to a scraper.
+var (
+	recordFixtures = flag.String("record", "", "comma separated fixtures to record from the live sites")
+	updateGolden   = flag.Bool("update", false, "rewrite the golden files from the recorded responses")
+)
+
+func TestScraperFixtures(t *testing.T) {
+	dirs, _ := filepath.Glob(filepath.Join("testdata", "fixtures", "*"))
+	if len(dirs) == 0 {
+		t.Skip("no scraper fixtures")
+	}
+
+	record := map[string]bool{}
+	for _, name := range strings.Split(*recordFixtures, ",") {
+		if name != "" {
+			record[name] = true
+		}
+	}
+
+	for _, dir := range dirs {
+		dir := dir
+		name := filepath.Base(dir)
+		t.Run(name, func(t *testing.T) {
+			mode := FixtureReplay
+			if record[name] {
+				mode = FixtureRecord
+			}
+			scenes, err := RunScraperFixture(dir, mode)
+			if err != nil {
+				t.Fatal(err)
+			}
+
+			if mode == FixtureRecord || *updateGolden {
+				if err := WriteScraperGolden(dir, scenes); err != nil {
+					t.Fatal(err)
+				}
+				t.Logf("wrote golden file with %v scenes", len(scenes))
+				return
+			}
+			if _, err := os.Stat(filepath.Join(dir, "golden.json")); os.IsNotExist(err) {
+				t.Fatal("golden.json is missing, record the fixture or run with -update")
+			}
+			if err := CompareScraperGolden(dir, scenes); err != nil {
+				t.Error(err)
+			}
+		})
+	}
+}
@@ -278,7 +278,7 @@ func createFlareSolverrCollector(domains ...string) *colly.Collector {
 	)
 
 	c.SetClient(&http.Client{
-		Transport: transport,
+		Transport: newHTTPCacheTransport(newRateLimitTransport(transport)),
 		Timeout:   300 * time.Second,
 	})
 
@@ -304,69 +304,24 @@ func createFlareSolverrCollector(domains ...string) *colly.Collector {
 // FlareSolverrGet performs an HTTP GET request through FlareSolverr
 // This is useful for API-based scrapers that use resty instead of colly
 func FlareSolverrGet(url string) (string, error) {
-	transport := getSharedFlareSolverrTransport()
-
-	// Ensure session is created
-	transport.mu.Lock()
-	if transport.sessionID == "" {
-		if err := transport.createSession(); err != nil {
-			transport.mu.Unlock()
-			return "", fmt.Errorf("session creation failed: %w", err)
-		}
-	}
-	sessionID := transport.sessionID
-	transport.mu.Unlock()
-
-	// Random delay 100-300ms
-	delay := time.Duration(100+rand.Intn(200)) * time.Millisecond
-	time.Sleep(delay)
-
 	log.Infof("FlareSolverr GET: %s", url)
 
-	if transport.baseURL == "" {
-		return "", fmt.Errorf("flaresolverr address not configured")
-	}
-
-	payload := map[string]interface{}{
-		"cmd":        "request.get",
-		"url":        url,
-		"session":    sessionID,
-		"maxTimeout": 120000,
+	req, err := http.NewRequest("GET", url, nil)
+	if err != nil {
+		return "", err
 	}
-
-	jsonPayload, _ := json.Marshal(payload)
-
-	ctx, cancel := context.WithTimeout(context.Background(), 300*time.Second)
-	defer cancel()
-
-	req, _ := http.NewRequestWithContext(ctx, "POST", transport.baseURL+"/v1", bytes.NewReader(jsonPayload))
-	req.Header.Set("Content-Type", "application/json")
-
-	resp, err := transport.client.Do(req)
+	// FlareSolverr can't send conditional requests, so only sites with a cache TTL get cached responses
+	resp, err := newHTTPCacheTransport(newRateLimitTransport(getSharedFlareSolverrTransport())).RoundTrip(req)
 	if err != nil {
-		log.Errorf("FlareSolverr request failed: %v", err)
 		return "", err
 	}
 	defer resp.Body.Close()
 
-	var result struct {
-		Status   string `json:"status"`
-		Message  string `json:"message"`
-		Solution struct {
-			Response string `json:"response"`
-			Status   int    `json:"status"`
-		} `json:"solution"`
-	}
-
-	body, _ := io.ReadAll(resp.Body)
-	if err := json.Unmarshal(body, &result); err != nil {
-		return "", fmt.Errorf("failed to parse response: %w", err)
-	}
-
-	if result.Status != "ok" {
-		return "", fmt.Errorf("flaresolverr error: %s (%s)", result.Status, result.Message)
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return "", err
 	}
 
-	log.Debugf("FlareSolverr GET response: %s (%d bytes)", url, len(result.Solution.Response))
-	return result.Solution.Response, nil
+	log.Debugf("FlareSolverr GET response: %s (%d bytes)", url, len(body))
+	return string(body), nil
 }
@@ -1,28 +1,28 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"regexp"
 	"strings"
 
-	"github.com/go-resty/resty/v2"
 	"github.com/gocolly/colly/v2"
 	"github.com/nleeper/goment"
 	"github.com/thoas/go-funk"
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func FuckPassVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func FuckPassVR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "fuckpassvr-native"
 	siteID := "FuckPassVR"
 	logScrapeStart(scraperID, siteID)
 
-	sceneCollector := createCollector("www.fuckpassvr.com")
-	siteCollector := createCollector("www.fuckpassvr.com")
+	sceneCollector := createCollector(ctx, "www.fuckpassvr.com")
+	siteCollector := createCollector(ctx, "www.fuckpassvr.com")
 
-	client := resty.New()
+	client := newRestyClient()
 	client.SetHeader("User-Agent", UserAgent)
 
 	sceneCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
@@ -127,7 +127,7 @@ func FuckPassVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -0,0 +1,353 @@
+package scrape
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/gocolly/colly/v2"
+	"github.com/mozillazg/go-slugify"
+	"github.com/thoas/go-funk"
+	"github.com/xbapps/xbvr/pkg/common"
+	"github.com/xbapps/xbvr/pkg/models"
+	"go.yaml.in/yaml/v3"
+)
+
+var (
+	sceneScraperIdRe    = regexp.MustCompile(`^[a-z0-9][a-z0-9-]*$`)
+	sceneScraperDigitRe = regexp.MustCompile(`\d+`)
+)
+
+// defaultListingMaxPages limits page_url listings of definitions without max_pages
+const defaultListingMaxPages = 500
+
+// LoadSceneScraperDefinitions registers a scraper for each json or yaml definition in the scene_scrapers
+// folder of the app dir. It has to run before the sites are initialised.
+func LoadSceneScraperDefinitions() {
+	dir := filepath.Join(common.AppDir, "scene_scrapers")
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		os.MkdirAll(dir, os.ModePerm)
+		writeSceneScraperExample(dir)
+		return
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Warnf("Can't read scene scraper definitions: %v", err)
+		return
+	}
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		if e.IsDir() || (ext != ".json" && ext != ".yaml" && ext != ".yml") {
+			continue
+		}
+		def, err := ReadSceneScraperDefinition(filepath.Join(dir, e.Name()))
+		if err == nil {
+			err = ValidateSceneScraperDefinition(def)
+		}
+		if err != nil {
+			log.Warnf("Skipping scene scraper %v: %v", e.Name(), err)
+			continue
+		}
+		registerSceneScraperDefinition(def)
+		log.Infof("Loaded scene scraper %v from %v", def.ID, e.Name())
+	}
+}
+
+// ReadSceneScraperDefinition reads a definition file. Yaml is converted to json first, so both
+// formats use the json field names.
+func ReadSceneScraperDefinition(fName string) (models.SceneScraperDefinition, error) {
+	var def models.SceneScraperDefinition
+	b, err := os.ReadFile(fName)
+	if err != nil {
+		return def, err
+	}
+	ext := strings.ToLower(filepath.Ext(fName))
+	if ext == ".yaml" || ext == ".yml" {
+		var raw interface{}
+		if err := yaml.Unmarshal(b, &raw); err != nil {
+			return def, err
+		}
+		if b, err = json.Marshal(raw); err != nil {
+			return def, err
+		}
+	}
+	err = json.Unmarshal(b, &def)
+	return def, err
+}
+
+func ValidateSceneScraperDefinition(def models.SceneScraperDefinition) error {
+	if !sceneScraperIdRe.MatchString(def.ID) {
+		return errors.New("id has to be lower case letters, digits and dashes")
+	}
+	for _, scraper := range models.GetScrapers() {
+		if scraper.ID == def.ID {
+			return fmt.Errorf("id %v is already used by another scraper", def.ID)
+		}
+	}
+	if def.Name == "" || def.Domain == "" {
+		return errors.New("name and domain are required")
+	}
+	if def.Listing.URL == "" && def.Listing.PageURL == "" {
+		return errors.New("listing url or page_url is required")
+	}
+	if def.Listing.PageURL != "" && !strings.Contains(def.Listing.PageURL, "{page}") {
+		return errors.New("listing page_url has to contain {page}")
+	}
+	if def.Listing.SceneLinks == "" {
+		return errors.New("listing scene_links is required")
+	}
+
+	found := map[string]bool{}
+	for _, rule := range def.Rules {
+		if !funk.ContainsString(models.SceneScraperFields, rule.XbvrField) {
+			return fmt.Errorf("unknown field %v, use one of %v", rule.XbvrField, strings.Join(models.SceneScraperFields, ", "))
+		}
+		if rule.Selector == "" {
+			return fmt.Errorf("rule for %v has no selector", rule.XbvrField)
+		}
+		found[rule.XbvrField] = true
+	}
+	if !found["site_id"] || !found["title"] {
+		return errors.New("rules for site_id and title are required")
+	}
+	return nil
+}
+
+func registerSceneScraperDefinition(def models.SceneScraperDefinition) {
+	registerScraper(def.ID, def.Name+" (Custom)", def.AvatarURL, def.Domain, func(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+		return GenericSceneScraper(ctx, wg, updateSite, knownScenes, out, singleSceneURL, limitScraping, def)
+	})
+}
+
+func GenericSceneScraper(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, limitScraping bool, def models.SceneScraperDefinition) error {
+	defer wg.Done()
+	scraperID := def.ID
+	siteID := def.Name
+	logScrapeStart(scraperID, siteID)
+
+	sceneCollector := createCollector(ctx, def.Domain)
+	siteCollector := createCollector(ctx, def.Domain)
+
+	sceneCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
+		sc := models.ScrapedScene{}
+		sc.ScraperID = scraperID
+		sc.SceneType = "VR"
+		if def.SceneType != "" {
+			sc.SceneType = def.SceneType
+		}
+		sc.Studio = def.Name
+		if def.Studio != "" {
+			sc.Studio = def.Studio
+		}
+		sc.Site = siteID
+		sc.HomepageURL = strings.Split(e.Request.URL.String(), "?")[0]
+
+		for _, rule := range def.Rules {
+			for _, result := range sceneRuleResults(rule, e) {
+				if result != "" {
+					assignSceneField(&sc, rule.XbvrField, result)
+				}
+			}
+		}
+
+		if sc.SiteID == "" || sc.Title == "" {
+			log.Warnf("[%v] No scene id or title found on %v", scraperID, sc.HomepageURL)
+			return
+		}
+		sc.SceneID = scraperID + "-" + slugify.Slugify(sc.SiteID)
+		out <- sc
+	})
+
+	newSceneLinks := 0
+	seenSceneLinks := map[string]bool{}
+	siteCollector.OnHTML(def.Listing.SceneLinks, func(e *colly.HTMLElement) {
+		sceneURL := e.Request.AbsoluteURL(e.Attr("href"))
+		if sceneURL == "" {
+			return
+		}
+		if !seenSceneLinks[sceneURL] {
+			seenSceneLinks[sceneURL] = true
+			newSceneLinks++
+		}
+
+		// If scene exist in database, there's no need to scrape
+		if !funk.ContainsString(knownScenes, sceneURL) {
+			sceneCollector.Visit(sceneURL)
+		}
+	})
+
+	pages := 1
+	if def.Listing.NextPage != "" && def.Listing.PageURL == "" {
+		siteCollector.OnHTML(def.Listing.NextPage, func(e *colly.HTMLElement) {
+			if limitScraping || (def.Listing.MaxPages > 0 && pages >= def.Listing.MaxPages) {
+				return
+			}
+			pageURL := e.Request.AbsoluteURL(e.Attr("href"))
+			if pageURL != "" {
+				pages++
+				siteCollector.Visit(pageURL)
+			}
+		})
+	}
+
+	if singleSceneURL != "" {
+		sceneCollector.Visit(singleSceneURL)
+	} else if def.Listing.PageURL != "" {
+		page := def.Listing.FirstPage
+		if page == 0 {
+			page = 1
+		}
+		maxPages := def.Listing.MaxPages
+		if maxPages == 0 {
+			maxPages = defaultListingMaxPages
+		}
+		// sites often answer page numbers past the end with the last or first page, so a page without
+		// scene links that weren't on an earlier page ends the listing
+		for count := 1; ; count++ {
+			newSceneLinks = 0
+			siteCollector.Visit(strings.ReplaceAll(def.Listing.PageURL, "{page}", strconv.Itoa(page)))
+			if newSceneLinks == 0 || limitScraping || count >= maxPages {
+				break
+			}
+			page++
+		}
+	} else {
+		siteCollector.Visit(def.Listing.URL)
+	}
+
+	if updateSite {
+		updateSiteLastUpdate(ctx, scraperID)
+	}
+	logScrapeFinished(scraperID, siteID)
+	return nil
+}
+
+// sceneRuleResults returns the value of each element matched by the rule, limited by first and last
+func sceneRuleResults(rule models.GenericActorScraperRule, e *colly.HTMLElement) []string {
+	var results []string
+	recordCnt := 1
+	e.ForEach(rule.Selector, func(id int, e *colly.HTMLElement) {
+		if !(rule.First.Present() && rule.First.OrElse(0) > recordCnt) && !(rule.Last.Present() && recordCnt > rule.Last.OrElse(0)) {
+			var result string
+			switch rule.ResultType {
+			case "text", "":
+				result = strings.TrimSpace(e.Text)
+			case "attr":
+				result = strings.TrimSpace(e.Attr(rule.Attribute))
+			case "html":
+				result, _ = e.DOM.Html()
+			}
+			if len(rule.PostProcessing) > 0 {
+				result = postProcessing(rule, result, e)
+			}
+			results = append(results, strings.TrimSpace(result))
+		}
+		recordCnt += 1
+	})
+	return results
+}
+
+func assignSceneField(sc *models.ScrapedScene, field string, value string) {
+	switch field {
+	case "site_id":
+		if sc.SiteID == "" {
+			sc.SiteID = value
+		}
+	case "title":
+		if sc.Title == "" {
+			sc.Title = value
+		}
+	case "synopsis":
+		if sc.Synopsis == "" {
+			sc.Synopsis = value
+		}
+	case "released":
+		if sc.Released == "" {
+			sc.Released = value
+		}
+	case "duration":
+		if sc.Duration == 0 {
+			sc.Duration = parseSceneScraperDuration(value)
+		}
+	case "cover":
+		sc.Covers = append(sc.Covers, value)
+	case "gallery":
+		sc.Gallery = append(sc.Gallery, value)
+	case "tags":
+		for _, tag := range strings.Split(value, ",") {
+			if tag = strings.TrimSpace(tag); tag != "" {
+				sc.Tags = append(sc.Tags, strings.ToLower(tag))
+			}
+		}
+	case "cast":
+		for _, name := range strings.Split(value, ",") {
+			if name = strings.TrimSpace(name); name != "" && !funk.ContainsString(sc.Cast, name) {
+				sc.Cast = append(sc.Cast, name)
+			}
+		}
+	case "filenames":
+		sc.Filenames = append(sc.Filenames, value)
+	case "trailer_url":
+		if sc.TrailerSrc == "" {
+			sc.TrailerType = "url"
+			sc.TrailerSrc = value
+		}
+	case "members_url":
+		sc.MembersUrl = value
+	case "studio":
+		sc.Studio = value
+	}
+}
+
+// parseSceneScraperDuration reads durations like 1:02:30, 45:10 or 45 min as minutes
+func parseSceneScraperDuration(value string) int {
+	if strings.Contains(value, ":") {
+		parts := strings.Split(strings.TrimSpace(value), ":")
+		hours, minutes := 0, 0
+		switch len(parts) {
+		case 2:
+			minutes, _ = strconv.Atoi(sceneScraperDigitRe.FindString(parts[0]))
+		case 3:
+			hours, _ = strconv.Atoi(sceneScraperDigitRe.FindString(parts[0]))
+			minutes, _ = strconv.Atoi(sceneScraperDigitRe.FindString(parts[1]))
+		}
+		return hours*60 + minutes
+	}
+	minutes, _ := strconv.Atoi(sceneScraperDigitRe.FindString(value))
+	return minutes
+}
+
+// writeSceneScraperExample creates a sample definition, it isn't loaded as it doesn't end in .json
+func writeSceneScraperExample(dir string) {
+	example := models.SceneScraperDefinition{
+		ID:        "examplevr",
+		Name:      "ExampleVR",
+		AvatarURL: "https://examplevr.com/favicon.png",
+		Domain:    "examplevr.com",
+		Listing: models.SceneScraperListing{
+			URL:        "https://examplevr.com/videos",
+			SceneLinks: "div.video-card a.title",
+			NextPage:   "a.pagination-next",
+		},
+		Rules: []models.GenericActorScraperRule{
+			{XbvrField: "site_id", Selector: `link[rel="canonical"]`, ResultType: "attr", Attribute: "href", PostProcessing: []models.PostProcessing{{Function: "RegexString", Params: []string{`/video/(\d+)`, "1"}}}},
+			{XbvrField: "title", Selector: "h1"},
+			{XbvrField: "synopsis", Selector: "div.description"},
+			{XbvrField: "released", Selector: "span.release-date", PostProcessing: []models.PostProcessing{{Function: "Parse Date", Params: []string{"Jan 2, 2006"}}}},
+			{XbvrField: "duration", Selector: "span.duration"},
+			{XbvrField: "cover", Selector: `meta[property="og:image"]`, ResultType: "attr", Attribute: "content"},
+			{XbvrField: "gallery", Selector: "div.gallery a", ResultType: "attr", Attribute: "href", PostProcessing: []models.PostProcessing{{Function: "AbsoluteUrl"}}},
+			{XbvrField: "cast", Selector: "div.models a"},
+			{XbvrField: "tags", Selector: "div.tags a"},
+		},
+	}
+	out, _ := json.MarshalIndent(example, "", "  ")
+	os.WriteFile(filepath.Join(dir, "example.json.sample"), out, 0644)
+}
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"regexp"
 	"strconv"
@@ -13,16 +14,16 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func GroobyVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func GroobyVR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "groobyvr"
 	siteID := "GroobyVR"
 	allowedDomains := []string{"groobyvr.com", "www.groobyvr.com"}
 	logScrapeStart(scraperID, siteID)
 
-	sceneCollector := createCollector(allowedDomains...)
-	siteCollector := createCollector(allowedDomains...)
-	vodCollector := createCollector(allowedDomains...)
+	sceneCollector := createCollector(ctx, allowedDomains...)
+	siteCollector := createCollector(ctx, allowedDomains...)
+	vodCollector := createCollector(ctx, allowedDomains...)
 
 	sceneCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
 		sc := models.ScrapedScene{}
@@ -127,7 +128,7 @@ func GroobyVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out ch
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -0,0 +1,446 @@
+package scrape
+
+import (
+	"bytes"
+	"context"
+	"crypto/sha1"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/go-resty/resty/v2"
+	"github.com/xbapps/xbvr/pkg/common"
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+// The HTTP cache keeps scraped responses between scrapes. Responses with an ETag or Last-Modified header are
+// revalidated with a conditional request, sites with a cache TTL use their responses without any request until
+// the TTL expires. Responses are stored per site, so the cache of one site can be cleared.
+const (
+	httpCacheOtherSite   = "_other"
+	httpCacheMaxBodySize = 20 * 1024 * 1024
+	httpCacheMaxAge      = 30 * 24 * time.Hour
+	httpCacheMaxSize     = 1024 * 1024 * 1024
+)
+
+// HTTPCacheDomainStats counts how the requests to a domain were served since startup
+type HTTPCacheDomainStats struct {
+	Domain      string `json:"domain"`
+	Hits        int    `json:"hits"`        // served from the cache without a request
+	Revalidated int    `json:"revalidated"` // the site answered not modified
+	Misses      int    `json:"misses"`
+	BytesSaved  int64  `json:"bytes_saved"`
+}
+
+// HTTPCacheSiteUsage is the disk usage of the cached responses of a site
+type HTTPCacheSiteUsage struct {
+	SiteID  string `json:"site_id"`
+	Entries int    `json:"entries"`
+	Size    int64  `json:"size"`
+}
+
+type httpCacheEntry struct {
+	URL          string      `json:"url"`
+	StatusCode   int         `json:"status_code"`
+	Header       http.Header `json:"header"`
+	ETag         string      `json:"etag"`
+	LastModified string      `json:"last_modified"`
+	StoredAt     time.Time   `json:"stored_at"`
+	BodySize     int         `json:"body_size"`
+}
+
+type httpCacheSiteKey struct{}
+
+var (
+	httpCacheMutex sync.Mutex
+	httpCacheStats = map[string]*HTTPCacheDomainStats{}
+	httpCacheTTLs  map[string]time.Duration
+)
+
+// WithCacheSite marks the requests made with ctx as requests of the site, so they are cached and counted with the site
+func WithCacheSite(ctx context.Context, siteID string) context.Context {
+	return context.WithValue(ctx, httpCacheSiteKey{}, siteID)
+}
+
+// scrapeSiteFromContext returns the site marked by WithCacheSite, or "" for requests of no site
+func scrapeSiteFromContext(ctx context.Context) string {
+	if ctx == nil {
+		return ""
+	}
+	siteID, _ := ctx.Value(httpCacheSiteKey{}).(string)
+	return siteID
+}
+
+// LoadHTTPCachePolicies reads the cache TTLs of the sites
+func LoadHTTPCachePolicies() {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	var sites []models.Site
+	db.Where("cache_ttl > 0").Find(&sites)
+	ttls := make(map[string]time.Duration, len(sites))
+	for _, site := range sites {
+		ttls[site.ID] = time.Duration(site.CacheTTL) * time.Hour
+	}
+
+	httpCacheMutex.Lock()
+	httpCacheTTLs = ttls
+	httpCacheMutex.Unlock()
+}
+
+func getHTTPCacheTTL(siteID string) time.Duration {
+	httpCacheMutex.Lock()
+	loaded := httpCacheTTLs != nil
+	httpCacheMutex.Unlock()
+	if !loaded {
+		LoadHTTPCachePolicies()
+	}
+
+	httpCacheMutex.Lock()
+	defer httpCacheMutex.Unlock()
+	return httpCacheTTLs[siteID]
+}
+
+// GetHTTPCacheStats returns the cache stats of every domain requested since startup
+func GetHTTPCacheStats() []HTTPCacheDomainStats {
+	httpCacheMutex.Lock()
+	defer httpCacheMutex.Unlock()
+
+	stats := make([]HTTPCacheDomainStats, 0, len(httpCacheStats))
+	for _, s := range httpCacheStats {
+		stats = append(stats, *s)
+	}
+	sort.Slice(stats, func(i, j int) bool { return stats[i].Domain < stats[j].Domain })
+	return stats
+}
+
+// GetHTTPCacheUsage returns the disk usage of the cache of every site with cached responses
+func GetHTTPCacheUsage() []HTTPCacheSiteUsage {
+	usage := []HTTPCacheSiteUsage{}
+	dirs, _ := os.ReadDir(common.ScrapeCacheDir)
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		u := HTTPCacheSiteUsage{SiteID: dir.Name()}
+		files, _ := os.ReadDir(filepath.Join(common.ScrapeCacheDir, dir.Name()))
+		for _, f := range files {
+			if strings.HasSuffix(f.Name(), ".json") {
+				u.Entries++
+			}
+			if info, err := f.Info(); err == nil {
+				u.Size += info.Size()
+			}
+		}
+		usage = append(usage, u)
+	}
+	return usage
+}
+
+// ClearHTTPCache removes the cached responses of a site, or of all sites when site is empty
+func ClearHTTPCache(siteID string) error {
+	if siteID == "" {
+		if err := os.RemoveAll(common.ScrapeCacheDir); err != nil {
+			return err
+		}
+		return os.MkdirAll(common.ScrapeCacheDir, os.ModePerm)
+	}
+	return os.RemoveAll(filepath.Join(common.ScrapeCacheDir, filepath.Base(siteID)))
+}
+
+// PruneHTTPCache removes the entries that weren't stored or revalidated for a month, and the oldest entries while
+// the cache is larger than its size limit
+func PruneHTTPCache() {
+	type cachedFile struct {
+		path    string
+		size    int64
+		modTime time.Time
+	}
+
+	var entries []cachedFile
+	var total int64
+	dirs, _ := os.ReadDir(common.ScrapeCacheDir)
+	for _, dir := range dirs {
+		if !dir.IsDir() {
+			continue
+		}
+		files, _ := os.ReadDir(filepath.Join(common.ScrapeCacheDir, dir.Name()))
+		for _, f := range files {
+			path := filepath.Join(common.ScrapeCacheDir, dir.Name(), f.Name())
+			info, err := f.Info()
+			if err != nil {
+				continue
+			}
+			switch {
+			case strings.HasSuffix(f.Name(), ".part"):
+				// left behind by an interrupted write
+				if time.Since(info.ModTime()) > time.Hour {
+					os.Remove(path)
+				}
+			case strings.HasSuffix(f.Name(), ".json"):
+				entry := cachedFile{path: strings.TrimSuffix(path, ".json"), size: info.Size(), modTime: info.ModTime()}
+				if body, err := os.Stat(entry.path + ".body"); err == nil {
+					entry.size += body.Size()
+				}
+				entries = append(entries, entry)
+				total += entry.size
+			}
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool { return entries[i].modTime.Before(entries[j].modTime) })
+	removed := 0
+	for _, entry := range entries {
+		if time.Since(entry.modTime) < httpCacheMaxAge && total <= httpCacheMaxSize {
+			break
+		}
+		removeHTTPCacheEntry(entry.path)
+		total -= entry.size
+		removed++
+	}
+	if removed > 0 {
+		log.Infof("Removed %v expired responses from the scrape cache", removed)
+	}
+}
+
+func recordHTTPCache(host string, apply func(*HTTPCacheDomainStats)) {
+	domain := strings.TrimPrefix(host, "www.")
+	httpCacheMutex.Lock()
+	defer httpCacheMutex.Unlock()
+	s, ok := httpCacheStats[domain]
+	if !ok {
+		s = &HTTPCacheDomainStats{Domain: domain}
+		httpCacheStats[domain] = s
+	}
+	apply(s)
+}
+
+// httpCacheTransport serves GET requests from the cache and stores the responses of next
+type httpCacheTransport struct {
+	next http.RoundTripper
+}
+
+func newHTTPCacheTransport(next http.RoundTripper) *httpCacheTransport {
+	return &httpCacheTransport{next: next}
+}
+
+// newRestyClient returns a resty client that shares the HTTP cache and rate limits of the collectors
+func newRestyClient() *resty.Client {
+	client := resty.New()
+	return client.SetTransport(newHTTPCacheTransport(newRateLimitTransport(client.GetClient().Transport)))
+}
+
+// newScraperTransport returns the transport of collectors, using the scraper proxy when one is configured
+func newScraperTransport(proxy string) http.RoundTripper {
+	transport := &http.Transport{
+		Proxy:                 http.ProxyFromEnvironment,
+		ForceAttemptHTTP2:     true,
+		MaxIdleConns:          100,
+		IdleConnTimeout:       90 * time.Second,
+		TLSHandshakeTimeout:   10 * time.Second,
+		ExpectContinueTimeout: 1 * time.Second,
+	}
+	if proxy != "" {
+		if proxyURL, err := url.Parse(proxy); err == nil {
+			transport.Proxy = http.ProxyURL(proxyURL)
+			transport.DisableKeepAlives = true
+		}
+	}
+	return transport
+}
+
+// RoundTrip serves the request from the cache or the next transport, and counts it for the running scraper
+// of its site. Retries of throttled requests by the rate limit transport are counted once.
+func (t *httpCacheTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	resp, err := t.roundTrip(req)
+	status := 0
+	if err == nil {
+		status = resp.StatusCode
+	}
+	recordScraperRequest(scrapeSiteFromContext(req.Context()), status)
+	return resp, err
+}
+
+func (t *httpCacheTransport) roundTrip(req *http.Request) (*http.Response, error) {
+	// fixtures record every request, so nothing may come from the cache
+	if req.Method != http.MethodGet || req.Header.Get("Range") != "" || getFixtureTransport() != nil {
+		return t.next.RoundTrip(req)
+	}
+
+	siteID := t.siteID(req)
+	ttl := getHTTPCacheTTL(siteID)
+	path := httpCachePath(siteID, req.URL.String())
+	entry, body := readHTTPCacheEntry(path)
+
+	if entry != nil && ttl > 0 && time.Since(entry.StoredAt) < ttl {
+		recordHTTPCache(req.URL.Hostname(), func(s *HTTPCacheDomainStats) {
+			s.Hits++
+			s.BytesSaved += int64(len(body))
+		})
+		return entry.response(req, body), nil
+	}
+
+	if entry != nil && (entry.ETag != "" || entry.LastModified != "") {
+		req = req.Clone(req.Context())
+		if entry.ETag != "" && req.Header.Get("If-None-Match") == "" {
+			req.Header.Set("If-None-Match", entry.ETag)
+		}
+		if entry.LastModified != "" && req.Header.Get("If-Modified-Since") == "" {
+			req.Header.Set("If-Modified-Since", entry.LastModified)
+		}
+	}
+
+	resp, err := t.next.RoundTrip(req)
+	if err != nil {
+		return resp, err
+	}
+
+	if resp.StatusCode == http.StatusNotModified && entry != nil {
+		resp.Body.Close()
+		entry.StoredAt = time.Now()
+		writeHTTPCacheEntry(path, entry, nil)
+		recordHTTPCache(req.URL.Hostname(), func(s *HTTPCacheDomainStats) {
+			s.Revalidated++
+			s.BytesSaved += int64(len(body))
+		})
+		return entry.response(req, body), nil
+	}
+
+	recordHTTPCache(req.URL.Hostname(), func(s *HTTPCacheDomainStats) { s.Misses++ })
+	if resp.StatusCode != http.StatusOK || strings.Contains(resp.Header.Get("Cache-Control"), "no-store") {
+		return resp, nil
+	}
+
+	newEntry := &httpCacheEntry{
+		URL:          req.URL.String(),
+		StatusCode:   resp.StatusCode,
+		Header:       resp.Header.Clone(),
+		ETag:         resp.Header.Get("ETag"),
+		LastModified: resp.Header.Get("Last-Modified"),
+		StoredAt:     time.Now(),
+	}
+	if newEntry.ETag == "" && newEntry.LastModified == "" && ttl == 0 {
+		// the response can't be revalidated and isn't used without revalidation
+		if entry != nil {
+			removeHTTPCacheEntry(path)
+		}
+		return resp, nil
+	}
+
+	newBody, err := io.ReadAll(io.LimitReader(resp.Body, httpCacheMaxBodySize+1))
+	resp.Body.Close()
+	if err != nil {
+		return nil, err
+	}
+	if len(newBody) <= httpCacheMaxBodySize {
+		writeHTTPCacheEntry(path, newEntry, newBody)
+	}
+	resp.Body = io.NopCloser(bytes.NewReader(newBody))
+	return resp, nil
+}
+
+// siteID returns the site a request is cached with, from the request context or the domain of the request
+func (t *httpCacheTransport) siteID(req *http.Request) string {
+	if siteID := scrapeSiteFromContext(req.Context()); siteID != "" {
+		return siteID
+	}
+	host := req.URL.Hostname()
+	if siteID, ok := DomainToSiteID[host]; ok {
+		return siteID
+	}
+	if siteID, ok := DomainToSiteID[strings.TrimPrefix(host, "www.")]; ok {
+		return siteID
+	}
+	return httpCacheOtherSite
+}
+
+func (e *httpCacheEntry) response(req *http.Request, body []byte) *http.Response {
+	return &http.Response{
+		Status:        fmt.Sprintf("%d %s", e.StatusCode, http.StatusText(e.StatusCode)),
+		StatusCode:    e.StatusCode,
+		Proto:         "HTTP/1.1",
+		ProtoMajor:    1,
+		ProtoMinor:    1,
+		Header:        e.Header.Clone(),
+		Body:          io.NopCloser(bytes.NewReader(body)),
+		ContentLength: int64(len(body)),
+		Request:       req,
+	}
+}
+
+func httpCachePath(siteID string, rawURL string) string {
+	sum := sha1.Sum([]byte(rawURL))
+	return filepath.Join(common.ScrapeCacheDir, filepath.Base(siteID), hex.EncodeToString(sum[:]))
+}
+
+func readHTTPCacheEntry(path string) (*httpCacheEntry, []byte) {
+	data, err := os.ReadFile(path + ".json")
+	if err != nil {
+		return nil, nil
+	}
+	var entry httpCacheEntry
+	if err := json.Unmarshal(data, &entry); err != nil {
+		return nil, nil
+	}
+	body, err := os.ReadFile(path + ".body")
+	if err != nil || len(body) != entry.BodySize {
+		// the body was replaced after the entry was read
+		return nil, nil
+	}
+	return &entry, body
+}
+
+// writeHTTPCacheEntry stores an entry, a nil body keeps the stored body
+func writeHTTPCacheEntry(path string, entry *httpCacheEntry, body []byte) {
+	if err := os.MkdirAll(filepath.Dir(path), os.ModePerm); err != nil {
+		log.Warnf("Can't create scrape cache folder: %v", err)
+		return
+	}
+	if body != nil {
+		entry.BodySize = len(body)
+		if err := writeHTTPCacheFile(path+".body", body); err != nil {
+			log.Warnf("Can't cache %v: %v", entry.URL, err)
+			return
+		}
+	}
+	data, _ := json.Marshal(entry)
+	if err := writeHTTPCacheFile(path+".json", data); err != nil {
+		log.Warnf("Can't cache %v: %v", entry.URL, err)
+	}
+}
+
+// writeHTTPCacheFile writes a temporary file and renames it into place, so a concurrent read never sees a partial file
+func writeHTTPCacheFile(dest string, data []byte) error {
+	tmp, err := os.CreateTemp(filepath.Dir(dest), filepath.Base(dest)+".*.part")
+	if err != nil {
+		return err
+	}
+	if _, err := tmp.Write(data); err != nil {
+		tmp.Close()
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := tmp.Close(); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	if err := os.Rename(tmp.Name(), dest); err != nil {
+		os.Remove(tmp.Name())
+		return err
+	}
+	return nil
+}
+
+func removeHTTPCacheEntry(path string) {
+	os.Remove(path + ".json")
+	os.Remove(path + ".body")
+}
@@ -0,0 +1,122 @@
+package scrape
+
+import (
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/xbapps/xbvr/pkg/common"
+)
+
+func TestHTTPCacheRevalidates(t *testing.T) {
+	common.ScrapeCacheDir = t.TempDir()
+	httpCacheTTLs = map[string]time.Duration{"ttlsite": time.Hour}
+
+	requests, downloads := 0, 0
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		requests++
+		if r.Header.Get("If-None-Match") == `"v1"` {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+		downloads++
+		w.Header().Set("ETag", `"v1"`)
+		w.Write([]byte("listing"))
+	}))
+	defer server.Close()
+
+	get := func(siteID string) string {
+		req, _ := http.NewRequestWithContext(WithCacheSite(t.Context(), siteID), "GET", server.URL, nil)
+		resp, err := newHTTPCacheTransport(http.DefaultTransport).RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		defer resp.Body.Close()
+		if resp.StatusCode != http.StatusOK {
+			t.Fatalf("status %v", resp.StatusCode)
+		}
+		body, _ := io.ReadAll(resp.Body)
+		return string(body)
+	}
+
+	for i := 0; i < 3; i++ {
+		if body := get("site"); body != "listing" {
+			t.Fatalf("body %q", body)
+		}
+	}
+	if requests != 3 || downloads != 1 {
+		t.Errorf("got %v requests and %v downloads, want 3 requests and 1 download", requests, downloads)
+	}
+
+	// within the TTL of a site nothing is requested
+	get("ttlsite")
+	get("ttlsite")
+	if requests != 4 {
+		t.Errorf("got %v requests, want 4", requests)
+	}
+
+	ClearHTTPCache("site")
+	get("site")
+	if downloads != 3 {
+		t.Errorf("got %v downloads after clearing the cache, want 3", downloads)
+	}
+}
+
+func TestScraperRequestsCountedBySite(t *testing.T) {
+	common.ScrapeCacheDir = t.TempDir()
+
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.URL.Path == "/missing" {
+			w.WriteHeader(http.StatusNotFound)
+			return
+		}
+		w.Write([]byte("scene"))
+	}))
+	defer server.Close()
+
+	// studios of an aggregator scrape the same domain at the same time
+	StartScraperRunStats("studio-a")
+	StartScraperRunStats("studio-b")
+	get := func(siteID string, path string) {
+		req, _ := http.NewRequestWithContext(WithCacheSite(t.Context(), siteID), "GET", server.URL+path, nil)
+		resp, err := newHTTPCacheTransport(http.DefaultTransport).RoundTrip(req)
+		if err != nil {
+			t.Fatal(err)
+		}
+		resp.Body.Close()
+	}
+	get("studio-a", "/1")
+	get("studio-a", "/missing")
+	get("studio-b", "/2")
+
+	a := FinishScraperRunStats("studio-a")
+	b := FinishScraperRunStats("studio-b")
+	if a.Pages != 2 || a.Statuses[http.StatusNotFound] != 1 {
+		t.Errorf("studio-a counted %v pages and statuses %v, want 2 pages and one 404", a.Pages, a.Statuses)
+	}
+	if b.Pages != 1 || b.Statuses[http.StatusOK] != 1 {
+		t.Errorf("studio-b counted %v pages and statuses %v, want 1 page with status 200", b.Pages, b.Statuses)
+	}
+}
+
+func TestPruneHTTPCache(t *testing.T) {
+	common.ScrapeCacheDir = t.TempDir()
+
+	old := httpCachePath("site", "https://example.com/old")
+	recent := httpCachePath("site", "https://example.com/recent")
+	writeHTTPCacheEntry(old, &httpCacheEntry{URL: "https://example.com/old"}, []byte("old"))
+	writeHTTPCacheEntry(recent, &httpCacheEntry{URL: "https://example.com/recent"}, []byte("recent"))
+	storedAt := time.Now().Add(-httpCacheMaxAge - time.Hour)
+	os.Chtimes(old+".json", storedAt, storedAt)
+
+	PruneHTTPCache()
+	if entry, _ := readHTTPCacheEntry(old); entry != nil {
+		t.Error("the expired entry wasn't removed")
+	}
+	if entry, body := readHTTPCacheEntry(recent); entry == nil || string(body) != "recent" {
+		t.Error("the recent entry was removed")
+	}
+}
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"github.com/PuerkitoBio/goquery"
 	"github.com/gocolly/colly/v2"
 	"github.com/nleeper/goment"
@@ -10,7 +11,7 @@ import (
 )
 
 func ScrapeJavDB(out *[]models.ScrapedScene, queryString string) {
-	sceneCollector := createCollector("www.javdatabase.com")
+	sceneCollector := createCollector(context.Background(), "www.javdatabase.com")
 
 	sceneCollector.OnHTML(`html`, func(html *colly.HTMLElement) {
 		sc := models.ScrapedScene{}
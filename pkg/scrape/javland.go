@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"strings"
 
 	"github.com/gocolly/colly/v2"
@@ -9,7 +10,7 @@ import (
 )
 
 func ScrapeJavLand(out *[]models.ScrapedScene, queryString string) {
-	sceneCollector := createCollector("jav.land")
+	sceneCollector := createCollector(context.Background(), "jav.land")
 
 	sceneCollector.OnHTML(`html`, func(html *colly.HTMLElement) {
 		sc := models.ScrapedScene{}
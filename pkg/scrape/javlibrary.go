@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"net/url"
 	"regexp"
 	"strings"
@@ -12,7 +13,7 @@ import (
 )
 
 func ScrapeJavLibrary(out *[]models.ScrapedScene, queryString string) {
-	sceneCollector := createCollector("www.javlibrary.com")
+	sceneCollector := createCollector(context.Background(), "www.javlibrary.com")
 
 	sceneCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
 		// This html page might be the redirected video details page, or the search results,
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"regexp"
 	"strconv"
@@ -13,14 +14,14 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func KinkVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func KinkVR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "kinkvr"
 	siteID := "KinkVR"
 	logScrapeStart(scraperID, siteID)
 
-	sceneCollector := createCollector("www.kink.com", "kink.com")
-	siteCollector := createCollector("www.kink.com", "kink.com")
+	sceneCollector := createCollector(ctx, "www.kink.com", "kink.com")
+	siteCollector := createCollector(ctx, "www.kink.com", "kink.com")
 
 	setAgeGateCookie := func(r *colly.Request) {
 		r.Headers.Set("Cookie", "age_gate_accepted=1")
@@ -200,7 +201,7 @@ func KinkVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"net/url"
 	"strings"
 	"time"
@@ -11,14 +12,14 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func LittleCaprice(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func LittleCaprice(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "littlecaprice"
 	siteID := "Little Caprice Dreams"
 	logScrapeStart(scraperID, siteID)
 
-	sceneCollector := createCollector("www.littlecaprice-dreams.com")
-	siteCollector := createCollector("www.littlecaprice-dreams.com")
+	sceneCollector := createCollector(ctx, "www.littlecaprice-dreams.com")
+	siteCollector := createCollector(ctx, "www.littlecaprice-dreams.com")
 	galleryCollector := cloneCollector(sceneCollector)
 
 	sceneCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
@@ -118,7 +119,7 @@ func LittleCaprice(wg *models.ScrapeWG, updateSite bool, knownScenes []string, o
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -0,0 +1,24 @@
+package scrape
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/xbapps/xbvr/pkg/common"
+)
+
+// TestMain keeps the caches the scrapers write out of the users app dir
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "xbvr-scrape-test")
+	if err != nil {
+		panic(err)
+	}
+	common.AppDir = dir
+	common.CacheDir = filepath.Join(dir, "cache")
+	common.ScrapeCacheDir = filepath.Join(common.CacheDir, "scrape_cache")
+
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
@@ -0,0 +1,456 @@
+package scrape
+
+import (
+	"bufio"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/mozillazg/go-slugify"
+	"github.com/xbapps/xbvr/pkg/common"
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+// Scraper plugins are executables in the scraper_plugins folder of the app dir. XBVR talks to them with one json
+// object per line on stdin and stdout, everything a plugin writes to stderr ends up in the XBVR log.
+//
+// On startup each plugin is run with a describe request and answers with one scraper line for every scraper it
+// provides, then exits:
+//
+//	-> {"type":"describe","protocol":1}
+//	<- {"type":"scraper","id":"nichevr","name":"NicheVR","domain":"nichevr.com","avatar_url":"...","timeout":3600}
+//
+// A scrape runs the plugin with a scrape request, stdin stays open for the answers to wait requests. The plugin
+// streams scenes in the ScrapedScene json format and exits when it is done:
+//
+//	-> {"type":"scrape","protocol":1,"scraper_id":"nichevr","known_scene_ids":[...],"known_scene_urls":[...],
+//	    "single_scene_url":"","additional_info":"","limit_scraping":false}
+//	<- {"type":"wait","domain":"nichevr.com"}                   before each request, to share the rate limits
+//	-> {"type":"wait_done"}                                     or {"type":"wait_done","error":"..."} to stop
+//	<- {"type":"response","domain":"nichevr.com","status":429,"retry_after":"30"}  lets the limits adapt
+//	<- {"type":"scene","scene":{"scene_id":"1234","title":"...","homepage_url":"...",...}}
+//	<- {"type":"log","level":"info","message":"..."}
+//	<- {"type":"error","message":"..."}                         fails the scrape
+const (
+	pluginProtocol        = 1
+	pluginDescribeTimeout = 30 * time.Second
+	pluginDefaultTimeout  = time.Hour
+	pluginIdleTimeout     = 10 * time.Minute
+	pluginMaxLineSize     = 16 * 1024 * 1024
+)
+
+// pluginMessage is a line of the plugin protocol, the fields used depend on the type
+type pluginMessage struct {
+	Type     string `json:"type"`
+	Protocol int    `json:"protocol,omitempty"`
+
+	// describe
+	ID           string `json:"id,omitempty"`
+	Name         string `json:"name,omitempty"`
+	AvatarURL    string `json:"avatar_url,omitempty"`
+	Domain       string `json:"domain,omitempty"`
+	MasterSiteID string `json:"master_site_id,omitempty"`
+	Timeout      int    `json:"timeout,omitempty"` // seconds a scrape may take
+
+	// scrape
+	ScraperID      string   `json:"scraper_id,omitempty"`
+	KnownSceneIDs  []string `json:"known_scene_ids,omitempty"`
+	KnownSceneURLs []string `json:"known_scene_urls,omitempty"`
+	SingleSceneURL string   `json:"single_scene_url,omitempty"`
+	AdditionalInfo string   `json:"additional_info,omitempty"`
+	LimitScraping  bool     `json:"limit_scraping,omitempty"`
+
+	// plugin output
+	Scene      *models.ScrapedScene `json:"scene,omitempty"`
+	Level      string               `json:"level,omitempty"`
+	Message    string               `json:"message,omitempty"`
+	Status     int                  `json:"status,omitempty"`
+	RetryAfter string               `json:"retry_after,omitempty"`
+	Error      string               `json:"error,omitempty"`
+}
+
+type scraperPlugin struct {
+	path         string
+	id           string
+	name         string
+	avatarURL    string
+	domain       string
+	masterSiteID string
+	timeout      time.Duration
+}
+
+// LoadScraperPlugins registers the scrapers of the executables in the scraper_plugins folder of the app dir. It
+// has to run before the sites are initialised.
+func LoadScraperPlugins() {
+	dir := filepath.Join(common.AppDir, "scraper_plugins")
+	if _, err := os.Stat(dir); os.IsNotExist(err) {
+		os.MkdirAll(dir, os.ModePerm)
+		writeScraperPluginExample(dir)
+		return
+	}
+
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		log.Warnf("Can't read scraper plugins: %v", err)
+		return
+	}
+	for _, e := range entries {
+		path := filepath.Join(dir, e.Name())
+		if e.IsDir() || !isPluginExecutable(path) {
+			continue
+		}
+		plugins, err := describeScraperPlugin(path)
+		if err != nil {
+			log.Warnf("Skipping scraper plugin %v: %v", e.Name(), err)
+			continue
+		}
+		for _, p := range plugins {
+			registerScraperPlugin(p)
+			log.Infof("Loaded scraper %v from plugin %v", p.id, e.Name())
+		}
+	}
+}
+
+func isPluginExecutable(path string) bool {
+	info, err := os.Stat(path)
+	if err != nil {
+		return false
+	}
+	if runtime.GOOS == "windows" {
+		ext := strings.ToLower(filepath.Ext(path))
+		return ext == ".exe" || ext == ".bat" || ext == ".cmd"
+	}
+	return info.Mode()&0111 != 0
+}
+
+func describeScraperPlugin(path string) ([]scraperPlugin, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), pluginDescribeTimeout)
+	defer cancel()
+
+	request, _ := json.Marshal(pluginMessage{Type: "describe", Protocol: pluginProtocol})
+	cmd := exec.CommandContext(ctx, path)
+	cmd.Dir = filepath.Dir(path)
+	cmd.Stdin = strings.NewReader(string(request) + "\n")
+	cmd.Stderr = newPluginLogWriter(filepath.Base(path))
+	out, err := cmd.Output()
+	if err != nil {
+		return nil, err
+	}
+
+	var plugins []scraperPlugin
+	ids := map[string]bool{}
+	for _, scraper := range models.GetScrapers() {
+		ids[scraper.ID] = true
+	}
+	for _, line := range strings.Split(string(out), "\n") {
+		if strings.TrimSpace(line) == "" {
+			continue
+		}
+		var msg pluginMessage
+		if err := json.Unmarshal([]byte(line), &msg); err != nil {
+			return nil, fmt.Errorf("invalid describe answer: %v", err)
+		}
+		if msg.Type != "scraper" {
+			continue
+		}
+		if !sceneScraperIdRe.MatchString(msg.ID) {
+			return nil, fmt.Errorf("scraper id %q has to be lower case letters, digits and dashes", msg.ID)
+		}
+		if ids[msg.ID] {
+			return nil, fmt.Errorf("id %v is already used by another scraper", msg.ID)
+		}
+		ids[msg.ID] = true
+		if msg.Name == "" {
+			return nil, fmt.Errorf("scraper %v has no name", msg.ID)
+		}
+		p := scraperPlugin{path: path, id: msg.ID, name: msg.Name, avatarURL: msg.AvatarURL, domain: msg.Domain, masterSiteID: msg.MasterSiteID, timeout: pluginDefaultTimeout}
+		if msg.Timeout > 0 {
+			p.timeout = time.Duration(msg.Timeout) * time.Second
+		}
+		plugins = append(plugins, p)
+	}
+	if len(plugins) == 0 {
+		return nil, errors.New("the plugin describes no scrapers")
+	}
+	return plugins, nil
+}
+
+func registerScraperPlugin(p scraperPlugin) {
+	f := func(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+		return PluginScraper(ctx, wg, updateSite, knownScenes, out, singleSceneURL, singeScrapeAdditionalInfo, limitScraping, p)
+	}
+	if p.masterSiteID != "" {
+		registerAlternateScraper(p.id, p.name+" (Plugin)", p.avatarURL, p.domain, p.masterSiteID, f)
+	} else {
+		registerScraper(p.id, p.name+" (Plugin)", p.avatarURL, p.domain, f)
+	}
+}
+
+func PluginScraper(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool, p scraperPlugin) error {
+	defer wg.Done()
+	logScrapeStart(p.id, p.name)
+
+	err := runScraperPlugin(ctx, p, knownScenes, out, singleSceneURL, singeScrapeAdditionalInfo, limitScraping)
+	if err != nil {
+		log.Errorf("Scraper plugin %v failed: %v", p.id, err)
+	} else if updateSite {
+		updateSiteLastUpdate(ctx, p.id)
+	}
+	logScrapeFinished(p.id, p.name)
+	return err
+}
+
+func runScraperPlugin(ctx context.Context, p scraperPlugin, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, additionalInfo string, limitScraping bool) error {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	var idleTimeout atomic.Bool
+	idle := time.AfterFunc(pluginIdleTimeout, func() {
+		idleTimeout.Store(true)
+		cancel()
+	})
+	defer idle.Stop()
+
+	cmd := exec.CommandContext(ctx, p.path)
+	cmd.Dir = filepath.Dir(p.path)
+	cmd.Stderr = newPluginLogWriter(p.id)
+	stdin, err := cmd.StdinPipe()
+	if err != nil {
+		return err
+	}
+	stdout, err := cmd.StdoutPipe()
+	if err != nil {
+		return err
+	}
+	if err := cmd.Start(); err != nil {
+		return err
+	}
+
+	var knownIDs []string
+	commonDb, _ := models.GetCommonDB()
+	commonDb.Model(&models.Scene{}).Where("scraper_id = ?", p.id).Pluck("scene_id", &knownIDs)
+
+	encoder := json.NewEncoder(stdin)
+	encoder.Encode(pluginMessage{
+		Type:           "scrape",
+		Protocol:       pluginProtocol,
+		ScraperID:      p.id,
+		KnownSceneIDs:  knownIDs,
+		KnownSceneURLs: knownScenes,
+		SingleSceneURL: singleSceneURL,
+		AdditionalInfo: additionalInfo,
+		LimitScraping:  limitScraping,
+	})
+
+	var pluginErr error
+	scanner := bufio.NewScanner(stdout)
+	scanner.Buffer(make([]byte, 64*1024), pluginMaxLineSize)
+	for scanner.Scan() {
+		idle.Reset(pluginIdleTimeout)
+		line := scanner.Bytes()
+		if len(strings.TrimSpace(string(line))) == 0 {
+			continue
+		}
+		var msg pluginMessage
+		if err := json.Unmarshal(line, &msg); err != nil {
+			log.Warnf("Scraper plugin %v sent an invalid line: %v", p.id, err)
+			continue
+		}
+
+		switch msg.Type {
+		case "scene":
+			if scene, err := pluginScene(p, msg.Scene); err != nil {
+				log.Warnf("Scraper plugin %v sent an invalid scene: %v", p.id, err)
+			} else {
+				out <- scene
+			}
+		case "wait":
+			reply := pluginMessage{Type: "wait_done"}
+			if err := pluginWait(ctx, msg.Domain); err != nil {
+				reply.Error = err.Error()
+			}
+			encoder.Encode(reply)
+		case "response":
+			pluginResponse(p.id, msg)
+		case "log":
+			pluginLog(p.id, msg.Level, msg.Message)
+		case "error":
+			pluginErr = errors.New(msg.Message)
+		}
+	}
+	stdin.Close()
+
+	waitErr := cmd.Wait()
+	switch {
+	case idleTimeout.Load():
+		return fmt.Errorf("the plugin sent nothing for %v", pluginIdleTimeout)
+	case ctx.Err() == context.DeadlineExceeded:
+		return errors.New("the plugin timed out")
+	case pluginErr != nil:
+		return pluginErr
+	case scanner.Err() != nil:
+		return scanner.Err()
+	}
+	return waitErr
+}
+
+// pluginScene checks a scene sent by a plugin and fills in what XBVR can derive
+func pluginScene(p scraperPlugin, scene *models.ScrapedScene) (models.ScrapedScene, error) {
+	if scene == nil {
+		return models.ScrapedScene{}, errors.New("no scene")
+	}
+	sc := *scene
+	if sc.SiteID == "" || sc.Title == "" {
+		return sc, errors.New("scene_id and title are required")
+	}
+	sc.ScraperID = p.id
+	if sc.SceneType == "" {
+		sc.SceneType = "VR"
+	}
+	if sc.Site == "" {
+		sc.Site = p.name
+	}
+	if sc.Studio == "" {
+		sc.Studio = sc.Site
+	}
+	if sc.SceneID == "" {
+		sc.SceneID = slugify.Slugify(sc.Site) + "-" + sc.SiteID
+	}
+	if p.masterSiteID != "" {
+		sc.MasterSiteId = p.masterSiteID
+	}
+	return sc, nil
+}
+
+// pluginWait applies the rate limits of a domain before a plugin requests it
+func pluginWait(ctx context.Context, domain string) error {
+	if domain == "" {
+		return nil
+	}
+	ScraperRateLimiterWait(domain)
+	return getAdaptiveLimiter(domain).waitForRequest(ctx)
+}
+
+// pluginResponse lets the limits of a domain adapt to a response a plugin got
+func pluginResponse(siteID string, msg pluginMessage) {
+	recordScraperRequest(siteID, msg.Status)
+	if msg.Domain == "" {
+		return
+	}
+	var err error
+	if msg.Error != "" {
+		err = errors.New(msg.Error)
+	}
+	resp := &http.Response{StatusCode: msg.Status, Header: http.Header{}}
+	if msg.RetryAfter != "" {
+		resp.Header.Set("Retry-After", msg.RetryAfter)
+	}
+	outcome, retryAfter := classifyResponse(resp, err)
+	getAdaptiveLimiter(msg.Domain).record(outcome, msg.Status, retryAfter)
+}
+
+func pluginLog(id string, level string, message string) {
+	entry := log.WithField("plugin", id)
+	switch level {
+	case "error":
+		entry.Error(message)
+	case "warn", "warning":
+		entry.Warn(message)
+	case "debug":
+		entry.Debug(message)
+	default:
+		entry.Info(message)
+	}
+}
+
+// pluginLogWriter logs what a plugin writes to stderr, line by line
+type pluginLogWriter struct {
+	mutex sync.Mutex
+	id    string
+	buf   []byte
+}
+
+func newPluginLogWriter(id string) io.Writer {
+	return &pluginLogWriter{id: id}
+}
+
+func (w *pluginLogWriter) Write(b []byte) (int, error) {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+	w.buf = append(w.buf, b...)
+	for {
+		i := strings.IndexByte(string(w.buf), '\n')
+		if i < 0 {
+			break
+		}
+		if line := strings.TrimSpace(string(w.buf[:i])); line != "" {
+			log.WithField("plugin", w.id).Info(line)
+		}
+		w.buf = w.buf[i+1:]
+	}
+	return len(b), nil
+}
+
+func writeScraperPluginExample(dir string) {
+	example := `#!/usr/bin/env python3
+# Example XBVR scraper plugin. Remove the .sample extension and make the file executable to load it.
+# XBVR sends one json request per line on stdin, the plugin answers with json lines on stdout.
+import json
+import sys
+import urllib.request
+
+
+def send(msg):
+    print(json.dumps(msg), flush=True)
+
+
+request = json.loads(sys.stdin.readline())
+
+if request["type"] == "describe":
+    send({"type": "scraper", "id": "examplevr-plugin", "name": "ExampleVR", "domain": "examplevr.com"})
+    sys.exit(0)
+
+known = set(request.get("known_scene_urls") or [])
+url = "https://examplevr.com/api/videos"
+
+# share the rate limits of XBVR, and let them adapt to the response
+send({"type": "wait", "domain": "examplevr.com"})
+answer = json.loads(sys.stdin.readline())
+if answer.get("error"):
+    send({"type": "error", "message": answer["error"]})
+    sys.exit(1)
+try:
+    with urllib.request.urlopen(url) as resp:
+        videos = json.load(resp)
+        send({"type": "response", "domain": "examplevr.com", "status": resp.status})
+except urllib.error.HTTPError as e:
+    send({"type": "response", "domain": "examplevr.com", "status": e.code, "retry_after": e.headers.get("Retry-After", "")})
+    send({"type": "error", "message": str(e)})
+    sys.exit(1)
+
+for video in videos:
+    if video["url"] in known:
+        continue
+    send({"type": "scene", "scene": {
+        "scene_id": str(video["id"]),
+        "title": video["title"],
+        "homepage_url": video["url"],
+        "covers": [video["cover"]],
+        "cast": video["models"],
+        "tags": video["tags"],
+        "released": video["date"],
+    }})
+    send({"type": "log", "level": "info", "message": "scraped " + video["title"]})
+`
+	os.WriteFile(filepath.Join(dir, "example-plugin.py.sample"), []byte(example), 0644)
+}
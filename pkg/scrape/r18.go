@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"html"
 	"strconv"
 	"strings"
@@ -13,8 +14,8 @@ import (
 )
 
 func ScrapeR18(knownScenes []string, out *[]models.ScrapedScene, queryString string) error {
-	sceneCollector := createCollector("www.r18.com")
-	siteCollector := createCollector("www.r18.com")
+	sceneCollector := createCollector(context.Background(), "www.r18.com")
+	siteCollector := createCollector(context.Background(), "www.r18.com")
 	siteCollector.CacheDir = ""
 
 	sceneCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"strings"
 
 	"github.com/xbapps/xbvr/pkg/config"
@@ -24,19 +25,19 @@ func addRealVRScraper(id string, name string, company string, avatarURL string,
 	siteURL += "/videos/1?order=newest"
 
 	if masterSiteId == "" {
-		registerScraper(id, suffixedName, avatarURL, "realvr.com", func(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
-			return BadoinkSite(wg, updateSite, knownScenes, out, singleSceneURL, id, siteNameSuffix, company, siteURL, singeScrapeAdditionalInfo, limitScraping, "", false)
+		registerScraper(id, suffixedName, avatarURL, "realvr.com", func(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+			return BadoinkSite(ctx, wg, updateSite, knownScenes, out, singleSceneURL, id, siteNameSuffix, company, siteURL, singeScrapeAdditionalInfo, limitScraping, "", false)
 		})
 	} else {
-		registerAlternateScraper(id, suffixedName, avatarURL, "realvr.com", masterSiteId, func(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
-			return BadoinkSite(wg, updateSite, knownScenes, out, singleSceneURL, id, siteNameSuffix, company, siteURL, singeScrapeAdditionalInfo, limitScraping, masterSiteId, false)
+		registerAlternateScraper(id, suffixedName, avatarURL, "realvr.com", masterSiteId, func(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+			return BadoinkSite(ctx, wg, updateSite, knownScenes, out, singleSceneURL, id, siteNameSuffix, company, siteURL, singeScrapeAdditionalInfo, limitScraping, masterSiteId, false)
 		})
 	}
 }
 
 func init() {
-	registerScraper("realvr-single_scene", "RealVR - Other Studios", "", "realvr.com", func(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
-		return BadoinkSite(wg, updateSite, knownScenes, out, singleSceneURL, "", "", "", "", singeScrapeAdditionalInfo, limitScraping, "", false)
+	registerScraper("realvr-single_scene", "RealVR - Other Studios", "", "realvr.com", func(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+		return BadoinkSite(ctx, wg, updateSite, knownScenes, out, singleSceneURL, "", "", "", "", singeScrapeAdditionalInfo, limitScraping, "", false)
 	})
 	var scrapers config.ScraperList
 	scrapers.Load()
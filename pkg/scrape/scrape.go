@@ -1,13 +1,11 @@
 package scrape
 
 import (
-	"crypto/sha1"
-	"encoding/hex"
+	"context"
 	"fmt"
 	"io"
 	"net/http"
 	"net/url"
-	"os"
 	"path"
 	"strings"
 	"time"
@@ -64,18 +62,14 @@ func ProxyGet(targetURL string) (string, error) {
 		return "", fmt.Errorf("proxy URL not configured")
 	}
 
-	// Build proxy request URL
-	var reqURL string
-	if apiKeyName != "" && apiKeyValue != "" {
-		reqURL = fmt.Sprintf("%s?%s=%s&url=%s", proxyURL, url.QueryEscape(apiKeyName), url.QueryEscape(apiKeyValue), url.QueryEscape(targetURL))
-	} else {
-		reqURL = fmt.Sprintf("%s?url=%s", proxyURL, url.QueryEscape(targetURL))
-	}
-
 	log.Infof("Proxy GET: %s", targetURL)
 
-	client := &http.Client{Timeout: 60 * time.Second}
-	resp, err := client.Get(reqURL)
+	// the response is cached with the target url, the proxy service passes conditional headers on
+	client := &http.Client{
+		Timeout:   60 * time.Second,
+		Transport: newHTTPCacheTransport(newRateLimitTransport(&proxyServiceTransport{proxyURL: proxyURL, apiKeyName: apiKeyName, apiKeyValue: apiKeyValue})),
+	}
+	resp, err := client.Get(targetURL)
 	if err != nil {
 		return "", err
 	}
@@ -94,6 +88,28 @@ func ProxyGet(targetURL string) (string, error) {
 	return string(body), nil
 }
 
+// proxyServiceTransport sends requests through the proxy service, which takes the target url as a parameter
+type proxyServiceTransport struct {
+	proxyURL    string
+	apiKeyName  string
+	apiKeyValue string
+}
+
+func (t *proxyServiceTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	var reqURL string
+	if t.apiKeyName != "" && t.apiKeyValue != "" {
+		reqURL = fmt.Sprintf("%s?%s=%s&url=%s", t.proxyURL, url.QueryEscape(t.apiKeyName), url.QueryEscape(t.apiKeyValue), url.QueryEscape(req.URL.String()))
+	} else {
+		reqURL = fmt.Sprintf("%s?url=%s", t.proxyURL, url.QueryEscape(req.URL.String()))
+	}
+	proxyReq, err := http.NewRequestWithContext(req.Context(), req.Method, reqURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	proxyReq.Header = req.Header.Clone()
+	return http.DefaultTransport.RoundTrip(proxyReq)
+}
+
 // IsFlareSolverrEnabled checks if FlareSolverr is enabled for a domain
 func IsFlareSolverrEnabled(domain string) bool {
 	// Check if FlareSolverr address is configured
@@ -115,7 +131,19 @@ func IsFlareSolverrEnabled(domain string) bool {
 	return site.UseFlareSolverr
 }
 
-func createCollector(domains ...string) *colly.Collector {
+// createCollector creates the collector for the domains. Its requests are cancelled with ctx, which clones of
+// the collector share.
+func createCollector(ctx context.Context, domains ...string) *colly.Collector {
+	c := newCollector(domains...)
+	c.Context = ctx
+	return c
+}
+
+func newCollector(domains ...string) *colly.Collector {
+	if getFixtureTransport() != nil {
+		return createBasicCollector(domains...)
+	}
+
 	// Check if any domain has FlareSolverr enabled
 	for _, domain := range domains {
 		if IsFlareSolverrEnabled(domain) {
@@ -140,27 +168,36 @@ func createBasicCollector(domains ...string) *colly.Collector {
 
 	c := colly.NewCollector(
 		colly.AllowedDomains(expandedDomains...),
-		colly.CacheDir(getScrapeCacheDir()),
 		colly.UserAgent(UserAgent),
 	)
 	// use proxy if configured
 	if config.Config.Advanced.ScraperProxy != "" {
 		common.Log.Infof("Using proxy for scraping: %s.", config.Config.Advanced.ScraperProxy)
-		c.SetProxy(config.Config.Advanced.ScraperProxy)
 	}
+	c.WithTransport(newHTTPCacheTransport(newRateLimitTransport(newScraperTransport(config.Config.Advanced.ScraperProxy))))
 
 	c.OnError(func(r *colly.Response, err error) {
 		log.Errorf("Error visiting %s %s", r.Request.URL, err)
 	})
 
 	c = createCallbacks(c)
+	if transport := getFixtureTransport(); transport != nil {
+		// fixtures record every request, so nothing may come from the cache, and replays don't need delays
+		c.WithTransport(transport)
+		if activeFixture.mode == FixtureReplay {
+			return c
+		}
+	}
 	c = setRateLimits(c, domains...)
 
 	return c
 }
 
 func setRateLimits(c *colly.Collector, domains ...string) *colly.Collector {
-	if Limiters == nil {
+	limitersMutex.Lock()
+	loaded := limitersLoaded
+	limitersMutex.Unlock()
+	if !loaded {
 		LoadScraperRateLimits()
 	}
 
@@ -168,7 +205,7 @@ func setRateLimits(c *colly.Collector, domains ...string) *colly.Collector {
 		SetupCollector(GetCoreDomain(domain)+"-scraper", c)
 		log.Debugf("Using Header/Cookies from %s", GetCoreDomain(domain)+"-scraper")
 		limiter := GetRateLimiter(domain)
-		if limiter != nil {
+		if limiter != nil && limiter.configured {
 			randomDelay := limiter.maxDelay - limiter.minDelay
 			delay := limiter.minDelay
 			c.Limit(&colly.LimitRule{
@@ -189,13 +226,11 @@ func cloneCollector(c *colly.Collector) *colly.Collector {
 }
 
 func createCallbacks(c *colly.Collector) *colly.Collector {
-	const maxRetries = 15
-
 	c.OnRequest(func(r *colly.Request) {
-		attempt := r.Ctx.GetAny("attempt")
-
-		if attempt == nil {
-			r.Ctx.Put("attempt", 1)
+		// a cancelled scrape drops the visits still queued instead of failing each of them
+		if c.Context != nil && c.Context.Err() != nil {
+			r.Abort()
+			return
 		}
 
 		log.Infoln("visiting", r.URL.String())
@@ -208,35 +243,11 @@ func createCallbacks(c *colly.Collector) *colly.Collector {
 	c.OnError(func(r *colly.Response, err error) {
 		// Log all errors
 		log.Errorf("Scrape error for %s: %v (status: %d)", r.Request.URL, err, r.StatusCode)
-
-		attempt := r.Ctx.GetAny("attempt")
-		if attempt == nil {
-			return
-		}
-		attemptInt := attempt.(int)
-
-		if r.StatusCode == 429 {
-			if attemptInt <= maxRetries {
-				unCache(r.Request.URL.String(), c.CacheDir)
-				log.Errorln("Waiting 2 seconds before next request...")
-				r.Ctx.Put("attempt", attemptInt+1)
-				time.Sleep(2 * time.Second)
-				r.Request.Retry()
-			}
-		}
 	})
 
 	return c
 }
 
-func DeleteScrapeCache() error {
-	return os.RemoveAll(getScrapeCacheDir())
-}
-
-func getScrapeCacheDir() string {
-	return common.ScrapeCacheDir
-}
-
 func registerScraper(id string, name string, avatarURL string, domain string, f models.ScraperFunc) {
 	models.RegisterScraper(id, name, avatarURL, domain, f, "")
 	// Register domain to site ID mapping for FlareSolverr lookup
@@ -274,17 +285,11 @@ func logScrapeFinished(id string, name string) {
 	}).Infof("Finished %v scraper", name)
 }
 
-func unCache(URL string, cacheDir string) {
-	sum := sha1.Sum([]byte(URL))
-	hash := hex.EncodeToString(sum[:])
-	dir := path.Join(cacheDir, hash[:2])
-	filename := path.Join(dir, hash)
-	if err := os.Remove(filename); err != nil {
-		log.Fatal(err)
+// updateSiteLastUpdate marks the site as scraped, unless the scrape was cancelled before it completed
+func updateSiteLastUpdate(ctx context.Context, id string) {
+	if ctx.Err() != nil {
+		return
 	}
-}
-
-func updateSiteLastUpdate(id string) {
 	var site models.Site
 	err := site.GetIfExist(id)
 	if err != nil {
@@ -333,7 +338,7 @@ func getTextFromHTMLWithSelector(data string, sel string) string {
 }
 
 func CreateCollector(domains ...string) *colly.Collector {
-	return createCollector(domains...)
+	return createCollector(context.Background(), domains...)
 }
 
 func GetCoreDomain(domain string) string {
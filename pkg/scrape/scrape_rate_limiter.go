@@ -1,7 +1,16 @@
 package scrape
 
 import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
 	"math/rand"
+	"net/http"
+	"sort"
+	"strconv"
+	"strings"
 	"sync"
 	"time"
 
@@ -16,14 +25,92 @@ import (
 // The ScraperRateLimiter is provides a way to limit visits across multiple instances of the same scraper.
 // The calls to the colly collector Visit function must first be passed to the ScraperRateLimiter which will then coridinate
 // between all instances and then call the colly Visit function
+
+// On top of the fixed delays limiters adapt to the responses of their domain: throttling responses back off
+// exponentially, Retry-After headers are honoured, and after repeated failures the circuit breaker stops
+// requests to the domain for a while. Domains without saved limits get a limiter with the default settings.
 var Limiters []*ScraperRateLimiter
 
+const (
+	defaultLimiterMaxRetries       = 3
+	defaultLimiterMaxBackoff       = 5 * time.Minute
+	defaultLimiterFailureThreshold = 5
+	defaultLimiterBreakerCooldown  = 15 * time.Minute
+	limiterInitialBackoff          = time.Second
+)
+
+var (
+	limitersMutex  sync.Mutex
+	limitersLoaded bool
+)
+
 type ScraperRateLimiter struct {
 	id          string
 	mutex       sync.Mutex
 	lastRequest time.Time
 	minDelay    time.Duration
 	maxDelay    time.Duration
+	configured  bool // saved by the user rather than created for a domain that was requested
+
+	// adaptive limits, stateMutex is separate as ScraperRateLimiterWait holds mutex while it sleeps
+	stateMutex       sync.Mutex
+	maxRetries       int
+	maxBackoff       time.Duration
+	failureThreshold int
+	breakerCooldown  time.Duration
+	backoff          time.Duration
+	nextRequest      time.Time
+	failures         int
+	openUntil        time.Time
+	throttled        int
+	lastStatus       int
+}
+
+// ScraperRateLimit holds the settings and the state of a limiter. Delays are in milliseconds, backoff and
+// cooldown limits in seconds.
+type ScraperRateLimit struct {
+	ID               string    `json:"id"`
+	Configured       bool      `json:"configured"`
+	MinDelay         int       `json:"min_delay"`
+	MaxDelay         int       `json:"max_delay"`
+	MaxRetries       int       `json:"max_retries"`
+	MaxBackoff       int       `json:"max_backoff"`
+	FailureThreshold int       `json:"failure_threshold"`
+	BreakerCooldown  int       `json:"breaker_cooldown"`
+	Backoff          int       `json:"backoff"` // milliseconds
+	NextRequest      time.Time `json:"next_request"`
+	Failures         int       `json:"failures"`
+	OpenUntil        time.Time `json:"open_until"`
+	Throttled        int       `json:"throttled"`
+	LastStatus       int       `json:"last_status"`
+}
+
+// scraperRateLimitState is the persisted state of a limiter
+type scraperRateLimitState struct {
+	Backoff     time.Duration `json:"backoff"`
+	NextRequest time.Time     `json:"next_request"`
+	Failures    int           `json:"failures"`
+	OpenUntil   time.Time     `json:"open_until"`
+	Throttled   int           `json:"throttled"`
+	LastStatus  int           `json:"last_status"`
+}
+
+type limiterOutcome int
+
+const (
+	limiterSuccess limiterOutcome = iota
+	limiterThrottled
+	limiterFailed
+)
+
+func newScraperRateLimiter(id string) *ScraperRateLimiter {
+	return &ScraperRateLimiter{
+		id:               id,
+		maxRetries:       defaultLimiterMaxRetries,
+		maxBackoff:       defaultLimiterMaxBackoff,
+		failureThreshold: defaultLimiterFailureThreshold,
+		breakerCooldown:  defaultLimiterBreakerCooldown,
+	}
 }
 
 func ScraperRateLimiterWait(rateLimiter string) {
@@ -74,9 +161,8 @@ func ScraperRateLimiterCheckErrors(domain string, err error) {
 }
 
 func LoadScraperRateLimits() {
-	var mutex sync.Mutex
-	mutex.Lock()
-	defer mutex.Unlock()
+	limitersMutex.Lock()
+	defer limitersMutex.Unlock()
 
 	var limiters []*ScraperRateLimiter
 	commonDb, _ := models.GetCommonDB()
@@ -85,19 +171,62 @@ func LoadScraperRateLimits() {
 	if kv.Key == "scraper_rate_limits" {
 		sites := gjson.Get(kv.Value, "sites")
 		for _, site := range sites.Array() {
-			name := site.Get("name").String()
+			limiter := newScraperRateLimiter(site.Get("name").String())
+			limiter.configured = true
 			minDelay := int(site.Get("mindelay").Int())
 			maxDelay := int(site.Get("maxdelay").Int())
 			if maxDelay < minDelay {
 				maxDelay = minDelay
 			}
-			limiters = append(limiters, &ScraperRateLimiter{id: name, minDelay: time.Duration(minDelay) * time.Millisecond, maxDelay: time.Duration(maxDelay) * time.Millisecond})
+			limiter.minDelay = time.Duration(minDelay) * time.Millisecond
+			limiter.maxDelay = time.Duration(maxDelay) * time.Millisecond
+			if v := site.Get("max_retries"); v.Exists() {
+				limiter.maxRetries = int(v.Int())
+			}
+			if v := site.Get("max_backoff"); v.Exists() {
+				limiter.maxBackoff = time.Duration(v.Int()) * time.Second
+			}
+			if v := site.Get("failure_threshold"); v.Exists() {
+				limiter.failureThreshold = int(v.Int())
+			}
+			if v := site.Get("breaker_cooldown"); v.Exists() {
+				limiter.breakerCooldown = time.Duration(v.Int()) * time.Second
+			}
+			limiters = append(limiters, limiter)
 		}
-		Limiters = limiters
 	}
+
+	// restore the backoff and circuit breakers, so a restart doesn't hammer a site that throttled us
+	var stateKV models.KV
+	commonDb.Where(models.KV{Key: "scraper_rate_limit_state"}).Find(&stateKV)
+	states := map[string]scraperRateLimitState{}
+	json.Unmarshal([]byte(stateKV.Value), &states)
+	for id, state := range states {
+		var limiter *ScraperRateLimiter
+		for _, l := range limiters {
+			if l.id == id {
+				limiter = l
+			}
+		}
+		if limiter == nil {
+			limiter = newScraperRateLimiter(id)
+			limiters = append(limiters, limiter)
+		}
+		limiter.backoff = state.Backoff
+		limiter.nextRequest = state.NextRequest
+		limiter.failures = state.Failures
+		limiter.openUntil = state.OpenUntil
+		limiter.throttled = state.Throttled
+		limiter.lastStatus = state.LastStatus
+	}
+
+	Limiters = limiters
+	limitersLoaded = true
 }
 
 func GetRateLimiter(id string) *ScraperRateLimiter {
+	limitersMutex.Lock()
+	defer limitersMutex.Unlock()
 	for _, limiter := range Limiters {
 		if limiter.id == id {
 			return limiter
@@ -105,3 +234,347 @@ func GetRateLimiter(id string) *ScraperRateLimiter {
 	}
 	return nil
 }
+
+// getAdaptiveLimiter returns the limiter of a host, creating one with the default settings if there is none
+func getAdaptiveLimiter(host string) *ScraperRateLimiter {
+	limitersMutex.Lock()
+	loaded := limitersLoaded
+	limitersMutex.Unlock()
+	if !loaded {
+		LoadScraperRateLimits()
+	}
+
+	limitersMutex.Lock()
+	defer limitersMutex.Unlock()
+	domain := strings.TrimPrefix(host, "www.")
+	for _, limiter := range Limiters {
+		if limiter.id == host || limiter.id == domain {
+			return limiter
+		}
+	}
+	limiter := newScraperRateLimiter(domain)
+	Limiters = append(Limiters, limiter)
+	return limiter
+}
+
+// GetScraperRateLimits returns the settings and state of every limiter
+func GetScraperRateLimits() []ScraperRateLimit {
+	limitersMutex.Lock()
+	if !limitersLoaded {
+		limitersMutex.Unlock()
+		LoadScraperRateLimits()
+		limitersMutex.Lock()
+	}
+	limiters := append([]*ScraperRateLimiter{}, Limiters...)
+	limitersMutex.Unlock()
+
+	limits := make([]ScraperRateLimit, 0, len(limiters))
+	for _, l := range limiters {
+		limits = append(limits, l.info())
+	}
+	sort.Slice(limits, func(i, j int) bool { return limits[i].ID < limits[j].ID })
+	return limits
+}
+
+// SaveScraperRateLimit stores the settings of a limiter, keeping its state
+func SaveScraperRateLimit(limit ScraperRateLimit) error {
+	switch {
+	case limit.ID == "":
+		return errors.New("a rate limit needs a domain or limiter id")
+	case limit.MinDelay < 0 || limit.MaxDelay < 0 || limit.MaxRetries < 0 || limit.MaxBackoff < 0 || limit.BreakerCooldown < 0:
+		return errors.New("rate limit settings can't be negative")
+	case limit.FailureThreshold < 1:
+		return errors.New("the failure threshold must be at least 1")
+	}
+	if limit.MaxDelay < limit.MinDelay {
+		limit.MaxDelay = limit.MinDelay
+	}
+
+	limiter := getAdaptiveLimiter(limit.ID)
+	limiter.mutex.Lock()
+	limiter.minDelay = time.Duration(limit.MinDelay) * time.Millisecond
+	limiter.maxDelay = time.Duration(limit.MaxDelay) * time.Millisecond
+	limiter.mutex.Unlock()
+	limiter.stateMutex.Lock()
+	limiter.configured = true
+	limiter.maxRetries = limit.MaxRetries
+	limiter.maxBackoff = time.Duration(limit.MaxBackoff) * time.Second
+	limiter.failureThreshold = limit.FailureThreshold
+	limiter.breakerCooldown = time.Duration(limit.BreakerCooldown) * time.Second
+	limiter.stateMutex.Unlock()
+	return saveScraperRateLimits()
+}
+
+// DeleteScraperRateLimit removes the saved settings of a limiter, the domain gets the default settings again
+func DeleteScraperRateLimit(id string) error {
+	limitersMutex.Lock()
+	for i, limiter := range Limiters {
+		if limiter.id == id {
+			Limiters = append(Limiters[:i], Limiters[i+1:]...)
+			break
+		}
+	}
+	limitersMutex.Unlock()
+	if err := saveScraperRateLimits(); err != nil {
+		return err
+	}
+	return saveScraperRateLimitState()
+}
+
+// ResetScraperRateLimit clears the backoff and closes the circuit breaker of a limiter
+func ResetScraperRateLimit(id string) error {
+	limiter := GetRateLimiter(id)
+	if limiter == nil {
+		return fmt.Errorf("no rate limiter %v", id)
+	}
+	limiter.stateMutex.Lock()
+	limiter.backoff = 0
+	limiter.nextRequest = time.Time{}
+	limiter.failures = 0
+	limiter.openUntil = time.Time{}
+	limiter.stateMutex.Unlock()
+	return saveScraperRateLimitState()
+}
+
+func (l *ScraperRateLimiter) info() ScraperRateLimit {
+	l.mutex.Lock()
+	minDelay, maxDelay := l.minDelay, l.maxDelay
+	l.mutex.Unlock()
+	l.stateMutex.Lock()
+	defer l.stateMutex.Unlock()
+	return ScraperRateLimit{
+		ID:               l.id,
+		Configured:       l.configured,
+		MinDelay:         int(minDelay / time.Millisecond),
+		MaxDelay:         int(maxDelay / time.Millisecond),
+		MaxRetries:       l.maxRetries,
+		MaxBackoff:       int(l.maxBackoff / time.Second),
+		FailureThreshold: l.failureThreshold,
+		BreakerCooldown:  int(l.breakerCooldown / time.Second),
+		Backoff:          int(l.backoff / time.Millisecond),
+		NextRequest:      l.nextRequest,
+		Failures:         l.failures,
+		OpenUntil:        l.openUntil,
+		Throttled:        l.throttled,
+		LastStatus:       l.lastStatus,
+	}
+}
+
+// saveScraperRateLimits stores the settings of the configured limiters, in the format the delays always used
+func saveScraperRateLimits() error {
+	type siteLimit struct {
+		Name             string `json:"name"`
+		MinDelay         int    `json:"mindelay"`
+		MaxDelay         int    `json:"maxdelay"`
+		MaxRetries       int    `json:"max_retries"`
+		MaxBackoff       int    `json:"max_backoff"`
+		FailureThreshold int    `json:"failure_threshold"`
+		BreakerCooldown  int    `json:"breaker_cooldown"`
+	}
+	var settings struct {
+		Sites []siteLimit `json:"sites"`
+	}
+	for _, limit := range GetScraperRateLimits() {
+		if limit.Configured {
+			settings.Sites = append(settings.Sites, siteLimit{
+				Name:             limit.ID,
+				MinDelay:         limit.MinDelay,
+				MaxDelay:         limit.MaxDelay,
+				MaxRetries:       limit.MaxRetries,
+				MaxBackoff:       limit.MaxBackoff,
+				FailureThreshold: limit.FailureThreshold,
+				BreakerCooldown:  limit.BreakerCooldown,
+			})
+		}
+	}
+	data, err := json.Marshal(settings)
+	if err != nil {
+		return err
+	}
+	commonDb, _ := models.GetCommonDB()
+	return commonDb.Save(&models.KV{Key: "scraper_rate_limits", Value: string(data)}).Error
+}
+
+func saveScraperRateLimitState() error {
+	limitersMutex.Lock()
+	limiters := append([]*ScraperRateLimiter{}, Limiters...)
+	limitersMutex.Unlock()
+
+	states := map[string]scraperRateLimitState{}
+	for _, l := range limiters {
+		l.stateMutex.Lock()
+		if l.backoff > 0 || l.failures > 0 || l.throttled > 0 || l.openUntil.After(time.Now()) {
+			states[l.id] = scraperRateLimitState{
+				Backoff:     l.backoff,
+				NextRequest: l.nextRequest,
+				Failures:    l.failures,
+				OpenUntil:   l.openUntil,
+				Throttled:   l.throttled,
+				LastStatus:  l.lastStatus,
+			}
+		}
+		l.stateMutex.Unlock()
+	}
+	data, err := json.Marshal(states)
+	if err != nil {
+		return err
+	}
+	commonDb, _ := models.GetCommonDB()
+	return commonDb.Save(&models.KV{Key: "scraper_rate_limit_state", Value: string(data)}).Error
+}
+
+// waitForRequest waits out the backoff of the limiter, it fails while the circuit breaker is open
+func (l *ScraperRateLimiter) waitForRequest(ctx context.Context) error {
+	l.stateMutex.Lock()
+	openUntil := l.openUntil
+	wait := time.Until(l.nextRequest)
+	l.stateMutex.Unlock()
+
+	if openUntil.After(time.Now()) {
+		return fmt.Errorf("too many failed requests to %v, requests are paused until %v", l.id, openUntil.Format("15:04:05"))
+	}
+	if wait <= 0 {
+		return nil
+	}
+	timer := time.NewTimer(wait)
+	defer timer.Stop()
+	select {
+	case <-ctx.Done():
+		return ctx.Err()
+	case <-timer.C:
+		return nil
+	}
+}
+
+// record adapts the limiter to the outcome of a request
+func (l *ScraperRateLimiter) record(outcome limiterOutcome, status int, retryAfter time.Duration) {
+	l.stateMutex.Lock()
+	now := time.Now()
+	changed := outcome != limiterSuccess || l.backoff > 0 || l.failures > 0
+	if status != 0 {
+		l.lastStatus = status
+	}
+
+	switch outcome {
+	case limiterSuccess:
+		l.failures = 0
+		l.backoff /= 2
+		if l.backoff < limiterInitialBackoff {
+			l.backoff = 0
+		}
+		l.nextRequest = now.Add(l.backoff)
+	case limiterThrottled:
+		l.throttled++
+		l.failures++
+		l.backoff *= 2
+		if l.backoff < limiterInitialBackoff {
+			l.backoff = limiterInitialBackoff
+		}
+		if l.backoff > l.maxBackoff {
+			l.backoff = l.maxBackoff
+		}
+		l.nextRequest = now.Add(l.backoff)
+	case limiterFailed:
+		l.failures++
+		if l.backoff < limiterInitialBackoff {
+			l.nextRequest = now.Add(limiterInitialBackoff)
+		} else {
+			l.nextRequest = now.Add(l.backoff)
+		}
+	}
+	if retryAfter > 0 && now.Add(retryAfter).After(l.nextRequest) {
+		l.nextRequest = now.Add(retryAfter)
+	}
+
+	// open the circuit breaker after repeated failures, or when the site asks to wait longer than the backoff limit
+	if outcome != limiterSuccess && (l.failures >= l.failureThreshold || retryAfter > l.maxBackoff) {
+		openUntil := now.Add(l.breakerCooldown)
+		if l.nextRequest.After(openUntil) {
+			openUntil = l.nextRequest
+		}
+		if !l.openUntil.After(now) {
+			log.Warnf("Pausing requests to %v until %v after %v failed requests", l.id, openUntil.Format("15:04:05"), l.failures)
+		}
+		l.openUntil = openUntil
+	}
+	l.stateMutex.Unlock()
+
+	if changed {
+		if err := saveScraperRateLimitState(); err != nil {
+			log.Warnf("Can't save the rate limit state: %v", err)
+		}
+	}
+}
+
+// classifyResponse tells whether a response means the site is throttling us or failed, and how long it asks to wait
+func classifyResponse(resp *http.Response, err error) (limiterOutcome, time.Duration) {
+	if err != nil {
+		return limiterFailed, 0
+	}
+	cloudflareChallenge := resp.Header.Get("Cf-Mitigated") == "challenge" ||
+		(strings.EqualFold(resp.Header.Get("Server"), "cloudflare") && resp.StatusCode == http.StatusForbidden)
+	switch {
+	case resp.StatusCode == http.StatusTooManyRequests, resp.StatusCode == http.StatusServiceUnavailable, cloudflareChallenge:
+		return limiterThrottled, parseRetryAfter(resp.Header.Get("Retry-After"))
+	case resp.StatusCode >= 500:
+		return limiterFailed, 0
+	}
+	return limiterSuccess, 0
+}
+
+func parseRetryAfter(value string) time.Duration {
+	if value == "" {
+		return 0
+	}
+	if seconds, err := strconv.Atoi(strings.TrimSpace(value)); err == nil {
+		return time.Duration(seconds) * time.Second
+	}
+	if t, err := http.ParseTime(value); err == nil {
+		return time.Until(t)
+	}
+	return 0
+}
+
+// rateLimitTransport applies the adaptive limits of the requested domain, retrying idempotent requests that were
+// throttled or failed
+type rateLimitTransport struct {
+	next http.RoundTripper
+}
+
+func newRateLimitTransport(next http.RoundTripper) *rateLimitTransport {
+	return &rateLimitTransport{next: next}
+}
+
+func (t *rateLimitTransport) RoundTrip(req *http.Request) (*http.Response, error) {
+	limiter := getAdaptiveLimiter(req.URL.Hostname())
+	idempotent := (req.Method == http.MethodGet || req.Method == http.MethodHead) && (req.Body == nil || req.Body == http.NoBody)
+
+	for attempt := 0; ; attempt++ {
+		if err := limiter.waitForRequest(req.Context()); err != nil {
+			return nil, err
+		}
+		resp, err := t.next.RoundTrip(req)
+		if req.Context().Err() != nil {
+			return resp, err
+		}
+
+		outcome, retryAfter := classifyResponse(resp, err)
+		status := 0
+		if resp != nil {
+			status = resp.StatusCode
+		}
+		limiter.record(outcome, status, retryAfter)
+
+		limiter.stateMutex.Lock()
+		maxRetries := limiter.maxRetries
+		limiter.stateMutex.Unlock()
+		if outcome == limiterSuccess || !idempotent || attempt >= maxRetries {
+			return resp, err
+		}
+		if resp != nil {
+			io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		log.Warnf("Retrying %v (attempt %v of %v, status %v)", req.URL, attempt+1, maxRetries, status)
+	}
+}
@@ -0,0 +1,50 @@
+package scrape
+
+import (
+	"sync"
+)
+
+// ScraperRunRequests counts the pages a scraper requested during a run, by http status. Status 0 is a
+// request that failed without a response.
+type ScraperRunRequests struct {
+	Pages    int
+	Statuses map[int]int
+}
+
+var (
+	scraperRunsMutex sync.Mutex
+	scraperRuns      = map[string]*ScraperRunRequests{}
+)
+
+// StartScraperRunStats starts counting the requests of a scraper
+func StartScraperRunStats(id string) {
+	scraperRunsMutex.Lock()
+	defer scraperRunsMutex.Unlock()
+	scraperRuns[id] = &ScraperRunRequests{Statuses: map[int]int{}}
+}
+
+// FinishScraperRunStats stops counting for a scraper and returns its requests
+func FinishScraperRunStats(id string) ScraperRunRequests {
+	scraperRunsMutex.Lock()
+	defer scraperRunsMutex.Unlock()
+	run, ok := scraperRuns[id]
+	if !ok {
+		return ScraperRunRequests{Statuses: map[int]int{}}
+	}
+	delete(scraperRuns, id)
+	return *run
+}
+
+// recordScraperRequest counts a response for the running scraper of a site. Scrapers of different sites
+// often share a domain, so requests are attributed by the site their collector scrapes.
+func recordScraperRequest(siteID string, status int) {
+	if siteID == "" {
+		return
+	}
+	scraperRunsMutex.Lock()
+	defer scraperRunsMutex.Unlock()
+	if run, ok := scraperRuns[siteID]; ok {
+		run.Pages++
+		run.Statuses[status]++
+	}
+}
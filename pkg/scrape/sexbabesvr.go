@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"net/url"
 	"regexp"
@@ -15,14 +16,14 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func SexBabesVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func SexBabesVR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "sexbabesvr"
 	siteID := "SexBabesVR"
 	logScrapeStart(scraperID, siteID)
 
-	sceneCollector := createCollector("sexbabesvr.com")
-	siteCollector := createCollector("sexbabesvr.com")
+	sceneCollector := createCollector(ctx, "sexbabesvr.com")
+	siteCollector := createCollector(ctx, "sexbabesvr.com")
 
 	sceneCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
 		sc := models.ScrapedScene{}
@@ -155,7 +156,7 @@ func SexBabesVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"net/http"
 	"regexp"
@@ -15,14 +16,14 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func SinsVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func SinsVR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "sinsvr"
 	siteID := "SinsVR"
 	logScrapeStart(scraperID, siteID)
 
-	sceneCollector := createCollector("xsinsvr.com")
-	siteCollector := createCollector("xsinsvr.com")
+	sceneCollector := createCollector(ctx, "xsinsvr.com")
+	siteCollector := createCollector(ctx, "xsinsvr.com")
 
 	durationRegexes := []*regexp.Regexp{
 		regexp.MustCompile(`(?:(?P<h>\d+):)?(?P<m>\d+):(?P<s>\d+)`),           // e.g. 11:11, 1:11:11
@@ -197,7 +198,7 @@ func SinsVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
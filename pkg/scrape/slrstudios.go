@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"html"
 	"net/http"
@@ -11,7 +12,6 @@ import (
 	"sync"
 	"time"
 
-	"github.com/go-resty/resty/v2"
 	"github.com/thoas/go-funk"
 	"github.com/tidwall/gjson"
 	"github.com/xbapps/xbvr/pkg/config"
@@ -59,7 +59,7 @@ func normalizeSLRSceneURL(u string) string {
 	return urlPrefix + "scenes/" + slug
 }
 
-func SexLikeReal(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, scraperID string, siteID string, company string, siteURL string, singeScrapeAdditionalInfo string, limitScraping bool, masterSiteId string) error {
+func SexLikeReal(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, scraperID string, siteID string, company string, siteURL string, singeScrapeAdditionalInfo string, limitScraping bool, masterSiteId string) error {
 	defer wg.Done()
 	logScrapeStart(scraperID, siteID)
 
@@ -69,13 +69,16 @@ func SexLikeReal(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 	sem := make(chan struct{}, 8) // hard-coded concurrency limit
 
 	// Create reusable HTTP client
-	client := resty.New()
+	client := newRestyClient()
 
 	// RegEx Patterns
 	filenameRegEx := regexp.MustCompile(`[?:]`)
 
 	// API-based scene processing function
 	processSceneFromAPI := func(sceneID string, sceneLabel string, isTransScene bool, isGayScene bool, duration int) {
+		if ctx.Err() != nil {
+			return
+		}
 		// Use v3 API endpoint with scene label for better data including gallery images
 		apiURL := "https://api.sexlikereal.com/v3/scenes/" + sceneLabel
 
@@ -88,7 +91,7 @@ func SexLikeReal(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 		}
 
 		// Fetch scene data from API
-		req := client.R().
+		req := client.R().SetContext(ctx).
 			SetHeader("User-Agent", UserAgent).
 			SetHeader("Client-Type", "web").
 			SetHeader("project", projectHeader)
@@ -108,7 +111,7 @@ func SexLikeReal(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 		// Fallback to project=0 if project=1 fails with 404
 		if resp.StatusCode() == 404 && projectHeader == "1" {
 			log.Infoln("Retrying scene", sceneID, "with project=0 fallback")
-			resp, err = client.R().
+			resp, err = client.R().SetContext(ctx).
 				SetHeader("User-Agent", UserAgent).
 				SetHeader("Client-Type", "web").
 				SetHeader("project", "0").
@@ -128,7 +131,7 @@ func SexLikeReal(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 		if resp.StatusCode() != 200 {
 			log.Infoln("V3 API failed for scene", sceneID, "- trying legacy API endpoint")
 			legacyURL := "https://api.sexlikereal.com/virtualreality/video/id/" + sceneID
-			resp, err = client.R().
+			resp, err = client.R().SetContext(ctx).
 				SetHeader("User-Agent", UserAgent).
 				SetHeader("Client-Type", "web").
 				SetHeader("project", projectHeader).
@@ -496,10 +499,10 @@ func SexLikeReal(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 			projectHeader = "3"
 		}
 
-		for (!limitScraping || page == 1) && (totalPages == 0 || page <= totalPages) {
+		for (!limitScraping || page == 1) && (totalPages == 0 || page <= totalPages) && ctx.Err() == nil {
 			apiURL := "https://api.sexlikereal.com/v3/scenes?studios=" + studioCode + "&perPage=" + strconv.Itoa(perPage) + "&sort=mostRecent&page=" + strconv.Itoa(page)
 
-			req := client.R().
+			req := client.R().SetContext(ctx).
 				SetHeader("User-Agent", UserAgent).
 				SetHeader("Client-Type", "web").
 				SetHeader("project", projectHeader)
@@ -519,7 +522,7 @@ func SexLikeReal(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 			// Fallback to project=0 if project=1 fails with 404
 			if resp.StatusCode() == 404 && projectHeader == "1" && page == 1 {
 				log.Infoln("Retrying studio", studioCode, "with project=0 fallback")
-				resp, err = client.R().
+				resp, err = client.R().SetContext(ctx).
 					SetHeader("User-Agent", UserAgent).
 					SetHeader("Client-Type", "web").
 					SetHeader("project", "0").
@@ -701,7 +704,7 @@ func SexLikeReal(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 	apiWG.Wait()
 
 	// Auto-enable limit scraping after successful full scrape if config option is enabled
-	if scrapeSuccessful && !limitScraping && scraperID != "" && config.Config.Advanced.AutoLimitScraping {
+	if scrapeSuccessful && ctx.Err() == nil && !limitScraping && scraperID != "" && config.Config.Advanced.AutoLimitScraping {
 		db, _ := models.GetDB()
 		defer db.Close()
 
@@ -715,7 +718,7 @@ func SexLikeReal(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -838,12 +841,12 @@ func addSLRScraper(id string, name string, company string, avatarURL string, cus
 	}
 
 	if masterSiteId == "" {
-		registerScraper(id, suffixedName, avatarURL, "sexlikereal.com", func(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
-			return SexLikeReal(wg, updateSite, knownScenes, out, singleSceneURL, id, siteNameSuffix, company, siteURL, singeScrapeAdditionalInfo, limitScraping, "")
+		registerScraper(id, suffixedName, avatarURL, "sexlikereal.com", func(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+			return SexLikeReal(ctx, wg, updateSite, knownScenes, out, singleSceneURL, id, siteNameSuffix, company, siteURL, singeScrapeAdditionalInfo, limitScraping, "")
 		})
 	} else {
-		registerAlternateScraper(id, suffixedName, avatarURL, "sexlikereal.com", masterSiteId, func(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
-			return SexLikeReal(wg, updateSite, knownScenes, out, singleSceneURL, id, siteNameSuffix, company, siteURL, singeScrapeAdditionalInfo, limitScraping, masterSiteId)
+		registerAlternateScraper(id, suffixedName, avatarURL, "sexlikereal.com", masterSiteId, func(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+			return SexLikeReal(ctx, wg, updateSite, knownScenes, out, singleSceneURL, id, siteNameSuffix, company, siteURL, singeScrapeAdditionalInfo, limitScraping, masterSiteId)
 		})
 	}
 }
@@ -851,8 +854,8 @@ func addSLRScraper(id string, name string, company string, avatarURL string, cus
 func init() {
 	var scrapers config.ScraperList
 	// scraper for single scenes with no existing scraper for the studio
-	registerScraper("slr-single_scene", "SLR - Other Studios", "", "sexlikereal.com", func(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
-		return SexLikeReal(wg, updateSite, knownScenes, out, singleSceneURL, "", "", "", "", singeScrapeAdditionalInfo, limitScraping, "")
+	registerScraper("slr-single_scene", "SLR - Other Studios", "", "sexlikereal.com", func(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+		return SexLikeReal(ctx, wg, updateSite, knownScenes, out, singleSceneURL, "", "", "", "", singeScrapeAdditionalInfo, limitScraping, "")
 	})
 
 	scrapers.Load()
@@ -137,6 +137,7 @@ performers{
 }
 fingerprints{
   hash
+  algorithm
   duration
   submissions
 }
@@ -438,6 +439,7 @@ func GetScenePage(variables string) QueryScenesResult {
 		  }
 		  fingerprints{
 			hash
+			algorithm
 			duration
 			submissions
 		  }
@@ -514,6 +516,7 @@ func GetSceneFromStash(sceneId string) models.StashScene {
 		   }
 		   fingerprints{
 			 hash
+			 algorithm
 			 duration
 			 submissions
 		   }
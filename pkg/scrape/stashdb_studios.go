@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"strings"
 
 	"github.com/jinzhu/gorm"
@@ -11,7 +12,7 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func StashStudio(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, scraper string, name string, limitScraping bool, stashGuid string, masterSiteId string) error {
+func StashStudio(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, scraper string, name string, limitScraping bool, stashGuid string, masterSiteId string) error {
 	defer wg.Done()
 	commonDb, _ := models.GetCommonDB()
 	stashGuid = strings.TrimPrefix(stashGuid, "https://stashdb.org/studios/")
@@ -38,7 +39,7 @@ func StashStudio(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -114,12 +115,12 @@ func init() {
 }
 func addStashScraper(id string, name string, avatarURL string, stashGuid string, masterSiteId string) {
 	if masterSiteId == "" {
-		registerScraper(id+"-stashdb", name+" (Stashdb)", avatarURL, "stashdb.org", func(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
-			return StashStudio(wg, updateSite, knownScenes, out, singleSceneURL, singeScrapeAdditionalInfo, id, name, limitScraping, stashGuid, masterSiteId)
+		registerScraper(id+"-stashdb", name+" (Stashdb)", avatarURL, "stashdb.org", func(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+			return StashStudio(ctx, wg, updateSite, knownScenes, out, singleSceneURL, singeScrapeAdditionalInfo, id, name, limitScraping, stashGuid, masterSiteId)
 		})
 	} else {
-		registerAlternateScraper(id+"-stashdb", name+" (Stashdb)", avatarURL, "stashdb.org", masterSiteId, func(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
-			return StashStudio(wg, updateSite, knownScenes, out, singleSceneURL, singeScrapeAdditionalInfo, id, name, limitScraping, stashGuid, masterSiteId)
+		registerAlternateScraper(id+"-stashdb", name+" (Stashdb)", avatarURL, "stashdb.org", masterSiteId, func(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+			return StashStudio(ctx, wg, updateSite, knownScenes, out, singleSceneURL, singeScrapeAdditionalInfo, id, name, limitScraping, stashGuid, masterSiteId)
 		})
 	}
 }
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"regexp"
@@ -13,14 +14,14 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func SwallowBay(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func SwallowBay(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "swallowbay"
 	siteID := "SwallowBay"
 	logScrapeStart(scraperID, siteID)
 
-	sceneCollector := createCollector("swallowbay.com")
-	siteCollector := createCollector("swallowbay.com")
+	sceneCollector := createCollector(ctx, "swallowbay.com")
+	siteCollector := createCollector(ctx, "swallowbay.com")
 
 	sceneCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
 		sc := models.ScrapedScene{}
@@ -132,7 +133,7 @@ func SwallowBay(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
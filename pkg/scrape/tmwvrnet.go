@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"strconv"
 	"strings"
@@ -13,14 +14,14 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func TmwVRnet(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func TmwVRnet(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "tmwvrnet"
 	siteID := "TmwVRnet"
 	logScrapeStart(scraperID, siteID)
 
-	sceneCollector := createCollector("tmwvrnet.com")
-	siteCollector := createCollector("tmwvrnet.com")
+	sceneCollector := createCollector(ctx, "tmwvrnet.com")
+	siteCollector := createCollector(ctx, "tmwvrnet.com")
 	siteCollector.MaxDepth = 5
 
 	sceneCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
@@ -129,7 +130,7 @@ func TmwVRnet(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out ch
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"strconv"
 	"strings"
@@ -12,14 +13,14 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func TNGFVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func TNGFVR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "tonightsgirlfriend"
 	siteID := "Tonight's Girlfriend VR"
 	logScrapeStart(scraperID, siteID)
 
-	sceneCollector := createCollector("www.tonightsgirlfriend.com")
-	siteCollector := createCollector("www.tonightsgirlfriend.com")
+	sceneCollector := createCollector(ctx, "www.tonightsgirlfriend.com")
+	siteCollector := createCollector(ctx, "www.tonightsgirlfriend.com")
 
 	sceneCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
 		sc := models.ScrapedScene{}
@@ -170,7 +171,7 @@ func TNGFVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"regexp"
 	"strconv"
@@ -13,15 +14,15 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func TransVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func TransVR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "transvr"
 	siteID := "TransVR"
 	allowedDomains := []string{"transvr.com", "www.transvr.com", "www.groobyod.com"}
 	logScrapeStart(scraperID, siteID)
 
-	sceneCollector := createCollector(allowedDomains...)
-	siteCollector := createCollector(allowedDomains...)
+	sceneCollector := createCollector(ctx, allowedDomains...)
+	siteCollector := createCollector(ctx, allowedDomains...)
 
 	sceneCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
 		sc := models.ScrapedScene{}
@@ -117,7 +118,7 @@ func TransVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out cha
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -1,11 +1,11 @@
 package scrape
 
 import (
+	"context"
 	"regexp"
 	"strconv"
 	"strings"
 
-	"github.com/go-resty/resty/v2"
 	"github.com/gocolly/colly/v2"
 	"github.com/mozillazg/go-slugify"
 	"github.com/nleeper/goment"
@@ -14,14 +14,14 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func UpCloseVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func UpCloseVR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	// this scraper is non-standard in that it gathers info via an api rather than scraping html pages
 	defer wg.Done()
 	scraperID := "upclosevr"
 	siteID := "UpCloseVR"
 	logScrapeStart(scraperID, siteID)
 
-	siteCollector := createCollector("www.upclosevr.com")
+	siteCollector := createCollector(ctx, "www.upclosevr.com")
 
 	siteCollector.OnHTML(`script`, func(e *colly.HTMLElement) {
 		apiKeyRegex := regexp.MustCompile(`"apiKey":"(.+)"}},"site`)
@@ -31,9 +31,9 @@ func UpCloseVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out c
 
 		if len(apiKey) > 0 && len(applicationID) > 0 {
 			pageTotal := 1
-			client := resty.New()
+			client := newRestyClient()
 
-			for page := 0; page < pageTotal; page++ {
+			for page := 0; page < pageTotal && ctx.Err() == nil; page++ {
 
 				var payloadStr string
 				if singleSceneURL != "" {
@@ -146,7 +146,7 @@ func UpCloseVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out c
 	siteCollector.Visit("https://www.upclosevr.com/en/videos")
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"strconv"
 	"strings"
 
@@ -11,14 +12,14 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func VirtualPee(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func VirtualPee(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "virtualpee"
 	siteID := "VirtualPee"
 	logScrapeStart(scraperID, siteID)
 
-	sceneCollector := createCollector("virtualpee.com")
-	siteCollector := createCollector("virtualpee.com")
+	sceneCollector := createCollector(ctx, "virtualpee.com")
+	siteCollector := createCollector(ctx, "virtualpee.com")
 
 	sceneCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
 		sc := models.ScrapedScene{}
@@ -108,7 +109,7 @@ func VirtualPee(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
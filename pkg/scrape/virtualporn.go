@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"regexp"
 	"strconv"
@@ -13,15 +14,15 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func Project1ServiceAPI(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, siteData *siteMetaData, limitScraping bool) error {
+func Project1ServiceAPI(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, siteData *siteMetaData, limitScraping bool) error {
 
 	// this scraper is non-standard in that it gathers info via an api rather than scraping html pages
 	defer wg.Done()
 
 	logScrapeStart(siteData.scraperID, siteData.siteID)
 	nextApiUrl := ""
-	siteCollector := createCollector(siteData.baseURL)
-	apiCollector := createCollector("site-api.project1service.com")
+	siteCollector := createCollector(ctx, siteData.baseURL)
+	apiCollector := createCollector(ctx, "site-api.project1service.com")
 	offset := 0
 	apiCollector.OnResponse(func(r *colly.Response) {
 		sceneListJson := gjson.ParseBytes(r.Body)
@@ -168,7 +169,7 @@ func Project1ServiceAPI(wg *models.ScrapeWG, updateSite bool, knownScenes []stri
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(siteData.scraperID)
+		updateSiteLastUpdate(ctx, siteData.scraperID)
 	}
 	logScrapeFinished(siteData.scraperID, siteData.siteID)
 	return nil
@@ -184,7 +185,7 @@ type siteMetaData struct {
 	studio      string
 }
 
-func VirtualPorn(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func VirtualPorn(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	bvrMetaData := siteMetaData{
 		scraperID:   "bvr",
 		siteID:      "VirtualPorn",
@@ -194,10 +195,10 @@ func VirtualPorn(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 		membersURL:  `https://site-ma.virtualporn.com/`,
 		studio:      "BangBros",
 	}
-	return Project1ServiceAPI(wg, updateSite, knownScenes, out, singleSceneURL, singeScrapeAdditionalInfo, &bvrMetaData, limitScraping)
+	return Project1ServiceAPI(ctx, wg, updateSite, knownScenes, out, singleSceneURL, singeScrapeAdditionalInfo, &bvrMetaData, limitScraping)
 }
 
-func BrazzersVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func BrazzersVR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	zzvrMetaData := siteMetaData{
 		scraperID:   "zzvr",
 		siteID:      "BrazzersVR",
@@ -207,7 +208,7 @@ func BrazzersVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 		membersURL:  `https://site-ma.brazzersvr.com/`,
 		studio:      "Brazzers",
 	}
-	return Project1ServiceAPI(wg, updateSite, knownScenes, out, singleSceneURL, singeScrapeAdditionalInfo, &zzvrMetaData, limitScraping)
+	return Project1ServiceAPI(ctx, wg, updateSite, knownScenes, out, singleSceneURL, singeScrapeAdditionalInfo, &zzvrMetaData, limitScraping)
 }
 
 func init() {
@@ -217,8 +218,8 @@ func init() {
 
 // one off conversion routine called by migrations.go
 func UpdateVirtualPornIds() {
-	collector := createCollector("virtualporn.com")
-	apiCollector := createCollector("site-api.project1service.com")
+	collector := createCollector(context.Background(), "virtualporn.com")
+	apiCollector := createCollector(context.Background(), "site-api.project1service.com")
 	offset := 0
 	sceneCnt := 0
 
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"fmt"
 	"regexp"
 	"strconv"
@@ -13,13 +14,13 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func VR3000(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func VR3000(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "vr3000"
 	siteID := "VR3000"
 	logScrapeStart(scraperID, siteID)
 
-	siteCollector := createCollector("vr3000.com", "www.vr3000.com")
+	siteCollector := createCollector(ctx, "vr3000.com", "www.vr3000.com")
 
 	siteCollector.OnHTML(`.row.no-gutter`, func(e *colly.HTMLElement) {
 		sc := models.ScrapedScene{}
@@ -125,7 +126,7 @@ func VR3000(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"fmt"
 	"strconv"
 	"strings"
@@ -11,15 +12,15 @@ import (
 	"github.com/xbapps/xbvr/pkg/models"
 )
 
-func VRSexygirlz(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func VRSexygirlz(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 
 	scraperID := "vrsexygirlz"
 	siteID := "VRSexyGirlz"
 	logScrapeStart(scraperID, siteID)
 
-	sceneCollector := createCollector("vrsexygirlz.com", "www.vrsexygirlz.com")
-	siteCollector := createCollector("vrsexygirlz.com", "www.vrsexygirlz.com")
+	sceneCollector := createCollector(ctx, "vrsexygirlz.com", "www.vrsexygirlz.com")
+	siteCollector := createCollector(ctx, "vrsexygirlz.com", "www.vrsexygirlz.com")
 
 	sceneCollector.OnHTML(`html`, func(e *colly.HTMLElement) {
 		sc := models.ScrapedScene{}
@@ -110,7 +111,7 @@ func VRSexygirlz(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
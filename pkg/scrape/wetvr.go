@@ -1,6 +1,7 @@
 package scrape
 
 import (
+	"context"
 	"encoding/json"
 	"fmt"
 	"io"
@@ -71,7 +72,7 @@ func fetchJSON(url string, target interface{}) error {
 	return json.Unmarshal(body, target)
 }
 
-func WetVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
+func WetVR(ctx context.Context, wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, limitScraping bool) error {
 	defer wg.Done()
 	scraperID := "wetvr"
 	siteID := "WetVR"
@@ -166,7 +167,7 @@ func WetVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<
 		page := 1
 		sceneCount := 0
 
-		for {
+		for ctx.Err() == nil {
 			apiURL := fmt.Sprintf("https://wetvr.com/api/releases?sort=latest&page=%d", page)
 			// Skip per-page logging
 
@@ -202,7 +203,7 @@ func WetVR(wg *models.ScrapeWG, updateSite bool, knownScenes []string, out chan<
 	}
 
 	if updateSite {
-		updateSiteLastUpdate(scraperID)
+		updateSiteLastUpdate(ctx, scraperID)
 	}
 	logScrapeFinished(scraperID, siteID)
 	return nil
@@ -2,11 +2,13 @@ package server
 
 import (
 	"fmt"
+	"strings"
 	"time"
 
 	"github.com/robfig/cron/v3"
 	"github.com/xbapps/xbvr/pkg/api"
 	"github.com/xbapps/xbvr/pkg/config"
+	"github.com/xbapps/xbvr/pkg/models"
 	"github.com/xbapps/xbvr/pkg/session"
 	"github.com/xbapps/xbvr/pkg/tasks"
 )
@@ -19,11 +21,13 @@ var actorScrapeTask cron.EntryID
 var stashdbScrapeTask cron.EntryID
 var linkScenesTask cron.EntryID
 var avifConversionTask cron.EntryID
+var phashTask cron.EntryID
 
 func SetupCron() {
 	cronInstance = cron.New()
 	cronInstance.AddFunc("@every 2s", session.CheckForDeadSession)
 	cronInstance.AddFunc("@every 6h", tasks.CalculateCacheSizes)
+	cronInstance.AddFunc("@every 5m", siteScheduleCron)
 	if config.Config.Cron.RescrapeSchedule.Enabled {
 		log.Println(fmt.Sprintf("Setup Rescrape Task %v", formatCronSchedule(config.CronSchedule(config.Config.Cron.RescrapeSchedule))))
 		rescrapTask, _ = cronInstance.AddFunc(formatCronSchedule(config.CronSchedule(config.Config.Cron.RescrapeSchedule)), scrapeCron)
@@ -53,6 +57,10 @@ func SetupCron() {
 		log.Println(fmt.Sprintf("Setup AVIF Conversion Task %v", formatCronSchedule(config.CronSchedule(config.Config.Cron.AVIFConversionSchedule))))
 		avifConversionTask, _ = cronInstance.AddFunc(formatCronSchedule(config.CronSchedule(config.Config.Cron.AVIFConversionSchedule)), avifConversionCron)
 	}
+	if config.Config.Cron.PhashSchedule.Enabled {
+		log.Println(fmt.Sprintf("Setup Phash Generation Task %v", formatCronSchedule(config.CronSchedule(config.Config.Cron.PhashSchedule))))
+		phashTask, _ = cronInstance.AddFunc(formatCronSchedule(config.CronSchedule(config.Config.Cron.PhashSchedule)), generatePhashCron)
+	}
 	cronInstance.Start()
 
 	go tasks.CalculateCacheSizes()
@@ -78,6 +86,9 @@ func SetupCron() {
 	if config.Config.Cron.AVIFConversionSchedule.RunAtStartDelay > 0 {
 		time.AfterFunc(time.Duration(config.Config.Cron.AVIFConversionSchedule.RunAtStartDelay)*time.Minute, avifConversionCron)
 	}
+	if config.Config.Cron.PhashSchedule.RunAtStartDelay > 0 {
+		time.AfterFunc(time.Duration(config.Config.Cron.PhashSchedule.RunAtStartDelay)*time.Minute, generatePhashCron)
+	}
 
 	if config.Config.Cron.RescrapeSchedule.Enabled {
 		log.Println(fmt.Sprintf("Next Rescrape Task at %v", cronInstance.Entry(rescrapTask).Next))
@@ -100,15 +111,33 @@ func SetupCron() {
 	if config.Config.Cron.AVIFConversionSchedule.Enabled {
 		log.Println(fmt.Sprintf("Next AVIF Conversion Task at %v", cronInstance.Entry(avifConversionTask).Next))
 	}
+	if config.Config.Cron.PhashSchedule.Enabled {
+		log.Println(fmt.Sprintf("Next Phash Generation Task at %v", cronInstance.Entry(phashTask).Next))
+	}
 }
 
 func scrapeCron() {
 	if !session.HasActiveSession() {
-		tasks.Scrape("_enabled", "", "", false)
+		// sites with their own schedule are left to siteScheduleCron
+		if sites := tasks.GlobalScheduleSites(); len(sites) > 0 {
+			tasks.ScheduledScrape(strings.Join(sites, ","))
+		}
 	}
 	log.Println(fmt.Sprintf("Next Rescrape Task at %v", cronInstance.Entry(rescrapTask).Next))
 }
 
+// siteScheduleCron scrapes the sites with their own schedule that are due. Sites that are due while another
+// scrape runs are picked up by a later check.
+func siteScheduleCron() {
+	if session.HasActiveSession() || models.CheckLock("scrape") {
+		return
+	}
+	if sites := tasks.DueScheduledSites(); len(sites) > 0 {
+		log.Infof("Scraping scheduled sites %v", strings.Join(sites, ", "))
+		tasks.ScheduledScrape(strings.Join(sites, ","))
+	}
+}
+
 func rescanCron() {
 	if !session.HasActiveSession() {
 		tasks.RescanVolumes(-1)
@@ -216,3 +245,23 @@ func avifConversionCron() {
 	}
 	log.Println(fmt.Sprintf("Next AVIF Conversion Task at %v", cronInstance.Entry(avifConversionTask).Next))
 }
+
+var phashGenerateInProgress = false
+
+func generatePhashCron() {
+	if !session.HasActiveSession() && !phashGenerateInProgress {
+		phashGenerateInProgress = true
+		defer func() {
+			phashGenerateInProgress = false
+		}()
+
+		var endTime *time.Time
+		if config.Config.Cron.PhashSchedule.UseRange {
+			et := calcEndTime(config.Config.Cron.PhashSchedule.HourStart, config.Config.Cron.PhashSchedule.HourEnd, config.Config.Cron.PhashSchedule.MinuteStart)
+			endTime = &et
+			log.Infof("Phash Generation will stop at %v", endTime)
+		}
+		tasks.GeneratePhashes(endTime)
+	}
+	log.Println(fmt.Sprintf("Next Phash Generation Task at %v", cronInstance.Entry(phashTask).Next))
+}
@@ -28,6 +28,7 @@ import (
 	"github.com/xbapps/xbvr/pkg/config"
 	"github.com/xbapps/xbvr/pkg/migrations"
 	"github.com/xbapps/xbvr/pkg/models"
+	"github.com/xbapps/xbvr/pkg/scrape"
 	"github.com/xbapps/xbvr/pkg/session"
 	"github.com/xbapps/xbvr/pkg/tasks"
 	"github.com/xbapps/xbvr/ui"
@@ -75,6 +76,8 @@ func StartServer(version, commit, branch, date string) {
 	go tasks.CheckDependencies()
 	models.CheckVolumes()
 
+	scrape.LoadSceneScraperDefinitions()
+	scrape.LoadScraperPlugins()
 	models.InitSites()
 
 	restful.DefaultContainer.EnableContentEncoding(true)
@@ -100,6 +103,8 @@ func StartServer(version, commit, branch, date string) {
 	restful.Add(api.TagGroupResource{}.WebService())
 	restful.Add(api.ExternalReference{}.WebService())
 	restful.Add(api.HealthResource{}.WebService())
+	restful.Add(api.DuplicatesResource{}.WebService())
+	restful.Add(api.NotificationResource{}.WebService())
 
 	restConfig := restfulspec.Config{
 		WebServices: restful.RegisteredWebServices(),
@@ -231,6 +236,10 @@ func StartServer(version, commit, branch, date string) {
 	// Cron
 	SetupCron()
 
+	// Filesystem watchers for local volumes
+	go tasks.SyncVolumeWatchers()
+	go tasks.StartSearchIndexer()
+
 	// List binding addresses
 	addrs, _ := net.InterfaceAddrs()
 	ips := []string{}
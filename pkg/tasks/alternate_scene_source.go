@@ -40,6 +40,7 @@ func AddAlternateSceneSource(db *gorm.DB, scrapedScene models.ScrapedScene) {
 	extref.UdfBool2 = scene.AiScript
 	extref.UdfDatetime1 = scene.ScriptPublished
 	extref.Save()
+	models.UpdateAltSourceFilenameIndex(db, extref.ID, scrapedScene.Filenames)
 }
 
 func MatchAlternateSources() {
@@ -259,12 +260,7 @@ func MatchAlternateSources() {
 }
 func AltSourceSearch(searchRequest *bleve.SearchRequest) (*bleve.SearchResult, error) {
 	// open and close the search for each search, this stops the search function from locking users out of searching
-	idx, err := NewIndex("scenes")
-	if err != nil {
-		return nil, err
-	}
-	defer idx.Bleve.Close()
-	return idx.Bleve.Search(searchRequest)
+	return searchIndexOnce(searchRequest)
 }
 func UpdateLinks(db *gorm.DB, externalreference_id uint, newLink models.ExternalReferenceLink) {
 	var extref models.ExternalReference
@@ -1,6 +1,7 @@
 package tasks
 
 import (
+	"context"
 	"encoding/json"
 	"errors"
 	"fmt"
@@ -104,11 +105,11 @@ func CleanTags() {
 	CountTags()
 }
 
-func runScrapers(knownScenes []string, toScrape string, updateSite bool, collectedScenes chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, forceLimit bool) error {
-	defer scrape.DeleteScrapeCache()
+func runScrapers(ctx context.Context, knownScenes []string, toScrape string, updateSite bool, collectedScenes chan<- models.ScrapedScene, singleSceneURL string, singeScrapeAdditionalInfo string, forceLimit bool, stats *scraperRunStats) error {
 	defer scrape.CleanupFlareSolverrSession() // Clean up FlareSolverr session when scraping is done
 
 	scrapers := models.GetScrapers()
+	scrape.LoadHTTPCachePolicies()
 
 	var sites []models.Site
 	commonDb, _ := models.GetCommonDB()
@@ -117,7 +118,8 @@ func runScrapers(knownScenes []string, toScrape string, updateSite bool, collect
 	} else if toScrape == "_enabled" {
 		commonDb.Where(&models.Site{IsEnabled: true}).Find(&sites)
 	} else {
-		commonDb.Where(&models.Site{ID: toScrape}).Find(&sites)
+		// a single site or a comma separated list of sites
+		commonDb.Where("id in (?)", strings.Split(toScrape, ",")).Find(&sites)
 	}
 
 	var wg models.ScrapeWG
@@ -128,12 +130,23 @@ func runScrapers(knownScenes []string, toScrape string, updateSite bool, collect
 	}
 	if len(sites) > 0 {
 		for _, site := range sites {
+			if ctx.Err() != nil {
+				break
+			}
 			for _, scraper := range scrapers {
 				if site.ID == scraper.ID {
 					wg.Add(1)
+					siteCtx, cancel := startSiteScrape(ctx, scraper.ID)
+					siteCtx = scrape.WithCacheSite(siteCtx, scraper.ID)
 					go func(scraper models.Scraper) {
+						defer cancel()
 						limitScraping := site.LimitScraping || forceLimit
-						scraper.Scrape(&wg, updateSite, knownScenes, collectedScenes, singleSceneURL, singeScrapeAdditionalInfo, limitScraping)
+						stats.start(scraper, limitScraping)
+						scrapeErr := scraper.Scrape(siteCtx, &wg, updateSite, knownScenes, collectedScenes, singleSceneURL, singeScrapeAdditionalInfo, limitScraping)
+						if scrapeErr == nil && siteCtx.Err() != nil {
+							scrapeErr = errors.New("cancelled")
+						}
+						stats.finish(scraper.ID, scrapeErr)
 						var site models.Site
 						err := site.GetIfExist(scraper.ID)
 						if err != nil {
@@ -155,7 +168,7 @@ func runScrapers(knownScenes []string, toScrape string, updateSite bool, collect
 			for _, scraper := range scrapers {
 				if toScrape == scraper.ID {
 					wg.Add(1)
-					go scraper.Scrape(&wg, updateSite, knownScenes, collectedScenes, singleSceneURL, singeScrapeAdditionalInfo, false)
+					go scraper.Scrape(scrape.WithCacheSite(ctx, scraper.ID), &wg, updateSite, knownScenes, collectedScenes, singleSceneURL, singeScrapeAdditionalInfo, false)
 				}
 			}
 		} else {
@@ -173,7 +186,7 @@ func sceneSliceAppender(collectedScenes *[]models.ScrapedScene, scenes <-chan mo
 	}
 }
 
-func sceneDBWriter(wg *sync.WaitGroup, i *uint64, scenes <-chan models.ScrapedScene, processedScenes *[]models.ScrapedScene, lock *sync.Mutex) {
+func sceneDBWriter(wg *sync.WaitGroup, i *uint64, scenes <-chan models.ScrapedScene, processedScenes *[]models.ScrapedScene, lock *sync.Mutex, stats *scraperRunStats, staging *sceneStaging) {
 	defer wg.Done()
 
 	commonDb, _ := models.GetCommonDB()
@@ -181,6 +194,16 @@ func sceneDBWriter(wg *sync.WaitGroup, i *uint64, scenes <-chan models.ScrapedSc
 		if os.Getenv("DEBUG") != "" {
 			log.Printf("Saving %v", scene.SceneID)
 		}
+		var existing int
+		if (stats != nil || staging != nil) && !scene.OnlyUpdateScriptData && scene.MasterSiteId == "" {
+			commonDb.Model(&models.Scene{}).Where("scene_id = ?", scene.SceneID).Count(&existing)
+		}
+		if !scene.OnlyUpdateScriptData {
+			stats.addScene(scene, existing == 0)
+		}
+		if staging.stage(commonDb, scene, existing == 0) {
+			continue
+		}
 		if scene.OnlyUpdateScriptData {
 			if config.Config.Funscripts.ScrapeFunscripts {
 				models.SceneUpdateScriptData(commonDb, scene)
@@ -226,6 +249,7 @@ func ReapplyEdits() {
 	}
 
 	actionCnt := 0
+	manualFields := map[string]bool{}
 
 	for _, a := range actions {
 		if actionCnt%100 == 0 {
@@ -239,6 +263,16 @@ func ReapplyEdits() {
 			// scene has been deleted, nothing to apply
 			continue
 		}
+		if field := models.ActionColumnField(a.ChangedColumn); field != "" {
+			if models.GetLockedSceneFields(db, scene.ID)[field] {
+				// the scrape left the field alone, so the edit is still in place
+				continue
+			}
+			if key := fmt.Sprintf("%v-%v", scene.ID, field); !manualFields[key] {
+				manualFields[key] = true
+				models.SetSceneFieldSources(db, scene.ID, models.FieldSourceManual, "", field)
+			}
+		}
 		if a.ChangedColumn == "tags" || a.ChangedColumn == "cast" || a.ChangedColumn == "is_multipart" {
 			prefix := string(a.NewValue[0])
 			name := a.NewValue[1:]
@@ -280,6 +314,11 @@ func ReapplyEdits() {
 		case "release_date_text":
 			dt, _ := time.Parse("2006-01-02", a.NewValue)
 			db.Model(&scene).Update("release_date", dt)
+		case "filenames_arr":
+			// the scrape rebuilt the filename index from the scraped filenames
+			db.Model(&scene).Update(a.ChangedColumn, a.NewValue)
+			scene.FilenamesArr = a.NewValue
+			scene.UpdateFilenameIndex(db)
 		default:
 			db.Model(&scene).Update(a.ChangedColumn, a.NewValue)
 		}
@@ -305,6 +344,15 @@ func ScrapeSingleScene(toScrape string, singleSceneURL string, singeScrapeAdditi
 
 }
 func Scrape(toScrape string, singleSceneURL string, singeScrapeAdditionalInfo string, forceLimit bool) {
+	scrapeScenes(toScrape, singleSceneURL, singeScrapeAdditionalInfo, forceLimit, false)
+}
+
+// ScheduledScrape scrapes the sites like Scrape, applying the auto approve rules of scene staging
+func ScheduledScrape(toScrape string) {
+	scrapeScenes(toScrape, "", "", false, true)
+}
+
+func scrapeScenes(toScrape string, singleSceneURL string, singeScrapeAdditionalInfo string, forceLimit bool, scheduled bool) {
 	if !models.CheckLock("scrape") {
 		models.CreateLock("scrape")
 		defer models.RemoveLock("scrape")
@@ -340,10 +388,20 @@ func Scrape(toScrape string, singleSceneURL string, singeScrapeAdditionalInfo st
 
 		var wg sync.WaitGroup
 		wg.Add(1)
-		go sceneDBWriter(&wg, &sceneCount, collectedScenes, &processedScenes, &processedScenesLock)
+		// single scenes say nothing about the health of a scraper, and are scraped to be used right away
+		var stats *scraperRunStats
+		var staging *sceneStaging
+		if singleSceneURL == "" {
+			stats = newScraperRunStats()
+			staging = newSceneStaging(scheduled)
+		}
+		go sceneDBWriter(&wg, &sceneCount, collectedScenes, &processedScenes, &processedScenesLock, stats, staging)
+
+		ctx, cancel := startScrapeRun()
+		defer cancel()
 
 		// Start scraping
-		if e := runScrapers(knownScenes, toScrape, true, collectedScenes, singleSceneURL, singeScrapeAdditionalInfo, forceLimit); e != nil {
+		if e := runScrapers(ctx, knownScenes, toScrape, true, collectedScenes, singleSceneURL, singeScrapeAdditionalInfo, forceLimit, stats); e != nil {
 			tlog.Info(e)
 		} else {
 			// Notify DB Writer threads that there are no more scenes
@@ -351,6 +409,8 @@ func Scrape(toScrape string, singleSceneURL string, singeScrapeAdditionalInfo st
 
 			// Wait for DB Writer threads to complete
 			wg.Wait()
+			stats.save(commonDb)
+			scrape.PruneHTTPCache()
 
 			// Send a signal to clean up the progress bars just in case
 			log.WithField("task", "scraperProgress").Info("DONE")
@@ -376,7 +436,11 @@ func Scrape(toScrape string, singleSceneURL string, singeScrapeAdditionalInfo st
 			if config.Config.Advanced.LinkScenesAfterSceneScraping {
 				MatchAlternateSources()
 			}
+			CheckSavedSearches()
 
+			if ctx.Err() != nil {
+				tlog.Info("Scraping was cancelled")
+			}
 			tlog.Infof("Scraped %v new scenes in %s",
 				sceneCount,
 				time.Since(t0).Round(time.Second))
@@ -507,7 +571,9 @@ func ExportBundle() {
 		var scrapedScenes []models.ScrapedScene
 		go sceneSliceAppender(&scrapedScenes, collectedScenes)
 
-		runScrapers(knownScenes, "_enabled", false, collectedScenes, "", "", false)
+		ctx, cancel := startScrapeRun()
+		defer cancel()
+		runScrapers(ctx, knownScenes, "_enabled", false, collectedScenes, "", "", false, nil)
 
 		out := ContentBundle{
 			Timestamp:     time.Now().UTC(),
@@ -928,6 +994,10 @@ func RestoreBundle(request RequestRestore) {
 				RestoreKvs(bundleData.Kvs, db)
 			}
 
+			if request.InclScenes || request.InclExternalRefs {
+				models.RebuildSceneFilenameIndex(db)
+			}
+
 			if request.InclScenes {
 				CountTags()
 				IndexScenes(&(bundleData.Scenes))
@@ -1382,6 +1452,7 @@ func RenameTags() {
 			for j := range newTags {
 				db.Model(&scenes[i]).Association("Tags").Append(&newTags[j])
 			}
+			models.QueueSceneIndex(scenes[i].ID)
 		}
 
 	}
@@ -0,0 +1,368 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"regexp"
+	"sort"
+	"strings"
+
+	"github.com/jinzhu/gorm"
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+// DuplicateFileGroup is a set of video files that hold the same content. Reasons lists what linked the
+// files: "oshash" for identical files, "size_duration" for identical size and length and "phash" for
+// other encodes of the same video.
+type DuplicateFileGroup struct {
+	Reasons    []string      `json:"reasons"`
+	BestFileID uint          `json:"best_file_id"`
+	Files      []models.File `json:"files"`
+}
+
+// DuplicateSceneGroup is a set of scenes, usually from different sites, for the same release
+type DuplicateSceneGroup struct {
+	Key    string         `json:"key"`
+	Scenes []models.Scene `json:"scenes"`
+}
+
+var duplicateTitleRe = regexp.MustCompile(`[^\p{L}\p{N}]+`)
+
+// duplicateFileSets is a union find over file indexes, recording which rules joined each set
+type duplicateFileSets struct {
+	parent  []int
+	reasons map[int]map[string]bool
+}
+
+func (s *duplicateFileSets) find(i int) int {
+	for s.parent[i] != i {
+		s.parent[i] = s.parent[s.parent[i]]
+		i = s.parent[i]
+	}
+	return i
+}
+
+func (s *duplicateFileSets) union(a int, b int, reason string) {
+	ra, rb := s.find(a), s.find(b)
+	if ra != rb {
+		s.parent[rb] = ra
+		for r := range s.reasons[rb] {
+			s.addReason(ra, r)
+		}
+		delete(s.reasons, rb)
+	}
+	s.addReason(ra, reason)
+}
+
+func (s *duplicateFileSets) addReason(root int, reason string) {
+	if s.reasons[root] == nil {
+		s.reasons[root] = map[string]bool{}
+	}
+	s.reasons[root][reason] = true
+}
+
+// InSameDuplicateGroup reports whether the files all belong to one group found by FindDuplicateFiles
+func InSameDuplicateGroup(db *gorm.DB, fileIDs []uint) bool {
+	if len(fileIDs) == 0 {
+		return false
+	}
+	for _, group := range FindDuplicateFiles(db) {
+		members := map[uint]bool{}
+		for _, f := range group.Files {
+			members[f.ID] = true
+		}
+		if !members[fileIDs[0]] {
+			continue
+		}
+		for _, id := range fileIDs {
+			if !members[id] {
+				return false
+			}
+		}
+		return true
+	}
+	return false
+}
+
+// FindDuplicateFiles groups video files by oshash, then by size and duration, then by perceptual hash
+func FindDuplicateFiles(db *gorm.DB) []DuplicateFileGroup {
+	var files []models.File
+	db.Where("type = ?", "video").Order("id").Find(&files)
+
+	sets := duplicateFileSets{parent: make([]int, len(files)), reasons: map[int]map[string]bool{}}
+	for i := range sets.parent {
+		sets.parent[i] = i
+	}
+
+	byHash := map[string]int{}
+	bySize := map[string]int{}
+	for i, f := range files {
+		if f.OsHash != "" {
+			if j, ok := byHash[f.OsHash]; ok {
+				sets.union(j, i, "oshash")
+			} else {
+				byHash[f.OsHash] = i
+			}
+		}
+		if f.Size > 0 && f.VideoDuration > 0 {
+			key := fmt.Sprintf("%d-%d", f.Size, int(math.Round(f.VideoDuration)))
+			if j, ok := bySize[key]; ok {
+				sets.union(j, i, "size_duration")
+			} else {
+				bySize[key] = i
+			}
+		}
+	}
+
+	var hashed []int
+	for i, f := range files {
+		if f.Phash != "" {
+			hashed = append(hashed, i)
+		}
+	}
+	for x := 0; x < len(hashed); x++ {
+		a := files[hashed[x]]
+		for y := x + 1; y < len(hashed); y++ {
+			b := files[hashed[y]]
+			distance := models.PhashDistance(a.Phash, b.Phash)
+			if distance < 0 || distance > models.PhashMatchDistance {
+				continue
+			}
+			// cuts and trailers share frames with the full video, the length has to be close as well
+			if math.Abs(a.VideoDuration-b.VideoDuration) > math.Max(5, a.VideoDuration*0.02) {
+				continue
+			}
+			sets.union(hashed[x], hashed[y], "phash")
+		}
+	}
+
+	members := map[int][]int{}
+	var roots []int
+	for i := range files {
+		root := sets.find(i)
+		if len(members[root]) == 0 {
+			roots = append(roots, root)
+		}
+		members[root] = append(members[root], i)
+	}
+
+	groups := []DuplicateFileGroup{}
+	for _, root := range roots {
+		if len(members[root]) < 2 {
+			continue
+		}
+		group := DuplicateFileGroup{}
+		for _, i := range members[root] {
+			group.Files = append(group.Files, files[i])
+		}
+		for r := range sets.reasons[root] {
+			group.Reasons = append(group.Reasons, r)
+		}
+		sort.Strings(group.Reasons)
+		group.BestFileID = BestDuplicateFile(group.Files).ID
+		groups = append(groups, group)
+	}
+	return groups
+}
+
+// BestDuplicateFile picks the file to keep: highest resolution, then bitrate, then the more efficient codec
+func BestDuplicateFile(files []models.File) models.File {
+	var best models.File
+	for i, f := range files {
+		if i == 0 || betterDuplicateFile(f, best) {
+			best = f
+		}
+	}
+	return best
+}
+
+func betterDuplicateFile(a models.File, b models.File) bool {
+	if a.VideoWidth != b.VideoWidth {
+		return a.VideoWidth > b.VideoWidth
+	}
+	if a.VideoBitRate != b.VideoBitRate {
+		return a.VideoBitRate > b.VideoBitRate
+	}
+	if codecRank(a.VideoCodecName) != codecRank(b.VideoCodecName) {
+		return codecRank(a.VideoCodecName) > codecRank(b.VideoCodecName)
+	}
+	return a.Size > b.Size
+}
+
+func codecRank(codec string) int {
+	switch strings.ToLower(codec) {
+	case "av1":
+		return 3
+	case "hevc", "h265":
+		return 2
+	case "h264":
+		return 1
+	}
+	return 0
+}
+
+// FindDuplicateScenes groups visible scenes by normalised title, release date and cast
+func FindDuplicateScenes(db *gorm.DB) []DuplicateSceneGroup {
+	var scenes []models.Scene
+	db.Select("id, scene_id, title, site, scraper_id, release_date, release_date_text, cover_url, duration, is_available, favourite, watchlist").
+		Where("is_hidden = ? and title <> ''", false).Order("id").Find(&scenes)
+
+	type castRow struct {
+		SceneID uint
+		Name    string
+	}
+	var castRows []castRow
+	db.Table("scene_cast").Select("scene_cast.scene_id, actors.name").
+		Joins("join actors on actors.id = scene_cast.actor_id").
+		Scan(&castRows)
+	cast := map[uint][]string{}
+	for _, r := range castRows {
+		cast[r.SceneID] = append(cast[r.SceneID], normalizeDuplicateText(r.Name))
+	}
+
+	byKey := map[string][]models.Scene{}
+	var keys []string
+	for _, scene := range scenes {
+		title := normalizeDuplicateText(scene.Title)
+		if title == "" {
+			continue
+		}
+		date := ""
+		if !scene.ReleaseDate.IsZero() && scene.ReleaseDate.Year() > 1900 {
+			date = scene.ReleaseDate.Format("2006-01-02")
+		}
+		names := cast[scene.ID]
+		sort.Strings(names)
+		// a title alone is too weak, generic titles are reused across sites
+		if date == "" && len(names) == 0 {
+			continue
+		}
+		key := title + "|" + date + "|" + strings.Join(names, ",")
+		if len(byKey[key]) == 0 {
+			keys = append(keys, key)
+		}
+		byKey[key] = append(byKey[key], scene)
+	}
+
+	groups := []DuplicateSceneGroup{}
+	for _, key := range keys {
+		if len(byKey[key]) > 1 {
+			groups = append(groups, DuplicateSceneGroup{Key: key, Scenes: byKey[key]})
+		}
+	}
+	return groups
+}
+
+func normalizeDuplicateText(s string) string {
+	return duplicateTitleRe.ReplaceAllString(strings.ToLower(s), "")
+}
+
+// MergeScenes moves the files and user data of the source scenes to the target scene. The source scenes
+// are hidden rather than deleted, so the next scrape of their site doesn't bring them back.
+func MergeScenes(targetID uint, sourceIDs []uint) (models.Scene, error) {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	var target models.Scene
+	if err := target.GetIfExistByPK(targetID); err != nil {
+		return target, err
+	}
+
+	var filenames []string
+	json.Unmarshal([]byte(target.FilenamesArr), &filenames)
+	var targetCuepoints int
+	db.Model(&models.SceneCuepoint{}).Where("scene_id = ?", target.ID).Count(&targetCuepoints)
+
+	for _, id := range sourceIDs {
+		if id == target.ID {
+			continue
+		}
+		var source models.Scene
+		if err := source.GetIfExistByPK(id); err != nil {
+			return target, fmt.Errorf("scene %v not found", id)
+		}
+
+		tx := db.Begin()
+		tx.Model(&models.File{}).Where("scene_id = ?", source.ID).Update("scene_id", target.ID)
+		tx.Model(&models.History{}).Where("scene_id = ?", source.ID).Update("scene_id", target.ID)
+		if targetCuepoints == 0 {
+			tx.Model(&models.SceneCuepoint{}).Where("scene_id = ?", source.ID).Update("scene_id", target.ID)
+		}
+		tx.Model(&models.FileMatchSuggestion{}).Where("scene_id = ?", source.ID).Update("scene_id", target.ID)
+
+		var sourceFilenames []string
+		json.Unmarshal([]byte(source.FilenamesArr), &sourceFilenames)
+		for _, fn := range sourceFilenames {
+			found := false
+			for _, existing := range filenames {
+				if strings.EqualFold(existing, fn) {
+					found = true
+				}
+			}
+			if !found {
+				filenames = append(filenames, fn)
+			}
+		}
+
+		target.Favourite = target.Favourite || source.Favourite
+		target.Watchlist = target.Watchlist || source.Watchlist
+		target.Wishlist = target.Wishlist || source.Wishlist
+		target.IsWatched = target.IsWatched || source.IsWatched
+		if target.StarRating == 0 {
+			target.StarRating = source.StarRating
+		}
+		target.TotalWatchTime += source.TotalWatchTime
+		if source.LastOpened.After(target.LastOpened) {
+			target.LastOpened = source.LastOpened
+		}
+		if !source.AddedDate.IsZero() && (target.AddedDate.IsZero() || source.AddedDate.Before(target.AddedDate)) {
+			target.AddedDate = source.AddedDate
+		}
+
+		if err := tx.Model(&models.Scene{}).Where("id = ?", source.ID).Updates(map[string]interface{}{
+			"is_hidden":     true,
+			"filenames_arr": "[]",
+			"favourite":     false,
+			"watchlist":     false,
+			"wishlist":      false,
+		}).Error; err != nil {
+			tx.Rollback()
+			return target, err
+		}
+		if err := tx.Commit().Error; err != nil {
+			return target, err
+		}
+
+		source.FilenamesArr = "[]"
+		source.UpdateFilenameIndex(db)
+		source.UpdateStatus()
+		models.QueueSceneIndex(source.ID)
+		models.AddAction(source.SceneID, "edit", "filenames_arr", source.FilenamesArr)
+		log.Infof("Merged scene %v into %v", source.SceneID, target.SceneID)
+	}
+
+	tmp, _ := json.Marshal(filenames)
+	target.FilenamesArr = string(tmp)
+	if err := db.Model(&models.Scene{}).Where("id = ?", target.ID).Updates(map[string]interface{}{
+		"filenames_arr":    target.FilenamesArr,
+		"favourite":        target.Favourite,
+		"watchlist":        target.Watchlist,
+		"wishlist":         target.Wishlist,
+		"is_watched":       target.IsWatched,
+		"star_rating":      target.StarRating,
+		"total_watch_time": target.TotalWatchTime,
+		"last_opened":      target.LastOpened,
+		"added_date":       target.AddedDate,
+	}).Error; err != nil {
+		return target, err
+	}
+	target.UpdateFilenameIndex(db)
+	models.AddAction(target.SceneID, "edit", "filenames_arr", target.FilenamesArr)
+	target.UpdateStatus()
+	// the updates above bypass Scene.Save, the files and cuepoints moved between the scenes
+	models.QueueSceneIndex(target.ID)
+
+	target.GetIfExistByPK(target.ID)
+	return target, nil
+}
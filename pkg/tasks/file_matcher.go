@@ -0,0 +1,456 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/blevesearch/bleve/v2"
+	"github.com/blevesearch/bleve/v2/search/query"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+	"github.com/xbapps/xbvr/pkg/config"
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+// ParsedFilename holds what the fuzzy matcher could recognise in a filename
+type ParsedFilename struct {
+	Studio     string    `json:"studio"`
+	SiteID     string    `json:"site_id"`
+	Date       time.Time `json:"date"`
+	Performers []string  `json:"performers"`
+	ReleaseIDs []string  `json:"release_ids"`
+	Words      []string  `json:"words"`
+}
+
+type MatchSuggestion struct {
+	Scene      models.Scene `json:"scene"`
+	Confidence float64      `json:"confidence"`
+	Reasons    []string     `json:"reasons"`
+}
+
+var (
+	fuzzyTokenSeparator = regexp.MustCompile(`[^a-z0-9]+`)
+	fuzzyDateYMD        = regexp.MustCompile(`(?:^|[^0-9])((?:19|20)\d{2})[-_. ]?(0[1-9]|1[0-2])[-_. ]?(0[1-9]|[12]\d|3[01])(?:[^0-9]|$)`)
+	fuzzyDateShortYMD   = regexp.MustCompile(`(?:^|[^0-9])(\d{2})[-_. ](0[1-9]|1[0-2])[-_. ](0[1-9]|[12]\d|3[01])(?:[^0-9]|$)`)
+	fuzzyResolution     = regexp.MustCompile(`^\d+(p|k|fps|x\d+)$`)
+	fuzzySiteSuffix     = regexp.MustCompile(`\s*\(.*\)\s*$`)
+)
+
+// words that describe the video format rather than the scene
+var fuzzyNoiseWords = map[string]bool{
+	"180": true, "360": true, "3d": true, "3dh": true, "3dv": true, "fb360": true, "fisheye": true, "fisheye190": true,
+	"funscript": true, "cmscript": true, "h264": true, "h265": true, "hevc": true, "x264": true, "x265": true, "av1": true, "vp9": true,
+	"hq": true, "lq": true, "uhq": true, "lr": true, "rl": true, "sbs": true, "tb": true, "mkx200": true, "mkx220": true, "rf52": true,
+	"vrca220": true, "mono": true, "mp4": true, "mkv": true, "oculus": true, "oculus5k": true, "oculusrift": true, "quest": true,
+	"quest2": true, "quest3": true, "gearvr": true, "psvr": true, "pico": true, "original": true, "smartphone": true, "desktop": true,
+	"vr": true, "xxx": true, "ai": true, "2d": true, "ow": true, "alpha": true, "passthrough": true, "trailer": true, "hsp": true,
+	"srt": true, "the": true, "and": true, "with": true, "a": true, "an": true, "of": true, "in": true, "on": true, "s": true,
+}
+
+// fileMatcher keeps the site list while matching a batch of files, the search index is opened for each search so
+// the indexer and the searches of the UI aren't locked out while a batch is matched
+type fileMatcher struct {
+	db    *gorm.DB
+	sites []models.Site
+}
+
+func newFileMatcher(db *gorm.DB) *fileMatcher {
+	m := &fileMatcher{db: db}
+	db.Find(&m.sites)
+	return m
+}
+
+func fuzzyNormalize(s string) string {
+	return fuzzyTokenSeparator.ReplaceAllString(strings.ToLower(s), "")
+}
+
+// Parse extracts the studio, release date, performers and release ids from a filename,
+// the remaining words are used to search titles
+func (m *fileMatcher) Parse(filename string) ParsedFilename {
+	var p ParsedFilename
+	name := strings.ToLower(strings.TrimSuffix(filename, filepath.Ext(filename)))
+
+	if match := fuzzyDateYMD.FindStringSubmatch(name); match != nil {
+		if d, err := time.Parse("2006-01-02", fmt.Sprintf("%v-%v-%v", match[1], match[2], match[3])); err == nil {
+			p.Date = d
+			name = strings.Replace(name, strings.Trim(match[0], "-_. "), " ", 1)
+		}
+	} else if match := fuzzyDateShortYMD.FindStringSubmatch(name); match != nil {
+		if d, err := time.Parse("06-01-02", fmt.Sprintf("%v-%v-%v", match[1], match[2], match[3])); err == nil {
+			p.Date = d
+			name = strings.Replace(name, strings.Trim(match[0], "-_. "), " ", 1)
+		}
+	}
+
+	var tokens []string
+	for _, t := range fuzzyTokenSeparator.Split(name, -1) {
+		if t != "" && !fuzzyNoiseWords[t] && !fuzzyResolution.MatchString(t) {
+			tokens = append(tokens, t)
+		}
+	}
+	used := make([]bool, len(tokens))
+
+	// studio, the longest run of tokens matching a site name
+	siteNames := map[string]models.Site{}
+	for _, site := range m.sites {
+		siteNames[fuzzyNormalize(fuzzySiteSuffix.ReplaceAllString(site.Name, ""))] = site
+		siteNames[fuzzyNormalize(site.ID)] = site
+	}
+	bestLen := 0
+	bestStart := 0
+	for n := 4; n >= 1 && bestLen == 0; n-- {
+		for i := 0; i+n <= len(tokens); i++ {
+			if site, ok := siteNames[strings.Join(tokens[i:i+n], "")]; ok && len(strings.Join(tokens[i:i+n], "")) > 2 {
+				p.Studio = fuzzySiteSuffix.ReplaceAllString(site.Name, "")
+				p.SiteID = site.ID
+				bestLen = n
+				bestStart = i
+				break
+			}
+		}
+	}
+	for i := bestStart; i < bestStart+bestLen; i++ {
+		used[i] = true
+	}
+
+	// performers, runs of two or three tokens matching an actor
+	candidates := map[string][]int{}
+	var names []string
+	for n := 3; n >= 2; n-- {
+		for i := 0; i+n <= len(tokens); i++ {
+			name := strings.Join(tokens[i:i+n], " ")
+			if _, ok := candidates[name]; !ok {
+				names = append(names, name)
+			}
+			candidates[name] = []int{i, n}
+		}
+	}
+	if len(names) > 0 {
+		var actors []models.Actor
+		m.db.Where("lower(name) in (?)", names).Find(&actors)
+		sort.Slice(actors, func(i, j int) bool { return len(actors[i].Name) > len(actors[j].Name) })
+		for _, actor := range actors {
+			// collations of mysql also match names that only differ in accents
+			pos, ok := candidates[strings.ToLower(actor.Name)]
+			if !ok {
+				continue
+			}
+			free := true
+			for i := pos[0]; i < pos[0]+pos[1]; i++ {
+				free = free && !used[i]
+			}
+			if !free {
+				continue
+			}
+			for i := pos[0]; i < pos[0]+pos[1]; i++ {
+				used[i] = true
+			}
+			p.Performers = append(p.Performers, actor.Name)
+		}
+	}
+
+	for i, t := range tokens {
+		if used[i] {
+			continue
+		}
+		if _, err := strconv.Atoi(t); err == nil {
+			if len(t) >= 3 && len(t) <= 8 {
+				p.ReleaseIDs = append(p.ReleaseIDs, t)
+			}
+			continue
+		}
+		if len(t) > 1 {
+			p.Words = append(p.Words, t)
+		}
+	}
+	return p
+}
+
+// Suggest returns candidate scenes for a file ranked by confidence
+func (m *fileMatcher) Suggest(file models.File) (ParsedFilename, []MatchSuggestion) {
+	p := m.Parse(file.Filename)
+
+	candidateIDs := map[uint]bool{}
+
+	for _, id := range p.ReleaseIDs {
+		var scenes []models.Scene
+		tx := m.db.Select("id").Where("scene_id like ?", "%-"+id)
+		if p.SiteID != "" {
+			tx = tx.Where("scraper_id = ?", p.SiteID)
+		}
+		tx.Limit(20).Find(&scenes)
+		for _, s := range scenes {
+			candidateIDs[s.ID] = true
+		}
+	}
+
+	if !p.Date.IsZero() && (p.SiteID != "" || len(p.Performers) > 0) {
+		var scenes []models.Scene
+		tx := m.db.Select("distinct scenes.id").
+			Where("scenes.release_date between ? and ?", p.Date.AddDate(0, 0, -1), p.Date.AddDate(0, 0, 2))
+		if p.SiteID != "" {
+			tx = tx.Where("scenes.scraper_id = ?", p.SiteID)
+		}
+		if len(p.Performers) > 0 {
+			tx = tx.Joins("join scene_cast on scene_cast.scene_id = scenes.id join actors on actors.id = scene_cast.actor_id").
+				Where("actors.name in (?)", p.Performers)
+		}
+		tx.Limit(20).Find(&scenes)
+		for _, s := range scenes {
+			candidateIDs[s.ID] = true
+		}
+	}
+
+	// the parsed values come from filenames, they are searched as text and never parsed as query syntax
+	var q []query.Query
+	for _, word := range p.Words {
+		title := bleve.NewMatchQuery(word)
+		title.SetField("title")
+		q = append(q, title)
+	}
+	for _, performer := range p.Performers {
+		cast := bleve.NewMatchPhraseQuery(performer)
+		cast.SetField("cast")
+		cast.SetBoost(3)
+		q = append(q, cast)
+	}
+	if p.Studio != "" {
+		site := bleve.NewMatchPhraseQuery(p.Studio)
+		site.SetField("site")
+		site.SetBoost(2)
+		q = append(q, site)
+	}
+	if !p.Date.IsZero() {
+		inclusive := true
+		released := bleve.NewDateRangeInclusiveQuery(p.Date.AddDate(0, 0, -2), p.Date.AddDate(0, 0, 2), &inclusive, &inclusive)
+		released.SetField("released")
+		q = append(q, released)
+	}
+	var sceneIDs []string
+	if len(q) > 0 {
+		searchRequest := bleve.NewSearchRequest(bleve.NewDisjunctionQuery(q...))
+		searchRequest.Size = 15
+		searchRequest.SortBy([]string{"-_score"})
+		if results, err := searchIndexOnce(searchRequest); err == nil {
+			for _, hit := range results.Hits {
+				sceneIDs = append(sceneIDs, hit.ID)
+			}
+		}
+	}
+
+	var scenes []models.Scene
+	if len(candidateIDs) > 0 || len(sceneIDs) > 0 {
+		var ids []uint
+		for id := range candidateIDs {
+			ids = append(ids, id)
+		}
+		m.db.Preload("Cast").Where("id in (?) or scene_id in (?)", append(ids, 0), append(sceneIDs, "")).Find(&scenes)
+	}
+
+	var suggestions []MatchSuggestion
+	for _, scene := range scenes {
+		s := scoreSuggestion(p, file, scene)
+		if s.Confidence > 0 {
+			suggestions = append(suggestions, s)
+		}
+	}
+	sort.SliceStable(suggestions, func(i, j int) bool { return suggestions[i].Confidence > suggestions[j].Confidence })
+	if len(suggestions) > 10 {
+		suggestions = suggestions[:10]
+	}
+	return p, suggestions
+}
+
+// scoreSuggestion combines the parts of the filename that agree with a scene into a confidence between 0 and 1
+func scoreSuggestion(p ParsedFilename, file models.File, scene models.Scene) MatchSuggestion {
+	s := MatchSuggestion{Scene: scene}
+	score := 0.0
+
+	studioMatch := p.SiteID != "" && (scene.ScraperId == p.SiteID || fuzzyNormalize(scene.Site) == fuzzyNormalize(p.Studio))
+	if studioMatch {
+		score += 0.2
+		s.Reasons = append(s.Reasons, "studio")
+	}
+
+	for _, id := range p.ReleaseIDs {
+		if strings.HasSuffix(scene.SceneID, "-"+id) {
+			if studioMatch {
+				score += 0.45
+			} else {
+				score += 0.3
+			}
+			s.Reasons = append(s.Reasons, "release id")
+			break
+		}
+	}
+
+	if !p.Date.IsZero() && !scene.ReleaseDate.IsZero() {
+		y, mo, d := scene.ReleaseDate.Date()
+		days := math.Abs(time.Date(y, mo, d, 0, 0, 0, 0, time.UTC).Sub(p.Date).Hours() / 24)
+		if days < 1 {
+			score += 0.25
+			s.Reasons = append(s.Reasons, "release date")
+		} else if days <= 2 {
+			score += 0.15
+			s.Reasons = append(s.Reasons, "release date within 2 days")
+		}
+	}
+
+	if len(p.Performers) > 0 {
+		matched := 0
+		for _, performer := range p.Performers {
+			for _, actor := range scene.Cast {
+				if strings.EqualFold(actor.Name, performer) {
+					matched++
+					break
+				}
+			}
+		}
+		if matched > 0 {
+			score += 0.3 * float64(matched) / float64(len(p.Performers))
+			s.Reasons = append(s.Reasons, fmt.Sprintf("performers %v/%v", matched, len(p.Performers)))
+		}
+	}
+
+	var titleWords []string
+	for _, w := range fuzzyTokenSeparator.Split(strings.ToLower(scene.Title), -1) {
+		if len(w) > 1 && !fuzzyNoiseWords[w] {
+			titleWords = append(titleWords, w)
+		}
+	}
+	if len(titleWords) > 0 && len(p.Words) > 0 {
+		found := 0
+		for _, tw := range titleWords {
+			for _, w := range p.Words {
+				if w == tw {
+					found++
+					break
+				}
+			}
+		}
+		if found > 0 {
+			ratio := float64(found) / float64(len(titleWords))
+			score += 0.35 * ratio
+			s.Reasons = append(s.Reasons, fmt.Sprintf("title %.0f%%", ratio*100))
+		}
+	}
+
+	if file.VideoDuration > 0 && scene.Duration > 0 && math.Abs(file.VideoDuration/60-float64(scene.Duration)) <= 1 {
+		score += 0.1
+		s.Reasons = append(s.Reasons, "duration")
+	}
+
+	s.Confidence = math.Round(math.Min(score, 1)*100) / 100
+	return s
+}
+
+// FuzzyMatchFiles suggests scenes for files that didn't match any expected filename. A file is linked
+// when the best suggestion reaches the auto link threshold and is clearly ahead of the next one,
+// otherwise suggestions above the minimum confidence are queued for review.
+func FuzzyMatchFiles(db *gorm.DB, files []models.File, tlog *logrus.Entry) {
+	if !config.Config.Storage.FuzzyMatch || len(files) == 0 {
+		return
+	}
+
+	m := newFileMatcher(db)
+
+	linked := 0
+	queued := 0
+	for i := range files {
+		if files[i].SceneID != 0 || files[i].Type != "video" {
+			continue
+		}
+		_, suggestions := m.Suggest(files[i])
+
+		var rejected []uint
+		db.Model(&models.FileMatchSuggestion{}).Where("file_id = ? and status = ?", files[i].ID, "rejected").Pluck("scene_id", &rejected)
+		var filtered []MatchSuggestion
+		for _, s := range suggestions {
+			isRejected := false
+			for _, id := range rejected {
+				isRejected = isRejected || id == s.Scene.ID
+			}
+			if !isRejected && s.Confidence >= config.Config.Storage.FuzzyMatchMinConfidence {
+				filtered = append(filtered, s)
+			}
+		}
+
+		db.Where("file_id = ? and status = ?", files[i].ID, "pending").Delete(&models.FileMatchSuggestion{})
+		if len(filtered) == 0 {
+			continue
+		}
+
+		top := filtered[0]
+		if top.Confidence >= config.Config.Storage.FuzzyMatchAutoLink && (len(filtered) == 1 || top.Confidence-filtered[1].Confidence >= 0.1) {
+			LinkFileToScene(db, &files[i], top.Scene.ID)
+			saveMatchSuggestion(db, files[i].ID, top, "auto")
+			tlog.Infof("File %v matched to scene %v with confidence %v", files[i].Filename, top.Scene.SceneID, top.Confidence)
+			linked++
+			continue
+		}
+
+		for _, s := range filtered {
+			saveMatchSuggestion(db, files[i].ID, s, "pending")
+		}
+		queued++
+	}
+	if linked > 0 || queued > 0 {
+		tlog.Infof("Fuzzy matching linked %v files, %v files are waiting for review", linked, queued)
+	}
+}
+
+// SuggestScenesForFile returns ranked suggestions for a single file without storing them
+func SuggestScenesForFile(file models.File) (ParsedFilename, []MatchSuggestion, error) {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	m := newFileMatcher(db)
+	p, suggestions := m.Suggest(file)
+	return p, suggestions, nil
+}
+
+func saveMatchSuggestion(db *gorm.DB, fileID uint, s MatchSuggestion, status string) {
+	reasons, _ := json.Marshal(s.Reasons)
+	db.Create(&models.FileMatchSuggestion{
+		FileID:     fileID,
+		SceneID:    s.Scene.ID,
+		Confidence: s.Confidence,
+		Reasons:    string(reasons),
+		Status:     status,
+	})
+}
+
+// LinkFileToScene matches a file to a scene and adds the filename to the scene, so the file is
+// found again by name when it's moved
+func LinkFileToScene(db *gorm.DB, file *models.File, sceneID uint) error {
+	var scene models.Scene
+	if err := scene.GetIfExistByPK(sceneID); err != nil {
+		return err
+	}
+
+	file.SceneID = scene.ID
+	file.Save()
+
+	var pfTxt []string
+	json.Unmarshal([]byte(scene.FilenamesArr), &pfTxt)
+	pfTxt = append(pfTxt, file.Filename)
+	tmp, err := json.Marshal(pfTxt)
+	if err == nil {
+		scene.FilenamesArr = string(tmp)
+	}
+	scene.Save()
+	scene.UpdateFilenameIndex(db)
+	models.AddAction(scene.SceneID, "match", "filenames_arr", scene.FilenamesArr)
+
+	db.Where("file_id = ? and status = ?", file.ID, "pending").Delete(&models.FileMatchSuggestion{})
+
+	scene.UpdateStatus()
+	return nil
+}
@@ -6,6 +6,7 @@ import (
 	"net/http"
 	"os"
 	"path/filepath"
+	"strings"
 	"sync"
 	"sync/atomic"
 	"time"
@@ -59,7 +60,7 @@ var (
 	imageErrors   = map[uint]time.Time{}
 )
 
-const totalHealthSteps = 16
+const totalHealthSteps = 18
 
 func publishProgress(step string, stepNum int) {
 	pct := float64(stepNum) / float64(totalHealthSteps) * 100
@@ -646,6 +647,87 @@ func RunHealthCheck() {
 		})
 	}
 
+	if cancelled() {
+		publishCancelled()
+		return
+	}
+
+	// -- Step 17: Duplicate files & scenes --
+	publishProgress("Checking duplicate files and scenes", 17)
+	dupFiles := FindDuplicateFiles(commonDb)
+	if len(dupFiles) > 0 {
+		items := make([]AffectedItem, 0, len(dupFiles))
+		for _, g := range dupFiles {
+			for _, f := range g.Files {
+				if f.ID == g.BestFileID {
+					items = append(items, AffectedItem{ID: f.ID, Label: f.Filename, Extra: fmt.Sprintf("%d copies (%s)", len(g.Files), strings.Join(g.Reasons, ", "))})
+				}
+			}
+			if len(items) >= 50 {
+				break
+			}
+		}
+		issues = append(issues, HealthIssue{
+			ID: "duplicate-files", Category: "files", Severity: "info",
+			Description:   fmt.Sprintf("%d groups of duplicate video files", len(dupFiles)),
+			Detail:        "Review the groups in the duplicates report and keep the best file",
+			AffectedItems: items,
+		})
+	}
+	stats["duplicate_file_groups"] = len(dupFiles)
+
+	dupScenes := FindDuplicateScenes(commonDb)
+	if len(dupScenes) > 0 {
+		items := make([]AffectedItem, 0, len(dupScenes))
+		for _, g := range dupScenes {
+			var sites []string
+			for _, s := range g.Scenes {
+				sites = append(sites, s.Site)
+			}
+			items = append(items, AffectedItem{ID: g.Scenes[0].ID, Label: g.Scenes[0].Title, Extra: strings.Join(sites, ", ")})
+			if len(items) >= 50 {
+				break
+			}
+		}
+		issues = append(issues, HealthIssue{
+			ID: "duplicate-scenes", Category: "scenes", Severity: "info",
+			Description:   fmt.Sprintf("%d groups of scenes for the same release", len(dupScenes)),
+			Detail:        "Review the groups in the duplicates report and merge the scenes",
+			AffectedItems: items,
+		})
+	}
+	stats["duplicate_scene_groups"] = len(dupScenes)
+
+	if cancelled() {
+		publishCancelled()
+		return
+	}
+
+	// -- Step 18: Scraper anomalies --
+	publishProgress("Checking scraper runs", 18)
+	var enabledSites []models.Site
+	commonDb.Where("is_enabled = ?", true).Order("name").Find(&enabledSites)
+	latestRuns := models.GetLatestScraperRuns(commonDb)
+	scraperItems := make([]AffectedItem, 0)
+	for _, site := range enabledSites {
+		run, ok := latestRuns[site.ID]
+		if !ok {
+			continue
+		}
+		for _, a := range run.GetAnomalies() {
+			scraperItems = append(scraperItems, AffectedItem{Label: site.Name, Extra: a})
+		}
+	}
+	if len(scraperItems) > 0 {
+		issues = append(issues, HealthIssue{
+			ID: "scraper-anomalies", Category: "scrapers", Severity: "warning",
+			Description:   fmt.Sprintf("%d problems in the last run of enabled scrapers", len(scraperItems)),
+			Detail:        "The site may have changed, check the scraper log and the run history of the site",
+			AffectedItems: scraperItems,
+		})
+	}
+	stats["scraper_anomalies"] = len(scraperItems)
+
 	// -- Build summary & store --
 	summary := map[string]int{"critical": 0, "warning": 0, "info": 0}
 	for _, issue := range issues {
@@ -749,6 +831,8 @@ func FixHealthIssue(action string) error {
 				// Keep the first (most files, oldest), delete the rest
 				toDelete := sceneIDs[1:]
 				db.Where("id IN (?)", toDelete).Delete(&models.Scene{})
+				// the index entry is shared by scene id, index the kept scene again
+				models.QueueSceneIndex(sceneIDs[0])
 			}
 		}()
 
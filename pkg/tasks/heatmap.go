@@ -69,7 +69,8 @@ func GenerateHeatmaps(tlog *logrus.Entry) {
 			if tlog != nil && (i%50) == 0 {
 				tlog.Infof("Generating heatmaps (%v/%v)", i+1, len(scriptfiles))
 			}
-			if file.Exists() {
+			// scripts on network volumes are streamed to players but not rendered
+			if file.Volume.Type == "local" && file.Exists() {
 				path := file.GetPath()
 				if strings.HasSuffix(path, ".funscript") {
 					log.Infof("Rendering %v", file.Filename)
@@ -0,0 +1,308 @@
+package tasks
+
+import (
+	"encoding/json"
+	"encoding/xml"
+	"fmt"
+	"io"
+	"net/http"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/sirupsen/logrus"
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+// NfoMovie follows the Kodi movie nfo schema, which Jellyfin and Emby read as well
+type NfoMovie struct {
+	XMLName   xml.Name     `xml:"movie"`
+	Title     string       `xml:"title"`
+	Plot      string       `xml:"plot,omitempty"`
+	Runtime   int          `xml:"runtime,omitempty"`
+	Premiered string       `xml:"premiered,omitempty"`
+	Year      int          `xml:"year,omitempty"`
+	Studio    string       `xml:"studio,omitempty"`
+	Set       *NfoSet      `xml:"set,omitempty"`
+	UniqueID  NfoUniqueID  `xml:"uniqueid"`
+	Genres    []string     `xml:"genre"`
+	Tags      []string     `xml:"tag"`
+	Actors    []NfoActor   `xml:"actor"`
+	Thumbs    []NfoThumb   `xml:"thumb"`
+	Fanart    *NfoFanart   `xml:"fanart,omitempty"`
+	Rating    float64      `xml:"userrating,omitempty"`
+	PlayCount int          `xml:"playcount,omitempty"`
+	LastPlay  string       `xml:"lastplayed,omitempty"`
+	DateAdded string       `xml:"dateadded,omitempty"`
+	FileInfo  *NfoFileInfo `xml:"fileinfo,omitempty"`
+}
+
+type NfoSet struct {
+	Name string `xml:"name"`
+}
+
+type NfoUniqueID struct {
+	Type    string `xml:"type,attr"`
+	Default bool   `xml:"default,attr"`
+	Value   string `xml:",chardata"`
+}
+
+type NfoActor struct {
+	Name  string `xml:"name"`
+	Thumb string `xml:"thumb,omitempty"`
+	Order int    `xml:"order"`
+}
+
+type NfoThumb struct {
+	Aspect string `xml:"aspect,attr,omitempty"`
+	Value  string `xml:",chardata"`
+}
+
+type NfoFanart struct {
+	Thumbs []NfoThumb `xml:"thumb"`
+}
+
+type NfoFileInfo struct {
+	Video NfoStreamVideo `xml:"streamdetails>video"`
+}
+
+type NfoStreamVideo struct {
+	Codec           string `xml:"codec,omitempty"`
+	Width           int    `xml:"width,omitempty"`
+	Height          int    `xml:"height,omitempty"`
+	DurationSeconds int    `xml:"durationinseconds,omitempty"`
+	StereoMode      string `xml:"stereomode,omitempty"`
+}
+
+// NfoExport is a file written, or to be written in a dry run, next to a video
+type NfoExport struct {
+	FileID  uint   `json:"file_id"`
+	SceneID string `json:"scene_id"`
+	Path    string `json:"path"`
+	Status  string `json:"status"` // written, would write or failed
+	Reason  string `json:"reason,omitempty"`
+}
+
+// ExportNfo writes .nfo files with poster and fanart images next to the matched videos of volumes that
+// have nfo export enabled. Only videos whose scene changed since the last export are written, unless
+// force is set.
+func ExportNfo(dryRun bool, force bool) []NfoExport {
+	results := []NfoExport{}
+	if models.CheckLock("nfo") {
+		return results
+	}
+	models.CreateLock("nfo")
+	defer models.RemoveLock("nfo")
+
+	tlog := log.WithFields(logrus.Fields{"task": "nfo"})
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	var files []models.File
+	db.Preload("Volume").
+		Joins("join volumes on volumes.id = files.volume_id and volumes.type = 'local' and volumes.nfo_export = ?", true).
+		Where("files.type = ? and files.scene_id <> 0", "video").
+		Order("files.id").
+		Find(&files)
+
+	if len(files) > 0 {
+		tlog.Infof("Exporting nfo files")
+	}
+	for i := range files {
+		file := files[i]
+		var scene models.Scene
+		if err := db.Preload("Cast").Preload("Tags").Where("id = ?", file.SceneID).First(&scene).Error; err != nil {
+			continue
+		}
+
+		nfoPath, posterPath, fanartPath := nfoPaths(file)
+		_, nfoErr := os.Stat(nfoPath)
+		if !force && nfoErr == nil && file.NfoExportedAt.After(scene.UpdatedAt) {
+			continue
+		}
+
+		result := NfoExport{FileID: file.ID, SceneID: scene.SceneID, Path: nfoPath}
+		if dryRun {
+			result.Status = "would write"
+			results = append(results, result)
+			continue
+		}
+
+		if err := writeNfo(scene, file, nfoPath, posterPath, fanartPath); err != nil {
+			result.Status = "failed"
+			result.Reason = err.Error()
+			tlog.Warnf("Can't export nfo for %v: %v", file.GetPath(), err)
+		} else {
+			result.Status = "written"
+			db.Model(&models.File{}).Where("id = ?", file.ID).Update("nfo_exported_at", time.Now())
+		}
+		results = append(results, result)
+	}
+	if !dryRun && len(results) > 0 {
+		tlog.Infof("Exported %v nfo files", len(results))
+	}
+	return results
+}
+
+// nfoPaths names the sidecar files. A video that is alone in its folder gets poster.jpg and fanart.jpg,
+// otherwise the images are prefixed with the video name like the nfo.
+func nfoPaths(file models.File) (string, string, string) {
+	dir := file.Path
+	stem := strings.TrimSuffix(file.Filename, filepath.Ext(file.Filename))
+
+	videos := 0
+	allowedVideoExt := getAllowedVideoExt()
+	if entries, err := os.ReadDir(dir); err == nil {
+		for _, e := range entries {
+			ext := strings.ToLower(filepath.Ext(e.Name()))
+			for _, v := range allowedVideoExt {
+				if ext == v && !e.IsDir() {
+					videos++
+				}
+			}
+		}
+	}
+
+	nfo := filepath.Join(dir, stem+".nfo")
+	if videos <= 1 {
+		return nfo, filepath.Join(dir, "poster.jpg"), filepath.Join(dir, "fanart.jpg")
+	}
+	return nfo, filepath.Join(dir, stem+"-poster.jpg"), filepath.Join(dir, stem+"-fanart.jpg")
+}
+
+func BuildNfo(scene models.Scene, file models.File) NfoMovie {
+	movie := NfoMovie{
+		Title:    scene.Title,
+		Plot:     scene.Synopsis,
+		Runtime:  scene.Duration,
+		Studio:   scene.Studio,
+		UniqueID: NfoUniqueID{Type: "xbvr", Default: true, Value: scene.SceneID},
+		Genres:   []string{},
+		Tags:     []string{},
+		Actors:   []NfoActor{},
+		Thumbs:   []NfoThumb{},
+	}
+	if movie.Studio == "" {
+		movie.Studio = scene.Site
+	}
+	if scene.Site != "" {
+		movie.Set = &NfoSet{Name: scene.Site}
+	}
+	if movie.Runtime == 0 && file.VideoDuration > 0 {
+		movie.Runtime = int(file.VideoDuration / 60)
+	}
+	if !scene.ReleaseDate.IsZero() && scene.ReleaseDate.Year() > 1900 {
+		movie.Premiered = scene.ReleaseDate.Format("2006-01-02")
+		movie.Year = scene.ReleaseDate.Year()
+	}
+	if !scene.AddedDate.IsZero() && scene.AddedDate.Year() > 1900 {
+		movie.DateAdded = scene.AddedDate.Format("2006-01-02 15:04:05")
+	}
+	if scene.StarRating > 0 {
+		// xbvr rates 0-5, kodi 0-10
+		movie.Rating = scene.StarRating * 2
+	}
+	if scene.IsWatched {
+		movie.PlayCount = 1
+		if !scene.LastOpened.IsZero() && scene.LastOpened.Year() > 1900 {
+			movie.LastPlay = scene.LastOpened.Format("2006-01-02 15:04:05")
+		}
+	}
+	movie.Genres = append(movie.Genres, "VR")
+	for _, tag := range scene.Tags {
+		movie.Tags = append(movie.Tags, tag.Name)
+	}
+	for i, actor := range scene.Cast {
+		movie.Actors = append(movie.Actors, NfoActor{Name: actor.Name, Thumb: actor.ImageUrl, Order: i})
+	}
+	if scene.CoverURL != "" {
+		movie.Thumbs = append(movie.Thumbs, NfoThumb{Aspect: "poster", Value: scene.CoverURL})
+	}
+	if fanart := nfoFanartURL(scene); fanart != "" {
+		movie.Fanart = &NfoFanart{Thumbs: []NfoThumb{{Value: fanart}}}
+	}
+	if file.VideoWidth > 0 {
+		movie.FileInfo = &NfoFileInfo{Video: NfoStreamVideo{
+			Codec:           file.VideoCodecName,
+			Width:           file.VideoWidth,
+			Height:          file.VideoHeight,
+			DurationSeconds: int(file.VideoDuration),
+			StereoMode:      nfoStereoMode(file.VideoProjection),
+		}}
+	}
+	return movie
+}
+
+func nfoStereoMode(projection string) string {
+	switch {
+	case strings.HasSuffix(projection, "_sbs"):
+		return "left_right"
+	case strings.HasSuffix(projection, "_tb"):
+		return "top_bottom"
+	}
+	return ""
+}
+
+// nfoFanartURL picks a gallery image for the fanart, falling back to the cover
+func nfoFanartURL(scene models.Scene) string {
+	var images []models.Image
+	json.Unmarshal([]byte(scene.Images), &images)
+	for _, img := range images {
+		if img.Type == "gallery" && img.URL != "" && img.URL != scene.CoverURL {
+			return img.URL
+		}
+	}
+	return scene.CoverURL
+}
+
+func writeNfo(scene models.Scene, file models.File, nfoPath string, posterPath string, fanartPath string) error {
+	movie := BuildNfo(scene, file)
+	out, err := xml.MarshalIndent(movie, "", "  ")
+	if err != nil {
+		return err
+	}
+	content := append([]byte(xml.Header), out...)
+	if err := os.WriteFile(nfoPath, content, 0644); err != nil {
+		return err
+	}
+
+	if scene.CoverURL != "" {
+		if err := downloadNfoImage(scene.CoverURL, posterPath); err != nil {
+			return fmt.Errorf("poster: %v", err)
+		}
+	}
+	if fanart := nfoFanartURL(scene); fanart != "" {
+		if err := downloadNfoImage(fanart, fanartPath); err != nil {
+			return fmt.Errorf("fanart: %v", err)
+		}
+	}
+	return nil
+}
+
+var nfoHttpClient = &http.Client{Timeout: 30 * time.Second}
+
+// downloadNfoImage writes the image to a temporary file first, so a failed download doesn't replace a good image
+func downloadNfoImage(url string, dest string) error {
+	resp, err := nfoHttpClient.Get(url)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return fmt.Errorf("%v returned %v", url, resp.Status)
+	}
+
+	tmp := dest + ".part"
+	f, err := os.Create(tmp)
+	if err != nil {
+		return err
+	}
+	if _, err := io.Copy(f, resp.Body); err != nil {
+		f.Close()
+		os.Remove(tmp)
+		return err
+	}
+	f.Close()
+	return os.Rename(tmp, dest)
+}
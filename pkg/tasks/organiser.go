@@ -0,0 +1,544 @@
+package tasks
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+	"github.com/xbapps/xbvr/pkg/common"
+	"github.com/xbapps/xbvr/pkg/config"
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+// OrganiseCompanion is a script or subtitle file moved together with its video
+type OrganiseCompanion struct {
+	FileID uint   `json:"file_id"`
+	From   string `json:"from"`
+	To     string `json:"to"`
+}
+
+// OrganiseMove is the planned or performed move of one video file. Status is one of
+// move, unchanged, conflict, done or failed.
+type OrganiseMove struct {
+	FileID     uint                `json:"file_id"`
+	SceneID    uint                `json:"scene_id"`
+	From       string              `json:"from"`
+	To         string              `json:"to"`
+	Companions []OrganiseCompanion `json:"companions"`
+	Status     string              `json:"status"`
+	Reason     string              `json:"reason,omitempty"`
+}
+
+type OrganiseResult struct {
+	BatchID string         `json:"batch_id"`
+	DryRun  bool           `json:"dry_run"`
+	Moves   []OrganiseMove `json:"moves"`
+}
+
+var (
+	organiseTokenRe      = regexp.MustCompile(`\{([a-z_]+)(?::([^}]*))?\}`)
+	organiseIllegalRe    = regexp.MustCompile(`[<>:"/\\|?*\x00-\x1f]`)
+	organiseEmptyGroupRe = regexp.MustCompile(`\[\s*\]|\(\s*\)`)
+	organiseTrailingRe   = regexp.MustCompile(`(\s*-\s*)+(\.[^.]*)?$`)
+	organiseLeadingRe    = regexp.MustCompile(`^(\s*-\s*)+`)
+	organiseSpacesRe     = regexp.MustCompile(`\s+`)
+
+	organiseTokens = []string{"site", "studio", "title", "cast", "scene_id", "release_date", "projection", "width", "height", "filename", "ext"}
+)
+
+const organiseMaxSegment = 200
+
+// ValidateOrganiseTemplate checks a template only uses known fields, stays inside the volume and keeps the extension
+func ValidateOrganiseTemplate(template string) error {
+	if !strings.HasSuffix(template, ".{ext}") {
+		return errors.New("template has to end with .{ext}")
+	}
+	if strings.HasPrefix(template, "/") || strings.HasPrefix(template, "\\") || filepath.IsAbs(template) {
+		return errors.New("template has to be relative to the volume")
+	}
+	for _, segment := range strings.Split(template, "/") {
+		if segment == ".." || segment == "." {
+			return errors.New("template can't contain . or .. folders")
+		}
+	}
+	for _, m := range organiseTokenRe.FindAllStringSubmatch(template, -1) {
+		known := false
+		for _, t := range organiseTokens {
+			if m[1] == t {
+				known = true
+			}
+		}
+		if !known {
+			return fmt.Errorf("unknown field {%v}", m[1])
+		}
+	}
+	return nil
+}
+
+// RenderOrganisePath builds the path of a file relative to its volume from the template
+func RenderOrganisePath(template string, scene models.Scene, file models.File) (string, error) {
+	ext := filepath.Ext(file.Filename)
+	stem := strings.TrimSuffix(file.Filename, ext)
+
+	var cast []string
+	for i, actor := range scene.Cast {
+		if i == 4 {
+			break
+		}
+		cast = append(cast, actor.Name)
+	}
+
+	rendered := organiseTokenRe.ReplaceAllStringFunc(template, func(token string) string {
+		m := organiseTokenRe.FindStringSubmatch(token)
+		value := ""
+		switch m[1] {
+		case "site":
+			value = scene.Site
+		case "studio":
+			value = scene.Studio
+		case "title":
+			value = scene.Title
+		case "cast":
+			value = strings.Join(cast, ", ")
+		case "scene_id":
+			value = scene.SceneID
+		case "release_date":
+			layout := m[2]
+			if layout == "" {
+				layout = "2006-01-02"
+			}
+			if !scene.ReleaseDate.IsZero() && scene.ReleaseDate.Year() > 1900 {
+				value = scene.ReleaseDate.Format(layout)
+			}
+		case "projection":
+			value = file.VideoProjection
+		case "width":
+			if file.VideoWidth > 0 {
+				value = strconv.Itoa(file.VideoWidth)
+			}
+		case "height":
+			if file.VideoHeight > 0 {
+				value = strconv.Itoa(file.VideoHeight)
+			}
+		case "filename":
+			value = stem
+		case "ext":
+			return strings.TrimPrefix(ext, ".")
+		}
+		value = strings.ReplaceAll(value, "/", "-")
+		value = organiseIllegalRe.ReplaceAllString(value, "")
+		return value
+	})
+
+	var segments []string
+	parts := strings.Split(rendered, "/")
+	for i, part := range parts {
+		last := i == len(parts)-1
+		part = organiseEmptyGroupRe.ReplaceAllString(part, "")
+		part = organiseSpacesRe.ReplaceAllString(part, " ")
+		part = organiseLeadingRe.ReplaceAllString(part, "")
+		if last {
+			part = organiseTrailingRe.ReplaceAllString(part, "$2")
+			part = strings.ReplaceAll(part, " .", ".")
+		} else {
+			part = organiseTrailingRe.ReplaceAllString(part, "")
+		}
+		// windows doesn't allow folders and files ending with a dot or space
+		part = strings.Trim(part, " .")
+		if last {
+			part = strings.TrimSpace(part)
+			partExt := filepath.Ext(part)
+			partStem := strings.TrimSuffix(part, partExt)
+			if strings.Trim(partStem, " .") == "" {
+				return "", errors.New("template renders an empty filename")
+			}
+			part = truncateOrganiseSegment(partStem, organiseMaxSegment-len(partExt)) + partExt
+		} else {
+			part = truncateOrganiseSegment(part, organiseMaxSegment)
+		}
+		if part == "" || part == "." || part == ".." {
+			continue
+		}
+		segments = append(segments, part)
+	}
+	return filepath.Join(segments...), nil
+}
+
+func truncateOrganiseSegment(s string, max int) string {
+	if len(s) <= max {
+		return s
+	}
+	s = s[:max]
+	for !utf8.ValidString(s) {
+		s = s[:len(s)-1]
+	}
+	return strings.TrimSpace(s)
+}
+
+// PlanOrganise works out where matched video files on local volumes should be moved to. An
+// empty list of file ids plans all matched files.
+func PlanOrganise(db *gorm.DB, template string, fileIDs []uint) ([]OrganiseMove, error) {
+	if err := ValidateOrganiseTemplate(template); err != nil {
+		return nil, err
+	}
+
+	var files []models.File
+	tx := db.Preload("Volume").
+		Joins("join volumes on volumes.id = files.volume_id and volumes.type = 'local'").
+		Where("files.type = ? and files.scene_id <> 0", "video")
+	if len(fileIDs) > 0 {
+		tx = tx.Where("files.id in (?)", fileIDs)
+	}
+	tx.Order("files.id").Find(&files)
+
+	scenes := map[uint]models.Scene{}
+	planned := map[string]uint{}
+	allowedVideoExt := getAllowedVideoExt()
+	moves := []OrganiseMove{}
+
+	for _, file := range files {
+		scene, ok := scenes[file.SceneID]
+		if !ok {
+			db.Preload("Cast").Where("id = ?", file.SceneID).First(&scene)
+			scenes[file.SceneID] = scene
+		}
+
+		move := OrganiseMove{FileID: file.ID, SceneID: file.SceneID, From: file.GetPath(), Companions: []OrganiseCompanion{}}
+		rel, err := RenderOrganisePath(template, scene, file)
+		if err != nil {
+			move.Status = "conflict"
+			move.Reason = err.Error()
+			moves = append(moves, move)
+			continue
+		}
+		move.To = filepath.Join(file.Volume.Path, rel)
+
+		fromStem := strings.TrimSuffix(move.From, filepath.Ext(move.From))
+		toStem := strings.TrimSuffix(move.To, filepath.Ext(move.To))
+		for _, companion := range findCompanionFiles(move.From, allowedVideoExt) {
+			c := OrganiseCompanion{From: companion, To: toStem + strings.TrimPrefix(companion, fromStem)}
+			if !strings.HasPrefix(companion, fromStem) {
+				// poster.jpg and fanart.jpg of a video alone in its folder keep their names, they are
+				// left behind when the target folder has its own, the next nfo export writes them again
+				c.To = filepath.Join(filepath.Dir(move.To), filepath.Base(companion))
+				if _, err := os.Stat(c.To); err == nil {
+					continue
+				}
+			}
+			var fl models.File
+			db.Where(&models.File{Path: filepath.Dir(companion), Filename: filepath.Base(companion)}).First(&fl)
+			c.FileID = fl.ID
+			move.Companions = append(move.Companions, c)
+		}
+
+		switch {
+		case move.From == move.To:
+			move.Status = "unchanged"
+		case planned[strings.ToLower(move.To)] != 0:
+			move.Status = "conflict"
+			move.Reason = fmt.Sprintf("file %v is moved to the same path", planned[strings.ToLower(move.To)])
+		default:
+			move.Status = "move"
+			for _, target := range append([]string{move.To}, companionTargets(move.Companions)...) {
+				if reason := organiseTargetConflict(db, move.From, target); reason != "" {
+					move.Status = "conflict"
+					move.Reason = reason
+					break
+				}
+			}
+		}
+		if move.Status != "unchanged" && move.To != "" {
+			if _, ok := planned[strings.ToLower(move.To)]; !ok {
+				planned[strings.ToLower(move.To)] = file.ID
+			}
+		}
+		moves = append(moves, move)
+	}
+	return moves, nil
+}
+
+func companionTargets(companions []OrganiseCompanion) []string {
+	var targets []string
+	for _, c := range companions {
+		targets = append(targets, c.To)
+	}
+	return targets
+}
+
+// organiseTargetConflict returns why a file can't be moved to target, a file only differing in case is fine
+func organiseTargetConflict(db *gorm.DB, from string, target string) string {
+	if st, err := os.Stat(target); err == nil {
+		if src, err := os.Stat(from); err != nil || !os.SameFile(src, st) {
+			return fmt.Sprintf("%v already exists", target)
+		}
+	}
+	var fl models.File
+	if db.Where(&models.File{Path: filepath.Dir(target), Filename: filepath.Base(target)}).First(&fl).Error == nil {
+		if fl.GetPath() != from {
+			return fmt.Sprintf("%v is already in the library", target)
+		}
+	}
+	return ""
+}
+
+// findCompanionFiles lists the scripts, subtitles and nfo sidecars next to a video that share its name,
+// e.g. video.funscript and video_ai.funscript for video.mp4. The nfo images of a video alone in its folder
+// aren't named after the video, they are included as well.
+func findCompanionFiles(videoPath string, allowedVideoExt []string) []string {
+	dir := filepath.Dir(videoPath)
+	stem := strings.TrimSuffix(filepath.Base(videoPath), filepath.Ext(videoPath))
+	entries, err := os.ReadDir(dir)
+	if err != nil {
+		return nil
+	}
+
+	// names of other videos in the folder that start with this name, e.g. video_part2.mp4 for video.mp4
+	var longerStems []string
+	videos := 0
+	for _, e := range entries {
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		otherStem := strings.TrimSuffix(e.Name(), filepath.Ext(e.Name()))
+		for _, v := range allowedVideoExt {
+			if ext == v && !e.IsDir() {
+				videos++
+			}
+			if ext == v && len(otherStem) > len(stem) && strings.HasPrefix(otherStem, stem) {
+				longerStems = append(longerStems, otherStem)
+			}
+		}
+	}
+
+	var companions []string
+	for _, e := range entries {
+		if videos == 1 && !e.IsDir() && (strings.EqualFold(e.Name(), "poster.jpg") || strings.EqualFold(e.Name(), "fanart.jpg")) {
+			companions = append(companions, filepath.Join(dir, e.Name()))
+			continue
+		}
+		if e.IsDir() || !strings.HasPrefix(e.Name(), stem) {
+			continue
+		}
+		ext := strings.ToLower(filepath.Ext(e.Name()))
+		isCompanion := false
+		for _, c := range []string{".funscript", ".cmscript", ".hsp", ".srt", ".ssa", ".ass", ".nfo"} {
+			if ext == c {
+				isCompanion = true
+			}
+		}
+		// images written by the nfo export
+		rest := strings.ToLower(strings.TrimPrefix(e.Name(), stem))
+		if rest == "-poster.jpg" || rest == "-fanart.jpg" {
+			isCompanion = true
+		}
+		for _, other := range longerStems {
+			if strings.HasPrefix(e.Name(), other) {
+				isCompanion = false
+			}
+		}
+		if isCompanion {
+			companions = append(companions, filepath.Join(dir, e.Name()))
+		}
+	}
+	return companions
+}
+
+// OrganiseFiles plans and, unless dryRun is set, performs the moves. An empty template uses the configured one.
+func OrganiseFiles(template string, fileIDs []uint, dryRun bool) (OrganiseResult, error) {
+	if template == "" {
+		template = config.Config.Storage.OrganiseTemplate
+	}
+	if !dryRun {
+		if models.CheckLock("organise") {
+			return OrganiseResult{}, errors.New("the organiser is already running")
+		}
+		models.CreateLock("organise")
+		defer models.RemoveLock("organise")
+	}
+
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	tlog := log.WithFields(logrus.Fields{"task": "organise"})
+	return organiseFiles(db, template, fileIDs, dryRun, tlog)
+}
+
+func organiseFiles(db *gorm.DB, template string, fileIDs []uint, dryRun bool, tlog *logrus.Entry) (OrganiseResult, error) {
+	result := OrganiseResult{DryRun: dryRun}
+	moves, err := PlanOrganise(db, template, fileIDs)
+	if err != nil {
+		return result, err
+	}
+	result.Moves = moves
+	if dryRun {
+		return result, nil
+	}
+
+	result.BatchID = time.Now().Format("20060102-150405.000")
+	for i := range result.Moves {
+		if result.Moves[i].Status != "move" {
+			continue
+		}
+		if err := applyOrganiseMove(db, result.BatchID, &result.Moves[i]); err != nil {
+			result.Moves[i].Status = "failed"
+			result.Moves[i].Reason = err.Error()
+			tlog.Warnf("Can't move %v: %v", result.Moves[i].From, err)
+			continue
+		}
+		result.Moves[i].Status = "done"
+		tlog.Infof("Moved %v to %v", result.Moves[i].From, result.Moves[i].To)
+	}
+	common.PublishWS("state.change.optionsStorage", nil)
+	return result, nil
+}
+
+// applyOrganiseMove updates the file rows and undo log in a transaction that is only committed
+// when all files were renamed, renames are reverted when one of them fails
+func applyOrganiseMove(db *gorm.DB, batchID string, move *OrganiseMove) error {
+	type rename struct {
+		fileID uint
+		from   string
+		to     string
+	}
+	renames := []rename{{move.FileID, move.From, move.To}}
+	for _, c := range move.Companions {
+		renames = append(renames, rename{c.FileID, c.From, c.To})
+	}
+
+	var scene models.Scene
+	if err := db.Where("id = ?", move.SceneID).First(&scene).Error; err != nil {
+		return err
+	}
+	var filenames []string
+	json.Unmarshal([]byte(scene.FilenamesArr), &filenames)
+	filenames = append(filenames, filepath.Base(move.To))
+	tmp, _ := json.Marshal(filenames)
+	scene.FilenamesArr = string(tmp)
+
+	tx := db.Begin()
+	for _, r := range renames {
+		if r.fileID != 0 {
+			// the nfo images may have stayed behind, the next export writes them again
+			err := tx.Model(&models.File{}).Where("id = ?", r.fileID).
+				Updates(map[string]interface{}{"path": filepath.Dir(r.to), "filename": filepath.Base(r.to), "nfo_exported_at": time.Time{}}).Error
+			if err != nil {
+				tx.Rollback()
+				return err
+			}
+		}
+		action := models.OrganiseAction{BatchID: batchID, FileID: r.fileID, OldPath: r.from, NewPath: r.to}
+		if err := tx.Create(&action).Error; err != nil {
+			tx.Rollback()
+			return err
+		}
+	}
+	// keep the new name in the expected filenames, so the file still matches after a restore
+	if err := tx.Model(&models.Scene{}).Where("id = ?", scene.ID).Update("filenames_arr", scene.FilenamesArr).Error; err != nil {
+		tx.Rollback()
+		return err
+	}
+
+	if err := os.MkdirAll(filepath.Dir(move.To), os.ModePerm); err != nil {
+		tx.Rollback()
+		return err
+	}
+	for i, r := range renames {
+		if err := os.Rename(r.from, r.to); err != nil {
+			for j := i - 1; j >= 0; j-- {
+				os.Rename(renames[j].to, renames[j].from)
+			}
+			tx.Rollback()
+			return err
+		}
+	}
+	if err := tx.Commit().Error; err != nil {
+		for j := len(renames) - 1; j >= 0; j-- {
+			os.Rename(renames[j].to, renames[j].from)
+		}
+		return err
+	}
+
+	scene.UpdateFilenameIndex(db)
+	models.AddAction(scene.SceneID, "match", "filenames_arr", scene.FilenamesArr)
+	removeEmptyOrganiseDirs(db, filepath.Dir(move.From), move.FileID)
+	return nil
+}
+
+// removeEmptyOrganiseDirs removes folders left empty by a move, up to the root of the volume
+func removeEmptyOrganiseDirs(db *gorm.DB, dir string, fileID uint) {
+	var file models.File
+	if db.Preload("Volume").Where("id = ?", fileID).First(&file).Error != nil {
+		return
+	}
+	root := filepath.Clean(file.Volume.Path)
+	for dir = filepath.Clean(dir); dir != root && strings.HasPrefix(dir, root+string(filepath.Separator)); dir = filepath.Dir(dir) {
+		if os.Remove(dir) != nil {
+			return
+		}
+	}
+}
+
+// GetOrganiseBatches returns the undo log, newest run first
+func GetOrganiseBatches(db *gorm.DB) []models.OrganiseAction {
+	var actions []models.OrganiseAction
+	db.Order("id desc").Limit(1000).Find(&actions)
+	return actions
+}
+
+// UndoOrganise moves the files of an organiser run back to where they were
+func UndoOrganise(batchID string) error {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	var actions []models.OrganiseAction
+	db.Where("batch_id = ? and undone_at is null", batchID).Order("id desc").Find(&actions)
+	if len(actions) == 0 {
+		return fmt.Errorf("nothing to undo for %v", batchID)
+	}
+
+	var failed []string
+	for _, a := range actions {
+		if _, err := os.Stat(a.OldPath); err == nil {
+			failed = append(failed, fmt.Sprintf("%v already exists", a.OldPath))
+			continue
+		}
+		if err := os.MkdirAll(filepath.Dir(a.OldPath), os.ModePerm); err != nil {
+			failed = append(failed, err.Error())
+			continue
+		}
+
+		tx := db.Begin()
+		if a.FileID != 0 {
+			tx.Model(&models.File{}).Where("id = ?", a.FileID).
+				Updates(map[string]interface{}{"path": filepath.Dir(a.OldPath), "filename": filepath.Base(a.OldPath), "nfo_exported_at": time.Time{}})
+		}
+		now := time.Now()
+		tx.Model(&models.OrganiseAction{}).Where("id = ?", a.ID).Update("undone_at", &now)
+		if err := os.Rename(a.NewPath, a.OldPath); err != nil {
+			tx.Rollback()
+			failed = append(failed, err.Error())
+			continue
+		}
+		if err := tx.Commit().Error; err != nil {
+			os.Rename(a.OldPath, a.NewPath)
+			failed = append(failed, err.Error())
+			continue
+		}
+		if a.FileID != 0 {
+			removeEmptyOrganiseDirs(db, filepath.Dir(a.NewPath), a.FileID)
+		}
+	}
+	common.PublishWS("state.change.optionsStorage", nil)
+
+	if len(failed) > 0 {
+		return errors.New(strings.Join(failed, ", "))
+	}
+	return nil
+}
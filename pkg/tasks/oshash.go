@@ -4,6 +4,7 @@ import (
 	"bytes"
 	"encoding/binary"
 	"fmt"
+	"io"
 	"os"
 )
 
@@ -18,7 +19,12 @@ func HashFile(file *os.File) (hash uint64, err error) {
 	if err != nil {
 		return
 	}
-	if fi.Size() < ChunkSize {
+	return HashReader(file, fi.Size())
+}
+
+// HashReader generates an OSDB hash for any seekable stream of a known size, such as a file on a network volume.
+func HashReader(file io.ReadSeeker, size int64) (hash uint64, err error) {
+	if size < ChunkSize {
 		return 0, fmt.Errorf("file is too small")
 	}
 
@@ -28,7 +34,7 @@ func HashFile(file *os.File) (hash uint64, err error) {
 	if err != nil {
 		return
 	}
-	err = readChunk(file, fi.Size()-ChunkSize, buf[ChunkSize:])
+	err = readChunk(file, size-ChunkSize, buf[ChunkSize:])
 	if err != nil {
 		return
 	}
@@ -44,7 +50,7 @@ func HashFile(file *os.File) (hash uint64, err error) {
 		hash += num
 	}
 
-	return hash + uint64(fi.Size()), nil
+	return hash + uint64(size), nil
 }
 
 // Hash generates an OSDB hash for a file.
@@ -58,8 +64,12 @@ func Hash(path string) (uint64, error) {
 }
 
 // Read a chunk of a file at `offset` so as to fill `buf`.
-func readChunk(file *os.File, offset int64, buf []byte) (err error) {
-	n, err := file.ReadAt(buf, offset)
+func readChunk(file io.ReadSeeker, offset int64, buf []byte) (err error) {
+	_, err = file.Seek(offset, io.SeekStart)
+	if err != nil {
+		return
+	}
+	n, err := io.ReadFull(file, buf)
 	if err != nil {
 		return
 	}
@@ -0,0 +1,127 @@
+package tasks
+
+import (
+	"bytes"
+	"fmt"
+	"image"
+	"image/draw"
+	"image/png"
+	"strconv"
+	"time"
+
+	"github.com/corona10/goimagehash"
+	"github.com/sirupsen/logrus"
+	"github.com/xbapps/xbvr/pkg/config"
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+// The sprite layout matches the perceptual hash used by Stash and StashDB, so hashes can be
+// used to look up scenes on StashDB as well as to find duplicates in the library
+const (
+	phashFrameWidth = 160
+	phashColumns    = 5
+	phashRows       = 5
+)
+
+func GeneratePhashes(endTime *time.Time) {
+	if !models.CheckLock("phash") {
+		models.CreateLock("phash")
+		defer models.RemoveLock("phash")
+
+		tlog := log.WithFields(logrus.Fields{"task": "phash"})
+		tlog.Infof("Generating perceptual hashes")
+		db, _ := models.GetDB()
+		defer db.Close()
+
+		var files []models.File
+		db.Preload("Volume").
+			Joins("join volumes on volumes.id = files.volume_id and volumes.type in ('local', 's3')").
+			Where("files.type = ? and files.phash = '' and files.video_duration > 0", "video").
+			Order("files.created_time desc").
+			Find(&files)
+
+		for i := range files {
+			if endTime != nil && time.Now().After(*endTime) {
+				return
+			}
+			file := files[i]
+			if !file.Exists() {
+				continue
+			}
+			tlog.Infof("Generating perceptual hash for %v (%v/%v)", file.Filename, i+1, len(files))
+			hash, err := GeneratePhash(&file)
+			if err != nil {
+				tlog.Warnf("Can't generate perceptual hash for %v: %v", file.Filename, err)
+				continue
+			}
+			file.Phash = hash
+			db.Model(&models.File{}).Where("id = ?", file.ID).Update("phash", hash)
+
+			if file.SceneID == 0 && config.Config.Storage.MatchOhash && config.Config.Advanced.StashApiKey != "" {
+				matchFileOnStashFingerprint(db, &file, hash)
+			}
+		}
+		tlog.Infof("Perceptual hashes generated")
+	}
+}
+
+// GeneratePhash builds a sprite of frames spread over the video and returns its perceptual hash, hex encoded
+func GeneratePhash(file *models.File) (string, error) {
+	input := file.GetPath()
+	if file.Volume.Type == "s3" {
+		u, err := file.GetS3PresignedURL()
+		if err != nil {
+			return "", err
+		}
+		input = u.String()
+	}
+
+	duration := file.VideoDuration
+	if duration <= 0 {
+		return "", fmt.Errorf("unknown duration")
+	}
+	offset := 0.05 * duration
+	step := (0.9 * duration) / float64(phashColumns*phashRows)
+
+	var sprite *image.RGBA
+	for i := 0; i < phashColumns*phashRows; i++ {
+		frame, err := extractPhashFrame(input, offset+float64(i)*step)
+		if err != nil {
+			return "", err
+		}
+		w := frame.Bounds().Dx()
+		h := frame.Bounds().Dy()
+		if sprite == nil {
+			sprite = image.NewRGBA(image.Rect(0, 0, w*phashColumns, h*phashRows))
+		}
+		x := (i % phashColumns) * w
+		y := (i / phashColumns) * h
+		draw.Draw(sprite, image.Rect(x, y, x+w, y+h), frame, frame.Bounds().Min, draw.Src)
+	}
+
+	hash, err := goimagehash.PerceptionHash(sprite)
+	if err != nil {
+		return "", err
+	}
+	return strconv.FormatUint(hash.GetHash(), 16), nil
+}
+
+func extractPhashFrame(input string, position float64) (image.Image, error) {
+	args := []string{
+		"-v", "error",
+		"-ss", fmt.Sprintf("%.3f", position),
+		"-i", input,
+		"-frames:v", "1",
+		"-vf", fmt.Sprintf("scale=%v:-2", phashFrameWidth),
+		"-c:v", "png",
+		"-f", "image2pipe",
+		"-",
+	}
+	cmd := buildCmd(GetBinPath("ffmpeg"), args...)
+	var out bytes.Buffer
+	cmd.Stdout = &out
+	if err := cmd.Run(); err != nil {
+		return nil, err
+	}
+	return png.Decode(&out)
+}
@@ -0,0 +1,273 @@
+package tasks
+
+import (
+	"math"
+	"sort"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+// Scenes are compared by feature vectors of their tags, cast, site and duration. Features shared by many scenes
+// say less about a scene, so features are weighted by their inverse document frequency. The vectors are built
+// from the whole library and kept for a while, recommendations compare them with a profile of the scenes the
+// user rated, favourited and watched.
+const (
+	recommendCacheTTL = 10 * time.Minute
+
+	recommendWeightCast     = 2.0
+	recommendWeightTag      = 1.0
+	recommendWeightSite     = 0.5
+	recommendWeightDuration = 0.3
+)
+
+type sceneVector map[string]float64
+
+type recommendData struct {
+	built   time.Time
+	vectors map[uint]sceneVector
+}
+
+var (
+	recommendMutex sync.Mutex
+	recommendCache *recommendData
+)
+
+// SimilarScenes returns the scenes most like a scene, with their similarity as score
+func SimilarScenes(sceneID uint, limit int, onlyAvailable bool) []models.Scene {
+	data := getRecommendData()
+	vector, ok := data.vectors[sceneID]
+	if !ok {
+		return []models.Scene{}
+	}
+	return rankScenes(data, vector, limit, onlyAvailable, map[uint]bool{sceneID: true})
+}
+
+// RecommendedScenes returns unwatched scenes like the scenes the user rated well, favourited and watched
+func RecommendedScenes(limit int, onlyAvailable bool) []models.Scene {
+	data := getRecommendData()
+
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	type interest struct {
+		ID             uint
+		StarRating     float64
+		Favourite      bool
+		IsWatched      bool
+		TotalWatchTime int
+		Plays          int
+	}
+	var interests []interest
+	db.Raw(`select scenes.id, scenes.star_rating, scenes.favourite, scenes.is_watched, scenes.total_watch_time,
+			(select count(*) from histories where histories.scene_id = scenes.id) as plays
+		from scenes
+		where scenes.star_rating > 0 or scenes.favourite = ? or scenes.is_watched = ? or scenes.total_watch_time > 0`, true, true).
+		Scan(&interests)
+
+	profile := sceneVector{}
+	exclude := map[uint]bool{}
+	for _, in := range interests {
+		exclude[in.ID] = true
+
+		// ratings below the middle of the scale count against similar scenes
+		weight := 0.0
+		if in.StarRating > 0 {
+			weight += (in.StarRating - 2.5) / 2.5
+		}
+		if in.Favourite {
+			weight += 1
+		}
+		if in.TotalWatchTime > 0 {
+			weight += math.Min(float64(in.TotalWatchTime)/1800, 1) * 0.5
+		} else if in.IsWatched {
+			weight += 0.25
+		}
+		weight += math.Min(float64(in.Plays), 5) * 0.1
+
+		for feature, value := range data.vectors[in.ID] {
+			profile[feature] += weight * value
+		}
+	}
+	normalizeVector(profile)
+	if len(profile) == 0 {
+		return []models.Scene{}
+	}
+	return rankScenes(data, profile, limit, onlyAvailable, exclude)
+}
+
+func rankScenes(data *recommendData, vector sceneVector, limit int, onlyAvailable bool, exclude map[uint]bool) []models.Scene {
+	type ranked struct {
+		id    uint
+		score float64
+	}
+	var candidates []ranked
+	for id, v := range data.vectors {
+		if exclude[id] {
+			continue
+		}
+		if score := cosineSimilarity(vector, v); score > 0 {
+			candidates = append(candidates, ranked{id, score})
+		}
+	}
+	sort.Slice(candidates, func(i, j int) bool {
+		if candidates[i].score == candidates[j].score {
+			return candidates[i].id < candidates[j].id
+		}
+		return candidates[i].score > candidates[j].score
+	})
+
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	// availability changes more often than the vectors, so it is checked when ranking
+	unavailable := map[uint]bool{}
+	if onlyAvailable {
+		var ids []uint
+		db.Model(&models.Scene{}).Where("is_available = ? or is_accessible = ?", false, false).Pluck("id", &ids)
+		for _, id := range ids {
+			unavailable[id] = true
+		}
+	}
+
+	var ids []uint
+	scores := map[uint]float64{}
+	for _, c := range candidates {
+		if len(ids) == limit {
+			break
+		}
+		if unavailable[c.id] {
+			continue
+		}
+		ids = append(ids, c.id)
+		scores[c.id] = c.score
+	}
+	if len(ids) == 0 {
+		return []models.Scene{}
+	}
+
+	var scenes []models.Scene
+	db.Preload("Cast").Preload("Tags").Preload("Files").Preload("History").Preload("Cuepoints").
+		Where("id in (?)", ids).Find(&scenes)
+	for i := range scenes {
+		scenes[i].Score = scores[scenes[i].ID]
+	}
+	sort.Slice(scenes, func(i, j int) bool { return scenes[i].Score > scenes[j].Score })
+	return scenes
+}
+
+func getRecommendData() *recommendData {
+	recommendMutex.Lock()
+	defer recommendMutex.Unlock()
+
+	if recommendCache != nil && time.Since(recommendCache.built) < recommendCacheTTL {
+		return recommendCache
+	}
+	recommendCache = buildRecommendData()
+	return recommendCache
+}
+
+func buildRecommendData() *recommendData {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	type sceneRow struct {
+		ID       uint
+		Site     string
+		Duration int
+	}
+	var scenes []sceneRow
+	db.Model(&models.Scene{}).Where("is_hidden = ?", false).Select("id, site, duration").Scan(&scenes)
+
+	type relation struct {
+		SceneID uint
+		ID      uint
+	}
+	var tags []relation
+	db.Table("scene_tags").Select("scene_id, tag_id as id").Scan(&tags)
+	var cast []relation
+	db.Table("scene_cast").Joins("join actors on actors.id = scene_cast.actor_id").
+		Where("actors.name not like 'aka:%'").Select("scene_cast.scene_id, scene_cast.actor_id as id").Scan(&cast)
+
+	vectors := make(map[uint]sceneVector, len(scenes))
+	for _, s := range scenes {
+		v := sceneVector{}
+		if s.Site != "" {
+			v["s:"+s.Site] = recommendWeightSite
+		}
+		if s.Duration > 0 {
+			v["d:"+durationBucket(s.Duration)] = recommendWeightDuration
+		}
+		vectors[s.ID] = v
+	}
+	for _, t := range tags {
+		if v, ok := vectors[t.SceneID]; ok {
+			v["t:"+strconv.Itoa(int(t.ID))] = recommendWeightTag
+		}
+	}
+	for _, c := range cast {
+		if v, ok := vectors[c.SceneID]; ok {
+			v["c:"+strconv.Itoa(int(c.ID))] = recommendWeightCast
+		}
+	}
+
+	counts := map[string]int{}
+	for _, v := range vectors {
+		for feature := range v {
+			counts[feature]++
+		}
+	}
+	total := float64(len(vectors))
+	for _, v := range vectors {
+		for feature := range v {
+			v[feature] *= math.Log(1 + total/float64(counts[feature]))
+		}
+		normalizeVector(v)
+	}
+
+	return &recommendData{built: time.Now(), vectors: vectors}
+}
+
+// durationBucket groups scene durations in minutes, so scenes of about the same length share a feature
+func durationBucket(minutes int) string {
+	switch {
+	case minutes < 20:
+		return "short"
+	case minutes < 40:
+		return "medium"
+	case minutes < 60:
+		return "long"
+	}
+	return "very-long"
+}
+
+func normalizeVector(v sceneVector) {
+	length := 0.0
+	for _, value := range v {
+		length += value * value
+	}
+	if length == 0 {
+		for feature := range v {
+			delete(v, feature)
+		}
+		return
+	}
+	length = math.Sqrt(length)
+	for feature := range v {
+		v[feature] /= length
+	}
+}
+
+// cosineSimilarity of two normalized vectors
+func cosineSimilarity(a sceneVector, b sceneVector) float64 {
+	if len(a) > len(b) {
+		a, b = b, a
+	}
+	score := 0.0
+	for feature, value := range a {
+		score += value * b[feature]
+	}
+	return score
+}
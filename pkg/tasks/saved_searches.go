@@ -0,0 +1,81 @@
+package tasks
+
+import (
+	"encoding/json"
+	"sort"
+	"strconv"
+	"sync"
+
+	"github.com/markphelps/optional"
+	"github.com/xbapps/xbvr/pkg/common"
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+var savedSearchMutex sync.Mutex
+
+// CheckSavedSearches compares the scenes matching subscribed saved searches with the scenes they matched when last
+// checked, and notifies about the scenes that started matching. Saved searches that weren't checked since they were
+// subscribed or changed only record their scenes.
+func CheckSavedSearches() {
+	savedSearchMutex.Lock()
+	defer savedSearchMutex.Unlock()
+
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	tlog := log.WithField("task", "savedsearch")
+
+	var playlists []models.Playlist
+	db.Where("playlist_type = ? and is_smart = ? and is_subscribed = ?", "scene", true, true).Find(&playlists)
+
+	for _, playlist := range playlists {
+		var r models.RequestSceneList
+		if err := json.Unmarshal([]byte(playlist.SearchParams), &r); err != nil {
+			tlog.Warnf("Can't read the search of %s: %v", playlist.Name, err)
+			continue
+		}
+		r.Limit = optional.Int{}
+		r.Offset = optional.Int{}
+
+		matching := map[uint]bool{}
+		for _, id := range models.QuerySceneIDs(r) {
+			if sceneID, err := strconv.Atoi(id); err == nil {
+				matching[uint(sceneID)] = true
+			}
+		}
+
+		var added, removed []uint
+		seen := map[uint]bool{}
+		for _, id := range models.GetSavedSearchSceneIDs(db, playlist.ID) {
+			seen[id] = true
+			if !matching[id] {
+				removed = append(removed, id)
+			}
+		}
+		for id := range matching {
+			if !seen[id] {
+				added = append(added, id)
+			}
+		}
+
+		if err := models.UpdateSavedSearchScenes(db, playlist.ID, added, removed); err != nil {
+			tlog.Errorf("Can't update the scenes of %s: %v", playlist.Name, err)
+			continue
+		}
+		if playlist.CheckedAt == nil || len(added) == 0 {
+			continue
+		}
+
+		sort.Slice(added, func(i, j int) bool { return added[i] < added[j] })
+		for _, id := range added {
+			db.Create(&models.SavedSearchNotification{PlaylistID: playlist.ID, SceneID: id})
+		}
+		tlog.Infof("%v new scenes match %s", len(added), playlist.Name)
+		common.PublishWS("notification.savedsearch", map[string]interface{}{
+			"playlist_id":   playlist.ID,
+			"playlist_name": playlist.Name,
+			"scene_ids":     added,
+			"unread":        models.CountUnreadSavedSearchNotifications(db),
+		})
+	}
+}
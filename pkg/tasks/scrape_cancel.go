@@ -0,0 +1,62 @@
+package tasks
+
+import (
+	"context"
+	"sync"
+)
+
+var (
+	scrapeCancelMutex sync.Mutex
+	scrapeRunCancel   context.CancelFunc
+	scrapeSiteCancels = map[string]context.CancelFunc{}
+)
+
+// startScrapeRun returns the context of a new scrape run, which CancelScrape("") cancels
+func startScrapeRun() (context.Context, context.CancelFunc) {
+	ctx, cancel := context.WithCancel(context.Background())
+	scrapeCancelMutex.Lock()
+	scrapeRunCancel = cancel
+	scrapeCancelMutex.Unlock()
+	return ctx, func() {
+		scrapeCancelMutex.Lock()
+		scrapeRunCancel = nil
+		scrapeCancelMutex.Unlock()
+		cancel()
+	}
+}
+
+// startSiteScrape returns the context of one site of a scrape run, which CancelScrape(site) cancels
+func startSiteScrape(ctx context.Context, site string) (context.Context, context.CancelFunc) {
+	siteCtx, cancel := context.WithCancel(ctx)
+	scrapeCancelMutex.Lock()
+	scrapeSiteCancels[site] = cancel
+	scrapeCancelMutex.Unlock()
+	return siteCtx, func() {
+		scrapeCancelMutex.Lock()
+		delete(scrapeSiteCancels, site)
+		scrapeCancelMutex.Unlock()
+		cancel()
+	}
+}
+
+// CancelScrape cancels the running scrape of a site, or the whole run when site is empty. Scenes already
+// scraped are still saved. It returns false when nothing was scraping.
+func CancelScrape(site string) bool {
+	scrapeCancelMutex.Lock()
+	defer scrapeCancelMutex.Unlock()
+	if site == "" {
+		if scrapeRunCancel == nil {
+			return false
+		}
+		log.WithField("task", "scrape").Info("Cancelling scrape")
+		scrapeRunCancel()
+		return true
+	}
+	cancel, ok := scrapeSiteCancels[site]
+	if !ok {
+		return false
+	}
+	log.WithField("task", "scrape").Infof("Cancelling scrape of %v", site)
+	cancel()
+	return true
+}
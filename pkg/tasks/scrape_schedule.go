@@ -0,0 +1,123 @@
+package tasks
+
+import (
+	"errors"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/robfig/cron/v3"
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+// Schedule modes of a site. Sites without a mode follow the global rescrape schedule.
+const (
+	ScheduleModeGlobal   = ""
+	ScheduleModeCron     = "cron"
+	ScheduleModeDays     = "days"
+	ScheduleModeAdaptive = "adaptive"
+	ScheduleModeOff      = "off"
+)
+
+const (
+	adaptiveBaseInterval = 24 * time.Hour
+	adaptiveMaxInterval  = 30 * 24 * time.Hour
+	adaptiveRuns         = 6
+)
+
+// ValidateSiteSchedule checks the schedule settings of a site
+func ValidateSiteSchedule(mode string, cronSpec string, days int) error {
+	switch mode {
+	case ScheduleModeGlobal, ScheduleModeAdaptive, ScheduleModeOff:
+		return nil
+	case ScheduleModeCron:
+		_, err := cron.ParseStandard(cronSpec)
+		return err
+	case ScheduleModeDays:
+		if days < 1 {
+			return errors.New("the days between scrapes must be at least 1")
+		}
+		return nil
+	}
+	return errors.New("unknown schedule mode " + mode)
+}
+
+// SiteNextScrape returns when a site with its own schedule is due, based on its last run. It returns nil for
+// sites following the global schedule or not scheduled at all.
+func SiteNextScrape(db *gorm.DB, site models.Site, lastRun *models.ScraperRun) *time.Time {
+	last := site.LastUpdate
+	if lastRun != nil && lastRun.StartedAt.After(last) {
+		last = lastRun.StartedAt
+	}
+
+	var next time.Time
+	switch site.ScheduleMode {
+	case ScheduleModeCron:
+		schedule, err := cron.ParseStandard(site.ScheduleCron)
+		if err != nil {
+			return nil
+		}
+		if last.IsZero() {
+			// a site that was never scraped is due right away, as in the other modes
+			return &last
+		}
+		next = schedule.Next(last)
+	case ScheduleModeDays:
+		next = last.Add(time.Duration(site.ScheduleDays) * 24 * time.Hour)
+	case ScheduleModeAdaptive:
+		next = last.Add(adaptiveInterval(db, site.ID))
+	default:
+		return nil
+	}
+	return &next
+}
+
+// adaptiveInterval doubles the time between scrapes for each of the last runs that found no new scenes
+func adaptiveInterval(db *gorm.DB, siteID string) time.Duration {
+	var runs []models.ScraperRun
+	db.Where("site_id = ? and error = ''", siteID).Order("id desc").Limit(adaptiveRuns).Find(&runs)
+
+	interval := adaptiveBaseInterval
+	for _, run := range runs {
+		if run.NewScenes > 0 {
+			break
+		}
+		interval *= 2
+	}
+	if interval > adaptiveMaxInterval {
+		interval = adaptiveMaxInterval
+	}
+	return interval
+}
+
+// DueScheduledSites returns the enabled sites with their own schedule that are due for a scrape
+func DueScheduledSites() []string {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	var sites []models.Site
+	db.Where("is_enabled = ? and schedule_mode in (?)", true, []string{ScheduleModeCron, ScheduleModeDays, ScheduleModeAdaptive}).Find(&sites)
+	latestRuns := models.GetLatestScraperRuns(db)
+
+	due := []string{}
+	now := time.Now()
+	for _, site := range sites {
+		var lastRun *models.ScraperRun
+		if run, ok := latestRuns[site.ID]; ok {
+			lastRun = &run
+		}
+		if next := SiteNextScrape(db, site, lastRun); next != nil && !next.After(now) {
+			due = append(due, site.ID)
+		}
+	}
+	return due
+}
+
+// GlobalScheduleSites returns the enabled sites following the global rescrape schedule
+func GlobalScheduleSites() []string {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	var ids []string
+	db.Model(&models.Site{}).Where("is_enabled = ? and schedule_mode = ?", true, ScheduleModeGlobal).Pluck("id", &ids)
+	return ids
+}
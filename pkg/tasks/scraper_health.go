@@ -0,0 +1,222 @@
+package tasks
+
+import (
+	"encoding/json"
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/xbapps/xbvr/pkg/models"
+	"github.com/xbapps/xbvr/pkg/scrape"
+)
+
+const (
+	scraperBaselineRuns    = 10
+	scraperBaselineMinRuns = 3
+)
+
+// scraperRunStats collects the stats of the scrapers started by one scrape. A nil value records nothing.
+type scraperRunStats struct {
+	mu   sync.Mutex
+	runs map[string]*models.ScraperRun
+}
+
+func newScraperRunStats() *scraperRunStats {
+	return &scraperRunStats{runs: map[string]*models.ScraperRun{}}
+}
+
+func (s *scraperRunStats) start(scraper models.Scraper, limitScraping bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	s.runs[scraper.ID] = &models.ScraperRun{SiteID: scraper.ID, StartedAt: time.Now(), LimitScraping: limitScraping}
+	s.mu.Unlock()
+	scrape.StartScraperRunStats(scraper.ID)
+}
+
+func (s *scraperRunStats) finish(id string, err error) {
+	if s == nil {
+		return
+	}
+	requests := scrape.FinishScraperRunStats(id)
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run, ok := s.runs[id]
+	if !ok {
+		return
+	}
+	run.DurationSeconds = math.Round(time.Since(run.StartedAt).Seconds())
+	run.Pages = requests.Pages
+	statuses := map[string]int{}
+	for status, count := range requests.Statuses {
+		if status == 0 || status >= 400 {
+			run.HttpErrors += count
+		}
+		statuses[fmt.Sprint(status)] = count
+	}
+	tmp, _ := json.Marshal(statuses)
+	run.HttpStatuses = string(tmp)
+	if err != nil {
+		run.Error = err.Error()
+	}
+}
+
+// addScene counts a scraped scene for its site. isNew is only known for scenes of the site itself, scenes
+// for other sites count as neither new nor updated.
+func (s *scraperRunStats) addScene(scene models.ScrapedScene, isNew bool) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	run, ok := s.runs[scene.ScraperID]
+	if !ok {
+		return
+	}
+	run.Scenes++
+	if scene.MasterSiteId == "" {
+		if isNew {
+			run.NewScenes++
+		} else {
+			run.UpdatedScenes++
+		}
+	}
+	if strings.TrimSpace(scene.Title) == "" {
+		run.MissingTitle++
+	}
+	if len(scene.Covers) == 0 {
+		run.MissingCover++
+	}
+	if len(scene.Cast) == 0 {
+		run.MissingCast++
+	}
+	if len(scene.Tags) == 0 {
+		run.MissingTags++
+	}
+	if scene.Duration == 0 {
+		run.MissingDuration++
+	}
+	if scene.Released == "" {
+		run.MissingReleased++
+	}
+	if strings.TrimSpace(scene.Synopsis) == "" {
+		run.MissingSynopsis++
+	}
+}
+
+// save compares the runs with earlier runs of their sites and stores them. It has to be called once all
+// scraped scenes have been written.
+func (s *scraperRunStats) save(db *gorm.DB) {
+	if s == nil {
+		return
+	}
+	s.mu.Lock()
+	defer s.mu.Unlock()
+	for _, run := range s.runs {
+		anomalies := DetectScraperAnomalies(db, *run)
+		tmp, _ := json.Marshal(anomalies)
+		run.Anomalies = string(tmp)
+		db.Create(run)
+		for _, a := range anomalies {
+			log.WithField("task", "scrape").Warnf("%v %v", run.SiteID, a)
+		}
+	}
+}
+
+// DetectScraperAnomalies compares a run with the last successful runs of the site with the same limit setting
+func DetectScraperAnomalies(db *gorm.DB, run models.ScraperRun) []string {
+	anomalies := []string{}
+	if run.Error != "" {
+		anomalies = append(anomalies, "failed: "+run.Error)
+	}
+	if run.Pages > 0 && run.HttpErrors == run.Pages {
+		anomalies = append(anomalies, fmt.Sprintf("all %v requests failed (%v)", run.Pages, httpStatusSummary(run.HttpStatuses)))
+	}
+
+	var baseline []models.ScraperRun
+	db.Where("site_id = ? and limit_scraping = ? and error = ''", run.SiteID, run.LimitScraping).
+		Order("id desc").Limit(scraperBaselineRuns).Find(&baseline)
+	if len(baseline) < scraperBaselineMinRuns {
+		return anomalies
+	}
+
+	var sceneCounts []int
+	for _, b := range baseline {
+		sceneCounts = append(sceneCounts, b.Scenes)
+	}
+	sort.Ints(sceneCounts)
+	median := sceneCounts[len(sceneCounts)/2]
+	if run.Scenes == 0 && median > 0 {
+		anomalies = append(anomalies, fmt.Sprintf("returned 0 scenes, previously %v", median))
+	} else if median >= 8 && run.Scenes*4 < median {
+		anomalies = append(anomalies, fmt.Sprintf("returned %v scenes, previously %v", run.Scenes, median))
+	}
+
+	var baselinePages, baselineErrors int
+	for _, b := range baseline {
+		baselinePages += b.Pages
+		baselineErrors += b.HttpErrors
+	}
+	if run.Pages >= 4 && run.HttpErrors < run.Pages && baselinePages > 0 {
+		rate := float64(run.HttpErrors) / float64(run.Pages)
+		previous := float64(baselineErrors) / float64(baselinePages)
+		if rate >= 0.5 && previous < 0.1 {
+			anomalies = append(anomalies, fmt.Sprintf("%v%% of requests failed, previously %v%%", math.Round(rate*100), math.Round(previous*100)))
+		}
+	}
+
+	if run.Scenes < 3 {
+		return anomalies
+	}
+	fields := []struct {
+		name    string
+		missing func(models.ScraperRun) int
+	}{
+		{"title", func(r models.ScraperRun) int { return r.MissingTitle }},
+		{"cover", func(r models.ScraperRun) int { return r.MissingCover }},
+		{"cast", func(r models.ScraperRun) int { return r.MissingCast }},
+		{"tags", func(r models.ScraperRun) int { return r.MissingTags }},
+		{"duration", func(r models.ScraperRun) int { return r.MissingDuration }},
+		{"release date", func(r models.ScraperRun) int { return r.MissingReleased }},
+		{"synopsis", func(r models.ScraperRun) int { return r.MissingSynopsis }},
+	}
+	for _, f := range fields {
+		var scenes, missing int
+		for _, b := range baseline {
+			scenes += b.Scenes
+			missing += f.missing(b)
+		}
+		if scenes == 0 {
+			continue
+		}
+		rate := float64(f.missing(run)) / float64(run.Scenes)
+		previous := float64(missing) / float64(scenes)
+		switch {
+		case rate == 1 && previous < 0.5:
+			anomalies = append(anomalies, fmt.Sprintf("%v missing on 100%% of scenes", f.name))
+		case rate >= 0.5 && previous < 0.1:
+			anomalies = append(anomalies, fmt.Sprintf("%v missing on %v%% of scenes, previously %v%%", f.name, math.Round(rate*100), math.Round(previous*100)))
+		}
+	}
+	return anomalies
+}
+
+func httpStatusSummary(statuses string) string {
+	var counts map[string]int
+	json.Unmarshal([]byte(statuses), &counts)
+	var parts []string
+	for status, count := range counts {
+		if status == "0" {
+			parts = append(parts, fmt.Sprintf("%v without response", count))
+		} else {
+			parts = append(parts, fmt.Sprintf("%v HTTP %v", count, status))
+		}
+	}
+	sort.Strings(parts)
+	return strings.Join(parts, ", ")
+}
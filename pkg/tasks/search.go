@@ -1,10 +1,15 @@
 package tasks
 
 import (
+	"encoding/json"
+	"errors"
 	"fmt"
 	"path/filepath"
 	"runtime/debug"
+	"strconv"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"time"
 
 	"github.com/blevesearch/bleve/v2"
@@ -17,18 +22,43 @@ import (
 )
 
 type Index struct {
-	Bleve bleve.Index
+	Bleve     bleve.Index
+	tagGroups map[uint][]string
 }
 
 type SceneIndexed struct {
 	Description string    `json:"description"`
 	Title       string    `json:"title"`
 	Cast        string    `json:"cast"`
+	Aliases     string    `json:"aliases"`
 	Site        string    `json:"site"`
+	Studio      string    `json:"studio"`
+	Tags        string    `json:"tags"`
+	TagGroups   string    `json:"tag_groups"`
+	Cuepoints   string    `json:"cuepoints"`
+	Files       string    `json:"files"`
+	Resolution  string    `json:"resolution"`
+	Projection  string    `json:"projection"`
 	Id          string    `json:"id"`
 	Released    time.Time `json:"released"`
 	Added       time.Time `json:"added"`
 	Duration    int       `json:"duration"`
+
+	// untokenized copies of values for facets
+	FacetSite       string   `json:"facet_site"`
+	FacetTag        []string `json:"facet_tag"`
+	FacetCast       []string `json:"facet_cast"`
+	FacetYear       string   `json:"facet_year"`
+	FacetResolution []string `json:"facet_resolution"`
+}
+
+// SearchFacets are the facets returned by scene searches, mapped to their index field
+var SearchFacets = map[string]string{
+	"site":       "facet_site",
+	"tag":        "facet_tag",
+	"cast":       "facet_cast",
+	"year":       "facet_year",
+	"resolution": "facet_resolution",
 }
 
 func NewIndex(name string) (*Index, error) {
@@ -48,10 +78,19 @@ func NewIndex(name string) (*Index, error) {
 	sceneMapping := bleve.NewDocumentMapping()
 	sceneMapping.AddFieldMappingsAt("title", titleFieldMapping)
 	sceneMapping.AddFieldMappingsAt("cast", castFieldMapping)
+	sceneMapping.AddFieldMappingsAt("aliases", castFieldMapping)
 	sceneMapping.AddFieldMappingsAt("released", releaseFieldMapping)
 	sceneMapping.AddFieldMappingsAt("added", addedFieldMapping)
 	sceneMapping.AddFieldMappingsAt("duration", durationFieldMapping)
 
+	// facet fields keep values whole and are only searched when named, eg facet_tag:"Blowjob"
+	for _, field := range SearchFacets {
+		facetFieldMapping := bleve.NewKeywordFieldMapping()
+		facetFieldMapping.Store = false
+		facetFieldMapping.IncludeInAll = false
+		sceneMapping.AddFieldMappingsAt(field, facetFieldMapping)
+	}
+
 	mapping := bleve.NewIndexMapping()
 	mapping.AddDocumentMapping("_default", sceneMapping)
 
@@ -78,21 +117,83 @@ func (i *Index) Exist(id string) bool {
 func (i *Index) PutScene(scene models.Scene) error {
 	cast := ""
 	castConcat := ""
+	var aliases []string
+	var castFacet []string
 	for _, c := range scene.Cast {
 		cast = cast + " " + c.Name
 		castConcat = castConcat + " " + strings.Replace(c.Name, " ", "", -1)
+		if c.Aliases != "" {
+			var actorAliases []string
+			json.Unmarshal([]byte(c.Aliases), &actorAliases)
+			aliases = append(aliases, actorAliases...)
+		}
+		if !strings.HasPrefix(c.Name, "aka:") {
+			castFacet = append(castFacet, c.Name)
+		}
+	}
+
+	var tags []string
+	var tagGroups []string
+	groups := i.getTagGroups()
+	for _, t := range scene.Tags {
+		tags = append(tags, t.Name)
+		tagGroups = append(tagGroups, groups[t.ID]...)
+	}
+
+	var cuepoints []string
+	for _, c := range scene.Cuepoints {
+		if c.Name != "" {
+			cuepoints = append(cuepoints, c.Name)
+		}
+	}
+
+	var files []string
+	var resolutions []string
+	var projections []string
+	for _, f := range scene.Files {
+		files = append(files, f.Filename)
+		if f.Type != "video" {
+			continue
+		}
+		if f.VideoCodecName != "" {
+			files = append(files, f.VideoCodecName)
+		}
+		if f.VideoProjection != "" {
+			projections = appendUnique(projections, f.VideoProjection)
+		}
+		if f.VideoWidth > 0 {
+			resolutions = appendUnique(resolutions, fileResolution(f))
+		}
+	}
+
+	year := ""
+	if !scene.ReleaseDate.IsZero() {
+		year = strconv.Itoa(scene.ReleaseDate.Year())
 	}
 
 	rd := time.Date(scene.ReleaseDate.Year(), scene.ReleaseDate.Month(), scene.ReleaseDate.Day(), 0, 0, 0, 0, &time.Location{})
 	si := SceneIndexed{
-		Title:       fmt.Sprintf("%v", scene.Title),
-		Description: fmt.Sprintf("%v", scene.Synopsis),
-		Cast:        fmt.Sprintf("%v %v", cast, castConcat),
-		Site:        fmt.Sprintf("%v", scene.Site),
-		Id:          fmt.Sprintf("%v", scene.SceneID),
-		Released:    rd,                                       // only index the date, not the time
-		Added:       scene.CreatedAt.Truncate(24 * time.Hour), // only index the date, not the time
-		Duration:    scene.Duration,
+		Title:           fmt.Sprintf("%v", scene.Title),
+		Description:     fmt.Sprintf("%v", scene.Synopsis),
+		Cast:            fmt.Sprintf("%v %v", cast, castConcat),
+		Aliases:         strings.Join(aliases, " "),
+		Site:            fmt.Sprintf("%v", scene.Site),
+		Studio:          scene.Studio,
+		Tags:            strings.Join(tags, " "),
+		TagGroups:       strings.Join(tagGroups, " "),
+		Cuepoints:       strings.Join(cuepoints, " "),
+		Files:           strings.Join(files, " "),
+		Resolution:      strings.Join(resolutions, " "),
+		Projection:      strings.Join(projections, " "),
+		Id:              fmt.Sprintf("%v", scene.SceneID),
+		Released:        rd,                                       // only index the date, not the time
+		Added:           scene.CreatedAt.Truncate(24 * time.Hour), // only index the date, not the time
+		Duration:        scene.Duration,
+		FacetSite:       scene.Site,
+		FacetTag:        tags,
+		FacetCast:       castFacet,
+		FacetYear:       year,
+		FacetResolution: resolutions,
 	}
 
 	if err := i.Bleve.Index(scene.SceneID, si); err != nil {
@@ -102,10 +203,48 @@ func (i *Index) PutScene(scene models.Scene) error {
 	return nil
 }
 
+// getTagGroups maps tag ids to the names of their tag groups, loaded once per opened index
+func (i *Index) getTagGroups() map[uint][]string {
+	if i.tagGroups != nil {
+		return i.tagGroups
+	}
+	i.tagGroups = map[uint][]string{}
+
+	db, _ := models.GetCommonDB()
+	var tagGroups []models.TagGroup
+	db.Preload("Tags").Find(&tagGroups)
+	for _, g := range tagGroups {
+		for _, t := range g.Tags {
+			i.tagGroups[t.ID] = append(i.tagGroups[t.ID], g.Name)
+		}
+	}
+	return i.tagGroups
+}
+
+// fileResolution labels a video like the Resolution filter, in K of horizontal pixels
+func fileResolution(f models.File) string {
+	width := f.VideoWidth
+	if strings.HasSuffix(f.VideoProjection, "_tb") {
+		width = width * 2
+	}
+	return fmt.Sprintf("%vK", (width+500)/1000)
+}
+
+func appendUnique(list []string, value string) []string {
+	for _, v := range list {
+		if v == value {
+			return list
+		}
+	}
+	return append(list, value)
+}
+
 func SearchIndex() {
 	if !models.CheckLock("index") {
 		models.CreateLock("index")
 		defer models.RemoveLock("index")
+		searchIndexMutex.Lock()
+		defer searchIndexMutex.Unlock()
 
 		tlog := log.WithFields(logrus.Fields{"task": "scrape"})
 
@@ -123,7 +262,7 @@ func SearchIndex() {
 		offset := 0
 		current := 0
 		var scenes []models.Scene
-		tx := db.Model(models.Scene{}).Preload("Cast").Preload("Tags")
+		tx := db.Model(models.Scene{}).Preload("Cast").Preload("Tags").Preload("Cuepoints").Preload("Files")
 		tx.Count(&total)
 
 		tlog.Infof("Building search index...")
@@ -162,6 +301,113 @@ func SearchIndex() {
 	}
 }
 
+const (
+	searchIndexerDelay     = 2 * time.Second
+	searchIndexerBatchSize = 200
+)
+
+var (
+	searchIndexerMutex sync.Mutex
+	searchLastIndexed  time.Time
+
+	// searchIndexMutex serialises opening the index, bleve can only open it once. The "index" lock only tells the
+	// bulk updates apart, the background indexer waits for them instead of holding the lock, so a bulk update never
+	// skips its scenes because a batch of changes is being indexed. searchIndexBatchOpen tells the readers that
+	// check the lock to skip the index while a batch has it open, instead of blocking on it.
+	searchIndexMutex     sync.Mutex
+	searchIndexBatchOpen atomic.Bool
+)
+
+// SearchIndexBusy tells if the index is being rebuilt or a batch of changes is being indexed, opening it would wait
+func SearchIndexBusy() bool {
+	return models.CheckLock("index") || searchIndexBatchOpen.Load()
+}
+
+// searchIndexOnce runs a search on the index opened only for it, so it stays free for the indexer. It waits for a
+// batch of changes being indexed, but fails while the index is rebuilt.
+func searchIndexOnce(searchRequest *bleve.SearchRequest) (*bleve.SearchResult, error) {
+	if models.CheckLock("index") {
+		return nil, errors.New("search index is being rebuilt")
+	}
+	searchIndexMutex.Lock()
+	defer searchIndexMutex.Unlock()
+
+	idx, err := NewIndex("scenes")
+	if err != nil {
+		return nil, err
+	}
+	defer idx.Bleve.Close()
+	return idx.Bleve.Search(searchRequest)
+}
+
+// StartSearchIndexer keeps the search index up to date with the scenes queued by changes to the models
+func StartSearchIndexer() {
+	for range models.SceneIndexQueueSignal() {
+		// give changes made together time to arrive, so they are indexed in one batch
+		time.Sleep(searchIndexerDelay)
+		for indexQueuedScenes() {
+		}
+	}
+}
+
+// indexQueuedScenes indexes a batch of queued scenes, it returns false once the queue is empty
+func indexQueuedScenes() bool {
+	// the bulk updates hold the mutex while they run, the batch waits for them
+	searchIndexMutex.Lock()
+	defer searchIndexMutex.Unlock()
+
+	ids, deleted := models.TakeSceneIndexQueue(searchIndexerBatchSize)
+	if len(ids) == 0 && len(deleted) == 0 {
+		return false
+	}
+
+	searchIndexBatchOpen.Store(true)
+	defer searchIndexBatchOpen.Store(false)
+
+	idx, err := NewIndex("scenes")
+	if err != nil {
+		log.Error(err)
+		return false
+	}
+	defer idx.Bleve.Close()
+
+	for _, id := range deleted {
+		idx.Bleve.Delete(id)
+	}
+
+	if len(ids) > 0 {
+		db, _ := models.GetDB()
+		defer db.Close()
+
+		var scenes []models.Scene
+		db.Preload("Cast").Preload("Tags").Preload("Cuepoints").Preload("Files").Where("id in (?)", ids).Find(&scenes)
+		for i := range scenes {
+			if err := idx.PutScene(scenes[i]); err != nil {
+				log.Error(err)
+			}
+		}
+	}
+
+	searchIndexerMutex.Lock()
+	searchLastIndexed = time.Now()
+	searchIndexerMutex.Unlock()
+	return true
+}
+
+// SearchIndexLag returns the number of changed scenes waiting to be indexed, how long the oldest has waited and
+// when the indexer last indexed changes
+func SearchIndexLag() (int, time.Duration, time.Time) {
+	pending, oldest := models.SceneIndexQueueState()
+	var lag time.Duration
+	if pending > 0 {
+		lag = time.Since(oldest)
+	}
+
+	searchIndexerMutex.Lock()
+	defer searchIndexerMutex.Unlock()
+	return pending, lag, searchLastIndexed
+}
+
 /**
  * Update search index for all of the specified scenes.
  */
@@ -169,6 +415,8 @@ func IndexScenes(scenes *[]models.Scene) {
 	if !models.CheckLock("index") {
 		models.CreateLock("index")
 		defer models.RemoveLock("index")
+		searchIndexMutex.Lock()
+		defer searchIndexMutex.Unlock()
 
 		tlog := log.WithFields(logrus.Fields{"task": "scrape"})
 
@@ -206,6 +454,11 @@ func IndexScenes(scenes *[]models.Scene) {
 		idx.Bleve.Close()
 
 		tlog.Infof("Indexed %v scenes", total)
+	} else {
+		// another bulk update holds the index, the background indexer adds the scenes once it's done
+		for i := range *scenes {
+			models.QueueSceneIndex((*scenes)[i].ID)
+		}
 	}
 }
 
@@ -213,6 +466,8 @@ func DeleteIndexScenes(scenes *[]models.Scene) {
 	if !models.CheckLock("index") {
 		models.CreateLock("index")
 		defer models.RemoveLock("index")
+		searchIndexMutex.Lock()
+		defer searchIndexMutex.Unlock()
 
 		tlog := log.WithFields(logrus.Fields{"task": "scrape"})
 
@@ -242,6 +497,10 @@ func DeleteIndexScenes(scenes *[]models.Scene) {
 		idx.Bleve.Close()
 
 		tlog.Infof("Indexed %v scenes", total)
+	} else {
+		for i := range *scenes {
+			models.QueueSceneIndexDelete((*scenes)[i].SceneID)
+		}
 	}
 }
 
@@ -251,6 +510,13 @@ func DeleteIndexScenes(scenes *[]models.Scene) {
  * which it calls IndexScenes.
  */
 func IndexScrapedScenes(scrapedScenes *[]models.ScrapedScene) {
+	// the scenes are read after taking them off the queue, so the changes made while scraping are indexed once
+	var sceneIDs []string
+	for i := range *scrapedScenes {
+		sceneIDs = append(sceneIDs, (*scrapedScenes)[i].SceneID)
+	}
+	models.UnqueueSceneIndex(models.GetScenePKs(sceneIDs)...)
+
 	// Map scrapedScenes to Scenes
 	var scenes []models.Scene
 	for i := range *scrapedScenes {
@@ -0,0 +1,124 @@
+package tasks
+
+import (
+	"errors"
+
+	"github.com/jinzhu/gorm"
+	"github.com/xbapps/xbvr/pkg/config"
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+// sceneStaging decides which scraped scenes are staged for review instead of written to the scenes table.
+// A nil value stages nothing.
+type sceneStaging struct {
+	autoApprove string
+}
+
+// newSceneStaging returns the staging of a scrape when it is enabled. Auto approve rules only apply to
+// scheduled scrapes, scrapes started by the user stage every scene.
+func newSceneStaging(scheduled bool) *sceneStaging {
+	if !config.Config.Advanced.StageScrapedScenes {
+		return nil
+	}
+	staging := &sceneStaging{autoApprove: "none"}
+	if scheduled {
+		staging.autoApprove = config.Config.Advanced.StagingAutoApprove
+	}
+	return staging
+}
+
+// stage stores the scene for review unless it is auto approved, and returns whether it was staged
+func (s *sceneStaging) stage(db *gorm.DB, scene models.ScrapedScene, isNew bool) bool {
+	if s == nil || scene.MasterSiteId != "" || scene.OnlyUpdateScriptData {
+		return false
+	}
+	switch {
+	case s.autoApprove == "all":
+		return false
+	case s.autoApprove == "new" && isNew:
+		return false
+	}
+	if err := models.StageScrapedScene(db, scene, isNew); err != nil {
+		log.WithField("task", "scrape").Errorf("Can't stage scene %v: %v", scene.SceneID, err)
+		return false
+	}
+	return true
+}
+
+// GetStagedSceneDiff returns a staged scene with the fields it changes on the existing scene
+func GetStagedSceneDiff(id uint) (models.StagedScene, models.ScrapedScene, []models.SceneFieldDiff, error) {
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	var staged models.StagedScene
+	if err := db.Where("id = ?", id).First(&staged).Error; err != nil {
+		return staged, models.ScrapedScene{}, nil, err
+	}
+	ext := staged.GetScrapedScene()
+	var scene models.Scene
+	db.Preload("Tags").Preload("Cast").Where(&models.Scene{SceneID: staged.SceneID}).First(&scene)
+
+	// locked fields won't change when the scene is approved
+	locked := models.GetLockedSceneFields(db, scene.ID)
+	diffs := []models.SceneFieldDiff{}
+	for _, d := range scene.DiffScrapedScene(ext) {
+		if !locked[models.ActionColumnField(d.Field)] {
+			diffs = append(diffs, d)
+		}
+	}
+	return staged, ext, diffs, nil
+}
+
+// ApproveStagedScenes writes the staged scenes with the ids, or all staged scenes of the site, to the scenes
+// table and returns how many were written
+func ApproveStagedScenes(ids []uint, site string) (int, error) {
+	if models.CheckLock("scrape") {
+		return 0, errors.New("scenes can't be approved while scraping")
+	}
+	models.CreateLock("scrape")
+	defer models.RemoveLock("scrape")
+	tlog := log.WithField("task", "scrape")
+
+	commonDb, _ := models.GetCommonDB()
+	var staged []models.StagedScene
+	stagedScenesQuery(commonDb, ids, site).Find(&staged)
+	if len(staged) == 0 {
+		return 0, nil
+	}
+
+	var approved []models.ScrapedScene
+	for i, s := range staged {
+		ext := s.GetScrapedScene()
+		if err := models.SceneCreateUpdateFromExternal(commonDb, ext); err != nil {
+			tlog.Errorf("Can't write staged scene %v: %v", s.SceneID, err)
+			continue
+		}
+		commonDb.Delete(&staged[i])
+		approved = append(approved, ext)
+	}
+
+	var dummyAka models.Aka
+	dummyAka.UpdateAkaSceneCastRecords()
+	var dummyTagGroup models.TagGroup
+	dummyTagGroup.UpdateSceneTagRecords()
+	CountTags()
+	ReapplyEdits()
+	IndexScrapedScenes(&approved)
+	CheckSavedSearches()
+
+	tlog.Infof("Approved %v staged scenes", len(approved))
+	return len(approved), nil
+}
+
+// RejectStagedScenes drops the staged scenes with the ids, or all staged scenes of the site
+func RejectStagedScenes(ids []uint, site string) int {
+	commonDb, _ := models.GetCommonDB()
+	return int(stagedScenesQuery(commonDb, ids, site).Delete(&models.StagedScene{}).RowsAffected)
+}
+
+func stagedScenesQuery(db *gorm.DB, ids []uint, site string) *gorm.DB {
+	if len(ids) > 0 {
+		return db.Where("id in (?)", ids)
+	}
+	return db.Where("scraper_id = ?", site)
+}
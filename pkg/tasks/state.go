@@ -6,6 +6,7 @@ import (
 
 	"github.com/xbapps/xbvr/pkg/common"
 	"github.com/xbapps/xbvr/pkg/config"
+	"github.com/xbapps/xbvr/pkg/scrape"
 	"github.com/xbapps/xbvr/ui"
 )
 
@@ -31,12 +32,15 @@ func UpdateState() {
 }
 
 func CalculateCacheSizes() {
+	scrape.PruneHTTPCache()
+
 	imgSize, _ := common.DirSize(common.ImgDir)
 	heatmapThumbSize, _ := common.DirSize(common.HeatmapThumbnailDir)
 	// Combine both image caches (imageproxy + heatmap thumbnails)
 	config.State.CacheSize.Images = imgSize + heatmapThumbSize
 	config.State.CacheSize.Previews, _ = common.DirSize(common.VideoPreviewDir)
 	config.State.CacheSize.SearchIndex, _ = common.DirSize(common.IndexDirV2)
+	config.State.CacheSize.Scrape, _ = common.DirSize(common.ScrapeCacheDir)
 
 	config.SaveState()
 }
@@ -1,10 +1,10 @@
 package tasks
 
 import (
-	"bytes"
 	"context"
 	"encoding/json"
 	"fmt"
+	"hash/fnv"
 	"os"
 	"path"
 	"path/filepath"
@@ -38,6 +38,9 @@ func RescanVolumes(id int) {
 		models.CreateLock("rescan")
 		defer models.RemoveLock("rescan")
 
+		volumeScanMutex.Lock()
+		defer volumeScanMutex.Unlock()
+
 		tlog := log.WithFields(logrus.Fields{"task": "rescan"})
 		tlog.Infof("Start scanning volumes")
 
@@ -61,146 +64,54 @@ func RescanVolumes(id int) {
 				scanLocalVolume(vol[i], db, tlog)
 			case "putio":
 				scanPutIO(vol[i], db, tlog)
+			case "sftp", "webdav", "smb":
+				scanRemoteVolume(vol[i], db, tlog)
+			case "s3":
+				scanS3(vol[i], db, tlog)
+			}
+		}
+
+		for i := range vol {
+			if vol[i].Type == "local" {
+				removeMissingLocalFiles(vol[i], db)
 			}
 		}
 
+		// Pick up volumes that were added or became available since the last scan
+		SyncVolumeWatchers()
+
 		// Match Scene to File
 		var files []models.File
-		var scenes []models.Scene
-		var extrefs []models.ExternalReference
 
 		tlog.Infof("Matching Scenes to known filenames")
 		db.Model(&models.File{}).Where("files.scene_id = 0").Find(&files)
 
-		escape := func(s string) string {
-			var buffer bytes.Buffer
-			json.HTMLEscape(&buffer, []byte(s))
-			return buffer.String()
-		}
-
-		// Regex to strip known funscript-only suffixes before extension swap.
-		// These suffixes appear on funscript files but NOT on the matching video file.
-		// Only well-known suffixes to avoid false positives.
-		funscriptSuffixRe := regexp.MustCompile(`(?i)_(ai|2d|ow)\.funscript$`)
-
 		for i := range files {
-			unescapedFilename := path.Base(files[i].Filename)
-			filename := escape(unescapedFilename)
-			filename2 := strings.Replace(filename, ".funscript", ".mp4", -1)
-			filename3 := strings.Replace(filename, ".hsp", ".mp4", -1)
-			filename4 := strings.Replace(filename, ".srt", ".mp4", -1)
-			filename5 := strings.Replace(filename, ".cmscript", ".mp4", -1)
-
-			// Generate additional variant: strip funscript-specific suffixes (_ai, _2d, _ow)
-			// e.g. "video_ai.funscript" → "video.mp4"
-			filename6 := ""
-			if funscriptSuffixRe.MatchString(unescapedFilename) {
-				stripped := funscriptSuffixRe.ReplaceAllString(escape(unescapedFilename), ".mp4")
-				if stripped != filename2 {
-					filename6 = stripped
-				}
-			}
-
-			query := `filenames_arr LIKE ? OR filenames_arr LIKE ? OR filenames_arr LIKE ? OR filenames_arr LIKE ? OR filenames_arr LIKE ?`
-			args := []interface{}{`%"` + filename + `"%`, `%"` + filename2 + `"%`, `%"` + filename3 + `"%`, `%"` + filename4 + `"%`, `%"` + filename5 + `"%`}
-			if filename6 != "" {
-				query += ` OR filenames_arr LIKE ?`
-				args = append(args, `%"`+filename6+`"%`)
-			}
-			err := db.Where(query, args...).Find(&scenes).Error
-			if err != nil {
-				log.Error(err, " when matching "+unescapedFilename)
-			}
-			if len(scenes) == 0 && config.Config.Advanced.UseAltSrcInFileMatching {
-				// check if the filename matches in external_reference record
-				altQuery := "external_source like 'alternate scene %' and (external_data LIKE ? OR external_data LIKE ? OR external_data LIKE ? OR external_data LIKE ? OR external_data LIKE ?"
-				altArgs := []interface{}{`%"` + filename + `%`, `%"` + filename2 + `%`, `%"` + filename3 + `%`, `%"` + filename4 + `%`, `%"` + filename5 + `%`}
-				if filename6 != "" {
-					altQuery += " OR external_data LIKE ?"
-					altArgs = append(altArgs, `%"`+filename6+`%`)
-				}
-				altQuery += ")"
-				db.Preload("XbvrLinks").Where(altQuery, altArgs...).Find(&extrefs)
-				if len(extrefs) == 1 {
-					if len(extrefs[0].XbvrLinks) == 1 {
-						// the scene id will be the Internal DB Id from the associated link
-						var scene models.Scene
-						scene.GetIfExistByPK(extrefs[0].XbvrLinks[0].InternalDbId)
-						// Add File to the list of Scene filenames
-						var pfTxt []string
-						err = json.Unmarshal([]byte(scene.FilenamesArr), &pfTxt)
-						if err != nil {
-							continue
-						}
-						pfTxt = append(pfTxt, files[i].Filename)
-						tmp, err := json.Marshal(pfTxt)
-						if err == nil {
-							scene.FilenamesArr = string(tmp)
-						}
-						scene.Save()
-						scenes = append(scenes, scene)
-					}
-				}
-			}
-			if len(scenes) == 1 {
-				files[i].SceneID = scenes[0].ID
-				files[i].Save()
-				scenes[0].UpdateStatus()
-			} else {
-				if config.Config.Storage.MatchOhash && config.Config.Advanced.StashApiKey != "" {
-					hash := files[i].OsHash
-					if len(hash) < 16 {
-						// the has in xbvr is sometiomes < 16 pad with zeros
-						paddingLength := 16 - len(hash)
-						hash = strings.Repeat("0", paddingLength) + hash
-					}
-					queryVariable := `
-				{"input":{
-					"fingerprints": {					
-						"value": "` + hash + `",
-						"modifier": "INCLUDES"
-					},				
-					"page": 1
-				}
-				}`
-					// call Stashdb graphql searching for os_hash
-					stashMatches := scrape.GetScenePage(queryVariable)
-					for _, match := range stashMatches.Data.QueryScenes.Scenes {
-						if match.ID != "" {
-							var externalRefLink models.ExternalReferenceLink
-							db.Where(&models.ExternalReferenceLink{ExternalSource: "stashdb scene", ExternalId: match.ID}).First(&externalRefLink)
-							if externalRefLink.ID != 0 {
-								files[i].SceneID = externalRefLink.InternalDbId
-								files[i].Save()
-								var scene models.Scene
-								scene.GetIfExistByPK(externalRefLink.InternalDbId)
-
-								// add filename tyo the array
-								var pfTxt []string
-								json.Unmarshal([]byte(scene.FilenamesArr), &pfTxt)
-								pfTxt = append(pfTxt, files[i].Filename)
-								tmp, _ := json.Marshal(pfTxt)
-								scene.FilenamesArr = string(tmp)
-								scene.Save()
-								models.AddAction(scene.SceneID, "match", "filenames_arr", scene.FilenamesArr)
-
-								scene.UpdateStatus()
-								log.Infof("File %s matched to Scene %s matched using stashdb hash %s", path.Base(files[i].Filename), scene.SceneID, hash)
-							}
-						}
-					}
-				}
-			}
+			matchFileToScene(db, &files[i])
 
 			if (i % 50) == 0 {
 				tlog.Infof("Matching Scenes to known filenames (%v/%v)", i+1, len(files))
 			}
 		}
 
+		tlog.Infof("Matching remaining files by parsing their filenames")
+		FuzzyMatchFiles(db, files, tlog)
+
+		if config.Config.Storage.Organise && !models.CheckLock("organise") {
+			tlog.Infof("Organising files")
+			if _, err := organiseFiles(db, config.Config.Storage.OrganiseTemplate, nil, false, tlog); err != nil {
+				tlog.Errorf("Can't organise files: %v", err)
+			}
+		}
+
 		tlog.Infof("Generating heatmaps")
 
 		GenerateHeatmaps(tlog)
 
+		ExportNfo(false, false)
+
+		CheckSavedSearches()
+
 		tlog.Infof("Scanning complete")
 
 		// Inform UI about state change
@@ -239,6 +150,98 @@ func RescanVolumes(id int) {
 	}
 }
 
+// matchFileToScene tries to link an unmatched file to a scene using the filenames
+// known for each scene, alternate scene sources and optionally the stashdb oshash
+func matchFileToScene(db *gorm.DB, file *models.File) {
+	keys := models.FilenameMatchKeys(file.Filename)
+	scenes := models.FindScenesByFilenames(db, keys)
+
+	if len(scenes) == 0 && config.Config.Advanced.UseAltSrcInFileMatching {
+		// check if the filename matches in external_reference record
+		extrefs := models.FindAltSourcesByFilenames(db, keys)
+		if len(extrefs) == 1 {
+			if len(extrefs[0].XbvrLinks) == 1 {
+				// the scene id will be the Internal DB Id from the associated link
+				var scene models.Scene
+				scene.GetIfExistByPK(extrefs[0].XbvrLinks[0].InternalDbId)
+				// Add File to the list of Scene filenames
+				var pfTxt []string
+				err := json.Unmarshal([]byte(scene.FilenamesArr), &pfTxt)
+				if err != nil {
+					return
+				}
+				pfTxt = append(pfTxt, file.Filename)
+				tmp, err := json.Marshal(pfTxt)
+				if err == nil {
+					scene.FilenamesArr = string(tmp)
+				}
+				scene.Save()
+				scene.UpdateFilenameIndex(db)
+				scenes = append(scenes, scene)
+			}
+		}
+	}
+	if len(scenes) == 1 {
+		file.SceneID = scenes[0].ID
+		file.Save()
+		scenes[0].UpdateStatus()
+	} else {
+		if config.Config.Storage.MatchOhash && config.Config.Advanced.StashApiKey != "" {
+			hash := file.OsHash
+			if len(hash) < 16 {
+				// the has in xbvr is sometiomes < 16 pad with zeros
+				paddingLength := 16 - len(hash)
+				hash = strings.Repeat("0", paddingLength) + hash
+			}
+			matchFileOnStashFingerprint(db, file, hash)
+		}
+	}
+}
+
+// matchFileOnStashFingerprint searches StashDB for scenes with a fingerprint (oshash or phash) and
+// links the file to the scene already linked to the StashDB scene
+func matchFileOnStashFingerprint(db *gorm.DB, file *models.File, hash string) bool {
+	queryVariable := `
+	{"input":{
+		"fingerprints": {					
+			"value": "` + hash + `",
+			"modifier": "INCLUDES"
+		},				
+		"page": 1
+	}
+	}`
+	// call Stashdb graphql searching for the hash
+	stashMatches := scrape.GetScenePage(queryVariable)
+	matched := false
+	for _, match := range stashMatches.Data.QueryScenes.Scenes {
+		if match.ID != "" {
+			var externalRefLink models.ExternalReferenceLink
+			db.Where(&models.ExternalReferenceLink{ExternalSource: "stashdb scene", ExternalId: match.ID}).First(&externalRefLink)
+			if externalRefLink.ID != 0 {
+				file.SceneID = externalRefLink.InternalDbId
+				file.Save()
+				var scene models.Scene
+				scene.GetIfExistByPK(externalRefLink.InternalDbId)
+
+				// add filename tyo the array
+				var pfTxt []string
+				json.Unmarshal([]byte(scene.FilenamesArr), &pfTxt)
+				pfTxt = append(pfTxt, file.Filename)
+				tmp, _ := json.Marshal(pfTxt)
+				scene.FilenamesArr = string(tmp)
+				scene.Save()
+				scene.UpdateFilenameIndex(db)
+				models.AddAction(scene.SceneID, "match", "filenames_arr", scene.FilenamesArr)
+
+				scene.UpdateStatus()
+				log.Infof("File %s matched to Scene %s matched using stashdb hash %s", path.Base(file.Filename), scene.SceneID, hash)
+				matched = true
+			}
+		}
+	}
+	return matched
+}
+
 func scanLocalVolume(vol models.Volume, db *gorm.DB, tlog *logrus.Entry) {
 	allowedVideoExt := getAllowedVideoExt()
 	if vol.IsMounted() {
@@ -275,161 +278,271 @@ func scanLocalVolume(vol models.Volume, db *gorm.DB, tlog *logrus.Entry) {
 			return nil
 		})
 
-		filenameSeparator := regexp.MustCompile("[ _.-]+")
-
 		for j, path := range videoProcList {
-			fStat, _ := os.Stat(path)
-			fTimes, err := times.Stat(path)
-			if err != nil {
-				tlog.Errorf("Can't get the modification/creation times for %s, error: %s", path, err)
-			}
+			scanLocalVideoFile(path, vol.ID, db, tlog)
+			tlog.Infof("Scanning %v (%v/%v)", vol.Path, j+1, len(videoProcList))
+		}
 
-			var birthtime time.Time
-			if fTimes.HasBirthTime() {
-				birthtime = fTimes.BirthTime()
-			} else {
-				birthtime = fTimes.ModTime()
-			}
-			var fl models.File
-			db.Where(&models.File{
-				Path:     filepath.Dir(path),
-				Filename: filepath.Base(path),
-				Type:     "video",
-			}).FirstOrCreate(&fl)
-
-			fl.Size = fStat.Size()
-			fl.CreatedTime = birthtime
-			fl.UpdatedTime = fTimes.ModTime()
-			fl.VolumeID = vol.ID
-
-			hash, err := Hash(path)
-			if err == nil {
-				fl.OsHash = fmt.Sprintf("%x", hash)
-			}
+		for _, path := range scriptProcList {
+			scanLocalScriptFile(path, vol.ID, db)
+		}
 
-			ffdata, err := ffprobe.GetProbeData(path, time.Second*5)
-			if err != nil {
-				tlog.Error("Error running ffprobe", path, err)
-			} else {
-				vs := ffdata.GetFirstVideoStream()
-				if vs == nil {
-					tlog.Error("No video stream in file ", path)
-				} else {
-					if vs.BitRate != "" {
-						bitRate, _ := strconv.Atoi(vs.BitRate)
-						fl.VideoBitRate = bitRate
-					}
-					fl.VideoAvgFrameRate = vs.AvgFrameRate
-					fl.VideoCodecName = vs.CodecName
-					fl.VideoWidth = vs.Width
-					fl.VideoHeight = vs.Height
-					if dur, err := strconv.ParseFloat(vs.Duration, 64); err == nil {
-						fl.VideoDuration = dur
-					} else if ffdata.Format.DurationSeconds > 0.0 {
-						fl.VideoDuration = ffdata.Format.DurationSeconds
-					}
-					fl.HasAlpha = false
-
-					if vs.Height*2 == vs.Width || vs.Width > vs.Height {
-						fl.VideoProjection = "180_sbs"
-						nameparts := filenameSeparator.Split(strings.ToLower(filepath.Base(path)), -1)
-						for i, part := range nameparts {
-							if part == "mkx200" || part == "mkx220" || part == "rf52" || part == "fisheye190" || part == "vrca220" || part == "flat" {
-								fl.VideoProjection = part
-								break
-							} else if part == "fisheye" || part == "f180" || part == "180f" {
-								fl.VideoProjection = "fisheye"
-								break
-							} else if i < len(nameparts)-1 && (part+"_"+nameparts[i+1] == "mono_360" || part+"_"+nameparts[i+1] == "mono_180") {
-								fl.VideoProjection = nameparts[i+1] + "_mono"
-								break
-							} else if i < len(nameparts)-1 && (part+"_"+nameparts[i+1] == "360_mono" || part+"_"+nameparts[i+1] == "180_mono") {
-								fl.VideoProjection = part + "_mono"
-								break
-							}
-						}
-						if fl.VideoProjection == "mkx200" || fl.VideoProjection == "mkx220" || fl.VideoProjection == "rf52" || fl.VideoProjection == "fisheye190" || fl.VideoProjection == "vrca220" {
-							// alpha passthrough only works with fisheye projections
-							for _, part := range nameparts {
-								if part == "alpha" {
-									fl.HasAlpha = true
-									break
-								}
-							}
-						}
-					}
+		for _, path := range hspProcList {
+			ScanLocalHspFile(path, vol.ID, 0)
+		}
 
-					if vs.Height == vs.Width {
-						fl.VideoProjection = "360_tb"
-					}
+		for _, path := range subtitlesProcList {
+			ScanLocalSubtitlesFile(path, vol.ID, 0)
+		}
 
-					fl.CalculateFramerate()
-				}
-			}
+		vol.LastScan = time.Now()
+		vol.Save()
+	}
+}
 
-			err = fl.Save()
-			if err != nil {
-				tlog.Errorf("New file %s, but got error %s", path, err)
+// removeMissingLocalFiles deletes the records of files no longer present on a local volume.
+// It runs after all volumes were scanned, so files moved to another volume are picked up by
+// findMovedFile before their old record is removed.
+func removeMissingLocalFiles(vol models.Volume, db *gorm.DB) {
+	if !vol.IsMounted() {
+		return
+	}
+
+	var scene models.Scene
+	// Check if files are still present at the location
+	allFiles := vol.Files()
+	for i := range allFiles {
+		if !allFiles[i].Exists() {
+			log.Info(allFiles[i].GetPath())
+			db.Delete(&allFiles[i])
+			if allFiles[i].SceneID != 0 {
+				scene.GetIfExistByPK(allFiles[i].SceneID)
+				scene.UpdateStatus()
 			}
+		}
+	}
+}
 
-			tlog.Infof("Scanning %v (%v/%v)", vol.Path, j+1, len(videoProcList))
+var filenameSeparator = regexp.MustCompile("[ _.-]+")
+
+// scanLocalVideoFile creates or refreshes the file record of a single video, including its oshash and ffprobe data
+func scanLocalVideoFile(path string, volID uint, db *gorm.DB, tlog *logrus.Entry) {
+	fStat, _ := os.Stat(path)
+	fTimes, err := times.Stat(path)
+	if err != nil {
+		tlog.Errorf("Can't get the modification/creation times for %s, error: %s", path, err)
+	}
+
+	var birthtime time.Time
+	if fTimes.HasBirthTime() {
+		birthtime = fTimes.BirthTime()
+	} else {
+		birthtime = fTimes.ModTime()
+	}
+	var fl models.File
+	newFile := db.Where(&models.File{
+		Path:     filepath.Dir(path),
+		Filename: filepath.Base(path),
+		Type:     "video",
+	}).First(&fl).Error == gorm.ErrRecordNotFound
+
+	osHash := ""
+	hash, err := Hash(path)
+	if err == nil {
+		osHash = fmt.Sprintf("%x", hash)
+	}
+
+	moved := false
+	if newFile && osHash != "" {
+		if prev, ok := findMovedFile(db, "video", osHash, fStat.Size()); ok {
+			tlog.Infof("Detected %v was moved to %v", prev.GetPath(), path)
+			fl = prev
+			fl.Path = filepath.Dir(path)
+			fl.Filename = filepath.Base(path)
+			moved = true
 		}
+	}
+	if fl.ID == 0 {
+		db.Where(&models.File{
+			Path:     filepath.Dir(path),
+			Filename: filepath.Base(path),
+			Type:     "video",
+		}).FirstOrCreate(&fl)
+	}
 
-		for _, path := range scriptProcList {
-			var fl models.File
-			db.Where(&models.File{
-				Path:     filepath.Dir(path),
-				Filename: filepath.Base(path),
-				Type:     "script",
-			}).FirstOrCreate(&fl)
-
-			fStat, _ := os.Stat(path)
-			fTimes, _ := times.Stat(path)
-
-			if fStat.Size() != fl.Size {
-				fl.Size = fStat.Size()
-				fl.HasHeatmap = false
-				fl.VideoDuration = 0.0
-			}
+	fl.Size = fStat.Size()
+	fl.CreatedTime = birthtime
+	fl.UpdatedTime = fTimes.ModTime()
+	fl.VolumeID = volID
+	if osHash != "" {
+		if fl.OsHash != osHash {
+			// the content changed, the perceptual hash has to be generated again
+			fl.Phash = ""
+		}
+		fl.OsHash = osHash
+	}
 
-			if fl.VideoDuration < 0.01 {
-				duration, err := getFunscriptDuration(path)
-				if err == nil {
-					fl.VideoDuration = duration
+	// a moved file has the same content, keep the existing metadata including a projection set by the user
+	if !moved || fl.VideoDuration == 0 || fl.VideoProjection == "" {
+		ffdata, err := ffprobe.GetProbeData(path, time.Second*5)
+		if err != nil {
+			tlog.Error("Error running ffprobe", path, err)
+		} else {
+			applyProbeData(&fl, ffdata, path, tlog)
+		}
+	}
+
+	err = fl.Save()
+	if err != nil {
+		tlog.Errorf("New file %s, but got error %s", path, err)
+	}
+}
+
+// applyProbeData copies the video stream details from ffprobe into the file record and
+// derives the projection from the dimensions and filename
+func applyProbeData(fl *models.File, ffdata *ffprobe.ProbeData, path string, tlog *logrus.Entry) {
+	vs := ffdata.GetFirstVideoStream()
+	if vs == nil {
+		tlog.Error("No video stream in file ", path)
+	} else {
+		if vs.BitRate != "" {
+			bitRate, _ := strconv.Atoi(vs.BitRate)
+			fl.VideoBitRate = bitRate
+		}
+		fl.VideoAvgFrameRate = vs.AvgFrameRate
+		fl.VideoCodecName = vs.CodecName
+		fl.VideoWidth = vs.Width
+		fl.VideoHeight = vs.Height
+		if dur, err := strconv.ParseFloat(vs.Duration, 64); err == nil {
+			fl.VideoDuration = dur
+		} else if ffdata.Format.DurationSeconds > 0.0 {
+			fl.VideoDuration = ffdata.Format.DurationSeconds
+		}
+		fl.HasAlpha = false
+
+		if vs.Height*2 == vs.Width || vs.Width > vs.Height {
+			fl.VideoProjection = "180_sbs"
+			nameparts := filenameSeparator.Split(strings.ToLower(filepath.Base(path)), -1)
+			for i, part := range nameparts {
+				if part == "mkx200" || part == "mkx220" || part == "rf52" || part == "fisheye190" || part == "vrca220" || part == "flat" {
+					fl.VideoProjection = part
+					break
+				} else if part == "fisheye" || part == "f180" || part == "180f" {
+					fl.VideoProjection = "fisheye"
+					break
+				} else if i < len(nameparts)-1 && (part+"_"+nameparts[i+1] == "mono_360" || part+"_"+nameparts[i+1] == "mono_180") {
+					fl.VideoProjection = nameparts[i+1] + "_mono"
+					break
+				} else if i < len(nameparts)-1 && (part+"_"+nameparts[i+1] == "360_mono" || part+"_"+nameparts[i+1] == "180_mono") {
+					fl.VideoProjection = part + "_mono"
+					break
+				}
+			}
+			if fl.VideoProjection == "mkx200" || fl.VideoProjection == "mkx220" || fl.VideoProjection == "rf52" || fl.VideoProjection == "fisheye190" || fl.VideoProjection == "vrca220" {
+				// alpha passthrough only works with fisheye projections
+				for _, part := range nameparts {
+					if part == "alpha" {
+						fl.HasAlpha = true
+						break
+					}
 				}
 			}
+		}
 
-			fl.CreatedTime = fTimes.ModTime()
-			fl.UpdatedTime = fTimes.ModTime()
-			fl.VolumeID = vol.ID
-			fl.Save()
+		if vs.Height == vs.Width {
+			fl.VideoProjection = "360_tb"
 		}
 
-		for _, path := range hspProcList {
-			ScanLocalHspFile(path, vol.ID, 0)
+		fl.CalculateFramerate()
+	}
+}
+
+// findMovedFile looks for the record of a video or script that vanished from its location on a local volume
+// and has the same content as a newly found file, so the file keeps its id, scene link and history.
+// Files on volumes that aren't mounted haven't vanished, they are only out of reach. Videos are compared by
+// oshash, scripts by the content hash of hashScript.
+func findMovedFile(db *gorm.DB, fileType string, hash string, size int64) (models.File, bool) {
+	column := "os_hash"
+	if fileType == "script" {
+		column = "content_hash"
+	}
+	var candidates []models.File
+	db.Preload("Volume").Where(column+" = ? and size = ? and type = ?", hash, size, fileType).Find(&candidates)
+	for _, f := range candidates {
+		if f.Volume.Type != "local" || !f.Volume.IsMounted() {
+			continue
+		}
+		if _, err := os.Stat(f.GetPath()); os.IsNotExist(err) {
+			return f, true
 		}
+	}
+	return models.File{}, false
+}
 
-		for _, path := range subtitlesProcList {
-			ScanLocalSubtitlesFile(path, vol.ID, 0)
+func scanLocalScriptFile(path string, volID uint, db *gorm.DB) {
+	var fl models.File
+	newFile := db.Where(&models.File{
+		Path:     filepath.Dir(path),
+		Filename: filepath.Base(path),
+		Type:     "script",
+	}).First(&fl).Error == gorm.ErrRecordNotFound
+
+	fStat, _ := os.Stat(path)
+	fTimes, _ := times.Stat(path)
+
+	// a moved script keeps its scene link, selection and heatmap
+	scriptHash := hashScript(path)
+	if newFile && scriptHash != "" {
+		if prev, ok := findMovedFile(db, "script", scriptHash, fStat.Size()); ok {
+			log.WithField("task", "rescan").Infof("Detected %v was moved to %v", prev.GetPath(), path)
+			fl = prev
+			fl.Path = filepath.Dir(path)
+			fl.Filename = filepath.Base(path)
 		}
+	}
+	if fl.ID == 0 {
+		db.Where(&models.File{
+			Path:     filepath.Dir(path),
+			Filename: filepath.Base(path),
+			Type:     "script",
+		}).FirstOrCreate(&fl)
+	}
+	if scriptHash != "" {
+		fl.ContentHash = scriptHash
+	}
 
-		vol.LastScan = time.Now()
-		vol.Save()
+	if fStat.Size() != fl.Size {
+		fl.Size = fStat.Size()
+		fl.HasHeatmap = false
+		fl.VideoDuration = 0.0
+	}
 
-		var scene models.Scene
-		// Check if files are still present at the location
-		allFiles := vol.Files()
-		for i := range allFiles {
-			if !allFiles[i].Exists() {
-				log.Info(allFiles[i].GetPath())
-				db.Delete(&allFiles[i])
-				if allFiles[i].SceneID != 0 {
-					scene.GetIfExistByPK(allFiles[i].SceneID)
-					scene.UpdateStatus()
-				}
-			}
+	if fl.VideoDuration < 0.01 {
+		duration, err := getFunscriptDuration(path)
+		if err == nil {
+			fl.VideoDuration = duration
 		}
 	}
+
+	fl.CreatedTime = fTimes.ModTime()
+	fl.UpdatedTime = fTimes.ModTime()
+	fl.VolumeID = volID
+	fl.Save()
+}
+
+// hashScript returns the content hash of a script used to detect moves, the oshash of larger scripts and the
+// FNV hash of scripts smaller than the oshash chunks
+func hashScript(path string) string {
+	if hash, err := Hash(path); err == nil {
+		return fmt.Sprintf("%x", hash)
+	}
+	data, err := os.ReadFile(path)
+	if err != nil || len(data) >= ChunkSize {
+		return ""
+	}
+	h := fnv.New64a()
+	h.Write(data)
+	return fmt.Sprintf("%x", h.Sum64())
 }
 
 func scanPutIO(vol models.Volume, db *gorm.DB, tlog *logrus.Entry) {
@@ -0,0 +1,147 @@
+package tasks
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+	"github.com/thoas/go-funk"
+	"github.com/xbapps/xbvr/pkg/ffprobe"
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+// scanRemoteVolume indexes a sftp, webdav or smb volume. Paths in the files table are the
+// slash separated paths on the server.
+func scanRemoteVolume(vol models.Volume, db *gorm.DB, tlog *logrus.Entry) {
+	fs, err := vol.GetRemoteFS()
+	if err != nil {
+		tlog.Errorf("Can't connect to %v: %v", vol.Path, err)
+		vol.IsAvailable = false
+		vol.Save()
+		return
+	}
+	defer fs.Close()
+
+	meta, _ := vol.GetRemoteMetadata()
+	allowedVideoExt := getAllowedVideoExt()
+
+	type remoteEntry struct {
+		name string
+		info os.FileInfo
+	}
+	var videoProcList []remoteEntry
+	present := map[string]bool{}
+
+	err = models.WalkRemote(fs, meta.Root, func(name string, info os.FileInfo) {
+		if strings.HasPrefix(info.Name(), ".") {
+			return
+		}
+
+		fileType := ""
+		ext := strings.ToLower(path.Ext(name))
+		switch {
+		case funk.ContainsString(allowedVideoExt, ext):
+			fileType = "video"
+		case ext == ".funscript" || ext == ".cmscript":
+			fileType = "script"
+		case ext == ".hsp":
+			fileType = "hsp"
+		case ext == ".srt" || ext == ".ssa" || ext == ".ass":
+			fileType = "subtitles"
+		default:
+			return
+		}
+		present[name] = true
+
+		var fl models.File
+		db.Where(&models.File{Path: path.Dir(name), Filename: path.Base(name), VolumeID: vol.ID}).FirstOrInit(&fl)
+
+		if fileType == "video" {
+			if fl.ID == 0 || fl.VideoDuration == 0 || fl.VideoProjection == "" || fl.Size != info.Size() || fl.OsHash == "" {
+				videoProcList = append(videoProcList, remoteEntry{name: name, info: info})
+			}
+			return
+		}
+
+		if fl.ID == 0 || fl.Size != info.Size() {
+			fl.Type = fileType
+			fl.Size = info.Size()
+			fl.HasHeatmap = false
+			fl.CreatedTime = info.ModTime()
+			fl.UpdatedTime = info.ModTime()
+			fl.VolumeID = vol.ID
+			fl.Save()
+		}
+	})
+	if err != nil {
+		// don't remove any files when the listing failed part way, nor count it as a scan
+		tlog.Errorf("Can't list %v: %v", vol.Path, err)
+		return
+	}
+
+	for j, entry := range videoProcList {
+		scanRemoteVideoFile(fs, vol.ID, entry.name, entry.info, db, tlog)
+		tlog.Infof("Scanning %v (%v/%v)", vol.Path, j+1, len(videoProcList))
+	}
+
+	var scene models.Scene
+	// Check if files are still present on the server
+	allFiles := vol.Files()
+	for i := range allFiles {
+		if !present[allFiles[i].GetRemotePath()] {
+			log.Info(allFiles[i].GetRemotePath())
+			db.Delete(&allFiles[i])
+			if allFiles[i].SceneID != 0 {
+				scene.GetIfExistByPK(allFiles[i].SceneID)
+				scene.UpdateStatus()
+			}
+		}
+	}
+
+	vol.IsAvailable = true
+	vol.LastScan = time.Now()
+	vol.Save()
+}
+
+func scanRemoteVideoFile(fs models.RemoteFS, volID uint, name string, info os.FileInfo, db *gorm.DB, tlog *logrus.Entry) {
+	var fl models.File
+	db.Where(&models.File{
+		Path:     path.Dir(name),
+		Filename: path.Base(name),
+		Type:     "video",
+		VolumeID: volID,
+	}).FirstOrCreate(&fl)
+
+	fl.Size = info.Size()
+	fl.CreatedTime = info.ModTime()
+	fl.UpdatedTime = info.ModTime()
+	fl.VolumeID = volID
+
+	if rs, err := fs.Open(name); err == nil {
+		hash, err := HashReader(rs, info.Size())
+		if err == nil {
+			fl.OsHash = fmt.Sprintf("%x", hash)
+		}
+		rs.Close()
+	}
+
+	open := func() (io.ReadSeekCloser, error) {
+		return fs.Open(name)
+	}
+	ffdata, err := ffprobe.GetProbeDataReader(name, info.ModTime(), open, time.Second*30)
+	if err != nil {
+		tlog.Error("Error running ffprobe", name, err)
+	} else {
+		applyProbeData(&fl, ffdata, name, tlog)
+	}
+
+	err = fl.Save()
+	if err != nil {
+		tlog.Errorf("New file %s, but got error %s", name, err)
+	}
+}
@@ -0,0 +1,161 @@
+package tasks
+
+import (
+	"context"
+	"fmt"
+	"path"
+	"strings"
+	"time"
+
+	"github.com/jinzhu/gorm"
+	"github.com/minio/minio-go/v7"
+	"github.com/sirupsen/logrus"
+	"github.com/thoas/go-funk"
+	"github.com/xbapps/xbvr/pkg/ffprobe"
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+// scanS3 indexes the objects below the prefix of a s3 volume. Objects are stored with the
+// key split into Path and Filename, the ETag is used to skip objects that haven't changed.
+func scanS3(vol models.Volume, db *gorm.DB, tlog *logrus.Entry) {
+	client, meta, err := vol.GetS3Client()
+	if err != nil {
+		tlog.Errorf("Can't connect to %v: %v", vol.Path, err)
+		vol.IsAvailable = false
+		vol.Save()
+		return
+	}
+
+	ctx := context.Background()
+	allowedVideoExt := getAllowedVideoExt()
+
+	prefix := meta.Prefix
+	if prefix != "" {
+		prefix += "/"
+	}
+
+	var videoProcList []minio.ObjectInfo
+	present := map[string]bool{}
+
+	for obj := range client.ListObjects(ctx, meta.Bucket, minio.ListObjectsOptions{Prefix: prefix, Recursive: true}) {
+		if obj.Err != nil {
+			// don't remove any files when the listing failed part way
+			tlog.Errorf("Can't list %v: %v", vol.Path, obj.Err)
+			vol.IsAvailable = false
+			vol.Save()
+			return
+		}
+		if strings.HasSuffix(obj.Key, "/") || strings.HasPrefix(path.Base(obj.Key), ".") {
+			continue
+		}
+
+		fileType := ""
+		ext := strings.ToLower(path.Ext(obj.Key))
+		switch {
+		case funk.ContainsString(allowedVideoExt, ext):
+			fileType = "video"
+		case ext == ".funscript" || ext == ".cmscript":
+			fileType = "script"
+		case ext == ".hsp":
+			fileType = "hsp"
+		case ext == ".srt" || ext == ".ssa" || ext == ".ass":
+			fileType = "subtitles"
+		default:
+			continue
+		}
+		present[obj.Key] = true
+
+		var fl models.File
+		db.Where(&models.File{Path: path.Dir(obj.Key), Filename: path.Base(obj.Key), VolumeID: vol.ID}).FirstOrInit(&fl)
+
+		if fileType == "video" {
+			if fl.ID == 0 || fl.VideoDuration == 0 || fl.VideoProjection == "" || fl.ETag != obj.ETag || fl.OsHash == "" {
+				videoProcList = append(videoProcList, obj)
+			}
+			continue
+		}
+
+		if fl.ID == 0 || fl.ETag != obj.ETag {
+			fl.Type = fileType
+			fl.Size = obj.Size
+			fl.ETag = obj.ETag
+			fl.HasHeatmap = false
+			fl.CreatedTime = obj.LastModified
+			fl.UpdatedTime = obj.LastModified
+			fl.VolumeID = vol.ID
+			fl.Save()
+		}
+	}
+
+	for j, obj := range videoProcList {
+		scanS3VideoFile(client, meta, vol.ID, obj, db, tlog)
+		tlog.Infof("Scanning %v (%v/%v)", vol.Path, j+1, len(videoProcList))
+	}
+
+	var scene models.Scene
+	// Check if files are still present in the bucket
+	allFiles := vol.Files()
+	for i := range allFiles {
+		if !present[allFiles[i].GetRemotePath()] {
+			log.Info(allFiles[i].GetRemotePath())
+			db.Delete(&allFiles[i])
+			if allFiles[i].SceneID != 0 {
+				scene.GetIfExistByPK(allFiles[i].SceneID)
+				scene.UpdateStatus()
+			}
+		}
+	}
+
+	vol.IsAvailable = true
+	vol.LastScan = time.Now()
+	vol.Save()
+}
+
+func scanS3VideoFile(client *minio.Client, meta models.S3VolumeMetadata, volID uint, obj minio.ObjectInfo, db *gorm.DB, tlog *logrus.Entry) {
+	var fl models.File
+	db.Where(&models.File{
+		Path:     path.Dir(obj.Key),
+		Filename: path.Base(obj.Key),
+		Type:     "video",
+		VolumeID: volID,
+	}).FirstOrCreate(&fl)
+
+	fl.Size = obj.Size
+	fl.ETag = obj.ETag
+	fl.CreatedTime = obj.LastModified
+	fl.UpdatedTime = obj.LastModified
+	fl.VolumeID = volID
+
+	ctx := context.Background()
+
+	// the opensubtitles hash only reads the first and last 64KiB, which are fetched with range requests
+	if o, err := client.GetObject(ctx, meta.Bucket, obj.Key, minio.GetObjectOptions{}); err == nil {
+		hash, err := HashReader(o, obj.Size)
+		if err == nil {
+			if fl.OsHash != fmt.Sprintf("%x", hash) {
+				fl.Phash = ""
+			}
+			fl.OsHash = fmt.Sprintf("%x", hash)
+		}
+		o.Close()
+	}
+
+	u, err := client.PresignedGetObject(ctx, meta.Bucket, obj.Key, models.S3PresignExpiry, nil)
+	if err != nil {
+		tlog.Error("Error presigning url", obj.Key, err)
+	} else {
+		probeCtx, cancel := context.WithTimeout(ctx, time.Second*30)
+		ffdata, err := ffprobe.GetProbeDataContext(probeCtx, u.String())
+		cancel()
+		if err != nil {
+			tlog.Error("Error running ffprobe", obj.Key, err)
+		} else {
+			applyProbeData(&fl, ffdata, obj.Key, tlog)
+		}
+	}
+
+	err = fl.Save()
+	if err != nil {
+		tlog.Errorf("New file %s, but got error %s", obj.Key, err)
+	}
+}
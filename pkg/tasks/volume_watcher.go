@@ -0,0 +1,324 @@
+package tasks
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+	"github.com/jinzhu/gorm"
+	"github.com/sirupsen/logrus"
+	"github.com/thoas/go-funk"
+	"github.com/xbapps/xbvr/pkg/common"
+	"github.com/xbapps/xbvr/pkg/config"
+	"github.com/xbapps/xbvr/pkg/models"
+)
+
+// volumeScanMutex stops the watchers processing changes while a full rescan is walking the volumes
+var volumeScanMutex sync.Mutex
+
+var volumeWatchers = map[uint]*volumeWatcher{}
+var volumeWatchersMutex sync.Mutex
+
+type volumeWatcher struct {
+	vol     models.Volume
+	watcher *fsnotify.Watcher
+	tlog    *logrus.Entry
+
+	pendingMutex sync.Mutex
+	pending      map[string]time.Time
+
+	done chan struct{}
+}
+
+// SyncVolumeWatchers starts a filesystem watcher for each enabled and mounted local volume
+// and stops the watchers of volumes that were removed or are no longer available.
+// The periodic rescan still walks every volume, so changes missed by a watcher are picked up there.
+func SyncVolumeWatchers() {
+	volumeWatchersMutex.Lock()
+	defer volumeWatchersMutex.Unlock()
+
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	var vols []models.Volume
+	if config.Config.Storage.WatchVolumes {
+		db.Where("type = ? and is_enabled = ?", "local", true).Find(&vols)
+	}
+
+	wanted := map[uint]models.Volume{}
+	for _, vol := range vols {
+		if vol.IsMounted() {
+			wanted[vol.ID] = vol
+		}
+	}
+
+	for id, w := range volumeWatchers {
+		vol, ok := wanted[id]
+		if !ok || vol.Path != w.vol.Path {
+			w.stop()
+			delete(volumeWatchers, id)
+		}
+	}
+
+	for id, vol := range wanted {
+		if _, ok := volumeWatchers[id]; ok {
+			continue
+		}
+		w, err := newVolumeWatcher(vol)
+		if err != nil {
+			log.WithField("task", "watcher").Errorf("Could not watch %v: %v", vol.Path, err)
+			continue
+		}
+		volumeWatchers[id] = w
+	}
+}
+
+// StopVolumeWatchers stops all running filesystem watchers
+func StopVolumeWatchers() {
+	volumeWatchersMutex.Lock()
+	defer volumeWatchersMutex.Unlock()
+
+	for id, w := range volumeWatchers {
+		w.stop()
+		delete(volumeWatchers, id)
+	}
+}
+
+func newVolumeWatcher(vol models.Volume) (*volumeWatcher, error) {
+	fsw, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+
+	w := &volumeWatcher{
+		vol:     vol,
+		watcher: fsw,
+		tlog:    log.WithFields(logrus.Fields{"task": "watcher"}),
+		pending: map[string]time.Time{},
+		done:    make(chan struct{}),
+	}
+
+	w.addRecursive(vol.Path)
+	go w.run()
+
+	w.tlog.Infof("Watching %v for changes", vol.Path)
+	return w, nil
+}
+
+func (w *volumeWatcher) stop() {
+	close(w.done)
+	w.watcher.Close()
+	w.tlog.Infof("Stopped watching %v", w.vol.Path)
+}
+
+// addRecursive registers a directory and all of its sub directories, fsnotify does not watch recursively
+func (w *volumeWatcher) addRecursive(root string) {
+	filepath.Walk(root, func(path string, f os.FileInfo, err error) error {
+		if err != nil || !f.IsDir() {
+			return nil
+		}
+		if path != root && strings.HasPrefix(f.Name(), ".") {
+			return filepath.SkipDir
+		}
+		if err := w.watcher.Add(path); err != nil {
+			// usually the inotify watch limit, the periodic rescan will still find these files
+			w.tlog.Warnf("Could not watch %v: %v", path, err)
+		}
+		return nil
+	})
+}
+
+func (w *volumeWatcher) run() {
+	ticker := time.NewTicker(time.Second)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-w.done:
+			return
+		case event, ok := <-w.watcher.Events:
+			if !ok {
+				return
+			}
+			w.handleEvent(event)
+		case err, ok := <-w.watcher.Errors:
+			if !ok {
+				return
+			}
+			w.tlog.Warnf("Watcher error on %v: %v", w.vol.Path, err)
+		case <-ticker.C:
+			w.flush()
+		}
+	}
+}
+
+func (w *volumeWatcher) handleEvent(event fsnotify.Event) {
+	if strings.HasPrefix(filepath.Base(event.Name), ".") {
+		return
+	}
+
+	if event.Has(fsnotify.Create) {
+		if fi, err := os.Stat(event.Name); err == nil && fi.IsDir() {
+			w.addRecursive(event.Name)
+		}
+	}
+
+	w.pendingMutex.Lock()
+	defer w.pendingMutex.Unlock()
+
+	// writes only push back files that are already pending, so a download in progress
+	// is processed once it has been quiet for the debounce period
+	if event.Has(fsnotify.Write) && !event.Has(fsnotify.Create) {
+		if _, ok := w.pending[event.Name]; !ok {
+			return
+		}
+	}
+	if event.Has(fsnotify.Create) || event.Has(fsnotify.Write) || event.Has(fsnotify.Rename) || event.Has(fsnotify.Remove) {
+		w.pending[event.Name] = time.Now()
+	}
+}
+
+// flush processes the paths that have not changed for the debounce period
+func (w *volumeWatcher) flush() {
+	debounce := time.Duration(config.Config.Storage.WatchDebounceSeconds) * time.Second
+	if debounce <= 0 {
+		debounce = time.Second
+	}
+
+	w.pendingMutex.Lock()
+	var paths []string
+	for path, lastEvent := range w.pending {
+		if time.Since(lastEvent) >= debounce {
+			paths = append(paths, path)
+		}
+	}
+	if len(paths) == 0 || models.CheckLock("rescan") {
+		// leave everything pending while a full rescan runs, it will be retried on the next tick
+		w.pendingMutex.Unlock()
+		return
+	}
+	for _, path := range paths {
+		delete(w.pending, path)
+	}
+	w.pendingMutex.Unlock()
+
+	processVolumeChanges(w.vol, paths, w.tlog)
+}
+
+// processVolumeChanges rescans only the files affected by filesystem events, matches
+// new files to scenes and refreshes the status of the scenes involved
+func processVolumeChanges(vol models.Volume, paths []string, tlog *logrus.Entry) {
+	volumeScanMutex.Lock()
+	defer volumeScanMutex.Unlock()
+
+	db, _ := models.GetDB()
+	defer db.Close()
+
+	allowedVideoExt := getAllowedVideoExt()
+	sceneIDs := map[uint]bool{}
+	var changed []string
+	var missing []string
+	scriptsChanged := false
+
+	var scanPath func(path string)
+	scanPath = func(path string) {
+		fi, err := os.Stat(path)
+		if err != nil {
+			// file or directory was removed or moved away
+			missing = append(missing, path)
+			return
+		}
+		if fi.IsDir() {
+			entries, err := os.ReadDir(path)
+			if err != nil {
+				return
+			}
+			for _, e := range entries {
+				if !strings.HasPrefix(e.Name(), ".") {
+					scanPath(filepath.Join(path, e.Name()))
+				}
+			}
+			return
+		}
+
+		ext := strings.ToLower(filepath.Ext(path))
+		switch {
+		case funk.ContainsString(allowedVideoExt, ext):
+			scanLocalVideoFile(path, vol.ID, db, tlog)
+		case ext == ".funscript" || ext == ".cmscript":
+			scanLocalScriptFile(path, vol.ID, db)
+			scriptsChanged = true
+		case ext == ".hsp":
+			ScanLocalHspFile(path, vol.ID, 0)
+		case ext == ".srt" || ext == ".ssa" || ext == ".ass":
+			ScanLocalSubtitlesFile(path, vol.ID, 0)
+		default:
+			return
+		}
+		changed = append(changed, path)
+	}
+
+	for _, path := range paths {
+		scanPath(path)
+	}
+
+	// removed files only refresh their scenes, the rescan deletes the records of files that are still missing
+	for _, path := range missing {
+		for _, id := range missingFileScenes(db, path, tlog) {
+			sceneIDs[id] = true
+		}
+	}
+
+	for _, path := range changed {
+		var fl models.File
+		if db.Where(&models.File{Path: filepath.Dir(path), Filename: filepath.Base(path)}).First(&fl).Error != nil {
+			continue
+		}
+		if fl.SceneID == 0 {
+			matchFileToScene(db, &fl)
+		}
+		if fl.SceneID != 0 {
+			sceneIDs[fl.SceneID] = true
+		}
+		tlog.Infof("Detected change to %v", path)
+	}
+
+	for id := range sceneIDs {
+		var scene models.Scene
+		if scene.GetIfExistByPK(id) == nil {
+			scene.UpdateStatus()
+		}
+	}
+
+	if scriptsChanged {
+		GenerateHeatmaps(nil)
+	}
+
+	if len(changed) > 0 || len(sceneIDs) > 0 {
+		common.PublishWS("state.change.optionsStorage", nil)
+	}
+}
+
+// missingFileScenes returns the ids of the scenes that referenced a removed file, or the files below a removed
+// directory. The records are kept until the next rescan: a file moved to another watched volume may not have been
+// picked up by that watcher yet, and it can only take over the record while the record still exists.
+func missingFileScenes(db *gorm.DB, path string, tlog *logrus.Entry) []uint {
+	var files []models.File
+	db.Where("(path = ? and filename = ?) or path = ? or path like ?",
+		filepath.Dir(path), filepath.Base(path), path, path+string(filepath.Separator)+"%").Find(&files)
+
+	var sceneIDs []uint
+	for i := range files {
+		if _, err := os.Stat(files[i].GetPath()); !os.IsNotExist(err) {
+			continue
+		}
+		tlog.Infof("Detected removal of %v", files[i].GetPath())
+		if files[i].SceneID != 0 {
+			sceneIDs = append(sceneIDs, files[i].SceneID)
+		}
+	}
+	return sceneIDs
+}
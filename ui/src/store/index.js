@@ -17,6 +17,7 @@ import optionsVendor from './optionsVendor'
 import optionsAdvanced from './optionsAdvanced'
 import optionsSceneCreate from './optionsSceneCreate'
 import health from './health'
+import notifications from './notifications'
 
 export default createStore({
   modules: {
@@ -37,5 +38,6 @@ export default createStore({
     optionsAdvanced,
     optionsSceneCreate,
     health,
+    notifications,
   }
 })
@@ -0,0 +1,37 @@
+import ky from '@/api'
+
+const state = {
+  unread: 0,
+  items: []
+}
+
+const mutations = {
+  setUnread (state, payload) {
+    state.unread = payload
+  }
+}
+
+const actions = {
+  async load ({ state }) {
+    await ky.get('/api/notifications', { searchParams: { limit: 20 } }).json()
+    .then(data => {
+      state.unread = data.unread
+      state.items = data.notifications
+    })
+  },
+  async markRead ({ dispatch }, id) {
+    await ky.post(`/api/notifications/${id}/read`)
+    dispatch('load')
+  },
+  async markAllRead ({ dispatch }) {
+    await ky.post('/api/notifications/read')
+    dispatch('load')
+  },
+}
+
+export default {
+  namespaced: true,
+  state,
+  mutations,
+  actions
+}
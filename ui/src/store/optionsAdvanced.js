@@ -13,6 +13,8 @@ const state = {
     useAltSrcInFileMatching: true,
     useAltSrcInScriptFilters: true,
     autoLimitScraping: true,
+    stageScrapedScenes: false,
+    stagingAutoApprove: 'new',
     ignoreReleasedBefore: null,
     collectorConfigs: null,
     flareSolverrAddress: '',
@@ -47,6 +49,8 @@ const actions = {
         state.advanced.useAltSrcInFileMatching = data.config.advanced.useAltSrcInFileMatching
         state.advanced.useAltSrcInScriptFilters = data.config.advanced.useAltSrcInScriptFilters
         state.advanced.autoLimitScraping = data.config.advanced.autoLimitScraping
+        state.advanced.stageScrapedScenes = data.config.advanced.stageScrapedScenes
+        state.advanced.stagingAutoApprove = data.config.advanced.stagingAutoApprove
         state.advanced.ignoreReleasedBefore = data.config.advanced.ignoreReleasedBefore
         state.advanced.flareSolverrAddress = data.config.advanced.flareSolverrAddress
         state.advanced.useFlareSolverr = data.config.advanced.useFlareSolverr
@@ -72,6 +76,8 @@ const actions = {
         state.advanced.useAltSrcInFileMatching = data.useAltSrcInFileMatching
         state.advanced.useAltSrcInScriptFilters = data.useAltSrcInScriptFilters
         state.advanced.autoLimitScraping = data.autoLimitScraping
+        state.advanced.stageScrapedScenes = data.stageScrapedScenes
+        state.advanced.stagingAutoApprove = data.stagingAutoApprove
         state.advanced.ignoreReleasedBefore = data.ignoreReleasedBefore
         state.advanced.flareSolverrAddress = data.flareSolverrAddress
         state.advanced.useFlareSolverr = data.useFlareSolverr
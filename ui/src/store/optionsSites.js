@@ -42,6 +42,14 @@ const actions = {
   async toggleUseProxy ({ commit }, params) {
     const items = await ky.put(`/api/options/sites/use_proxy/${params.id}`, { json: {}, timeout: 60000 }).json()
     commit('setItems', items)
+  },
+  async saveCacheTTL ({ commit }, params) {
+    const items = await ky.put(`/api/options/sites/cache_ttl/${params.id}`, { json: { cache_ttl: params.cacheTTL }, timeout: 60000 }).json()
+    commit('setItems', items)
+  },
+  async saveSchedule ({ commit }, params) {
+    const items = await ky.put(`/api/options/sites/schedule/${params.id}`, { json: params.schedule, timeout: 60000 }).json()
+    commit('setItems', items)
   }
 }
 
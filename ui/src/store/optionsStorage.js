@@ -7,6 +7,13 @@ const state = {
     forbidden_video_ext: [],
     video_ext: [],
     default_video_ext: [],
+    watch_volumes: true,
+    watch_debounce_seconds: 10,
+    fuzzy_match: true,
+    fuzzy_match_auto_link: 0.9,
+    fuzzy_match_min_confidence: 0.4,
+    organise: false,
+    organise_template: '',
   },  
 }
 
@@ -22,6 +29,13 @@ const actions = {
       state.options.forbidden_video_ext = data.forbidden_video_ext
       state.options.video_ext = data.video_ext
       state.options.default_video_ext = data.default_video_ext
+      state.options.watch_volumes = data.watch_volumes
+      state.options.watch_debounce_seconds = data.watch_debounce_seconds
+      state.options.fuzzy_match = data.fuzzy_match
+      state.options.fuzzy_match_auto_link = data.fuzzy_match_auto_link
+      state.options.fuzzy_match_min_confidence = data.fuzzy_match_min_confidence
+      state.options.organise = data.organise
+      state.options.organise_template = data.organise_template
     })
   },
   async save ({ state }) {
@@ -10,6 +10,7 @@ const defaultFilterState = {
   isHidden: false,
   isWatched: null,
   releaseMonth: '',
+  query: '',
   cast: [],
   sites: [],
   tags: [],
@@ -34,6 +35,7 @@ const state = {
     hidden: 0
   },
   show_scene_id: '',
+  queryError: '',
   filterOpts: {
     cast: [],
     sites: [],
@@ -154,7 +156,17 @@ const actions = {
       q.offset = iOffset
       q.limit = state.limit
 
-      const data = await ky.post('/api/scene/list', { json: q }).json()
+      let data
+      try {
+        data = await ky.post('/api/scene/list', { json: q }).json()
+        state.queryError = ''
+      } catch (error) {
+        if (error.response && error.response.status === 400) {
+          state.queryError = await error.response.text()
+          return
+        }
+        throw error
+      }
 
       if (iOffset === 0) {
         commit('setItems', data.scenes || [])